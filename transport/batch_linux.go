@@ -0,0 +1,131 @@
+//go:build linux && (amd64 || arm64)
+
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package transport
+
+import (
+	"net"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// mmsghdr mirrors struct mmsghdr for recvmmsg
+type mmsghdr struct {
+	hdr syscall.Msghdr
+	len uint32
+	_   [4]byte
+}
+
+// batchState holds the recvmmsg headers, reused between batches
+type batchState struct {
+	raw   syscall.RawConn
+	hdrs  []mmsghdr
+	iovs  []syscall.Iovec
+	names []syscall.RawSockaddrAny
+}
+
+// readBatch reads datagrams with recvmmsg, waiting on the runtime poller so
+// read deadlines and Close are honored.
+func (r *Receiver) readBatch(dst []Datagram) (int, error) {
+	b := &r.batch
+	if b.raw == nil {
+		raw, err := r.conn.SyscallConn()
+		if err != nil {
+			return 0, err
+		}
+		b.raw = raw
+	}
+	if len(b.hdrs) < len(dst) {
+		b.hdrs = make([]mmsghdr, len(dst))
+		b.iovs = make([]syscall.Iovec, len(dst))
+		b.names = make([]syscall.RawSockaddrAny, len(dst))
+	}
+	for i := range dst {
+		if len(dst[i].Buf) == 0 {
+			return 0, os.NewSyscallError("recvmmsg", syscall.EINVAL)
+		}
+		b.iovs[i].Base = &dst[i].Buf[0]
+		b.iovs[i].SetLen(len(dst[i].Buf))
+		b.hdrs[i] = mmsghdr{hdr: syscall.Msghdr{
+			Name:    (*byte)(unsafe.Pointer(&b.names[i])),
+			Namelen: uint32(unsafe.Sizeof(b.names[i])),
+			Iov:     &b.iovs[i],
+			Iovlen:  1,
+		}}
+	}
+	var n int
+	var errno syscall.Errno
+	err := b.raw.Read(func(fd uintptr) bool {
+		for {
+			r1, _, e := syscall.Syscall6(syscall.SYS_RECVMMSG, fd, uintptr(unsafe.Pointer(&b.hdrs[0])), uintptr(len(dst)), syscall.MSG_DONTWAIT, 0, 0)
+			switch e {
+			case syscall.EINTR:
+				continue
+			case syscall.EAGAIN:
+				return false
+			}
+			n, errno = int(r1), e
+			return true
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	if errno != 0 {
+		return 0, os.NewSyscallError("recvmmsg", errno)
+	}
+	for i := range dst[:n] {
+		dst[i].N = int(b.hdrs[i].len)
+		dst[i].Truncated = b.hdrs[i].hdr.Flags&syscall.MSG_TRUNC != 0
+		dst[i].Addr = udpAddr(&b.names[i], dst[i].Addr)
+	}
+	return n, nil
+}
+
+// udpAddr converts a socket address, reusing addr when it is non-nil
+func udpAddr(sa *syscall.RawSockaddrAny, addr *net.UDPAddr) *net.UDPAddr {
+	if addr == nil {
+		addr = &net.UDPAddr{}
+	}
+	switch sa.Addr.Family {
+	case syscall.AF_INET:
+		in := (*syscall.RawSockaddrInet4)(unsafe.Pointer(sa))
+		port := (*[2]byte)(unsafe.Pointer(&in.Port))
+		addr.IP = append(addr.IP[:0], in.Addr[:]...)
+		addr.Port = int(port[0])<<8 | int(port[1])
+		addr.Zone = ""
+	case syscall.AF_INET6:
+		in := (*syscall.RawSockaddrInet6)(unsafe.Pointer(sa))
+		port := (*[2]byte)(unsafe.Pointer(&in.Port))
+		addr.IP = append(addr.IP[:0], in.Addr[:]...)
+		addr.Port = int(port[0])<<8 | int(port[1])
+		addr.Zone = ""
+		if in.Scope_id != 0 {
+			if ifi, err := net.InterfaceByIndex(int(in.Scope_id)); err == nil {
+				addr.Zone = ifi.Name
+			}
+		}
+	default:
+		return nil
+	}
+	return addr
+}
@@ -0,0 +1,58 @@
+//go:build linux && (amd64 || arm64)
+
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package transport
+
+import (
+	"testing"
+
+	"github.com/geontech/vrtgen-go/vita49"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBatchTruncated(t *testing.T) {
+	r, s := loopback(t)
+	require.NoError(t, s.Send(dataPacket(1, 1, 2, 3, 4)))
+	require.NoError(t, s.Send(dataPacket(2)))
+
+	batch := []Datagram{{Buf: make([]byte, 8)}}
+	n, err := r.ReadBatch(batch)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.True(t, batch[0].Truncated)
+	assert.Equal(t, uint64(1), r.Counters().Errors)
+
+	// ReadPacket skips truncated datagrams
+	r.datagrams = r.datagrams[:1]
+	r.datagrams[0].Buf = r.datagrams[0].Buf[:8]
+	require.NoError(t, s.Send(dataPacket(3, 1, 2, 3, 4)))
+	require.NoError(t, s.Send(dataPacket(4)))
+	for _, expected := range []vita49.StreamID{2, 4} {
+		packet, _, err := r.ReadPacket()
+		require.NoError(t, err)
+		assert.Equal(t, expected, packet.(*vita49.SignalDataPacket).StreamID)
+	}
+	assert.Equal(t, uint64(2), r.Counters().Errors)
+
+	_, err = r.ReadBatch([]Datagram{{}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+//go:build !(linux && (amd64 || arm64))
+
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package transport
+
+// batchState is empty where recvmmsg is unavailable
+type batchState struct{}
+
+// readBatch reads a single datagram
+func (r *Receiver) readBatch(dst []Datagram) (int, error) {
+	n, addr, err := r.conn.ReadFromUDP(dst[0].Buf)
+	if err != nil {
+		return 0, err
+	}
+	dst[0].N, dst[0].Addr, dst[0].Truncated = n, addr, false
+	return 1, nil
+}
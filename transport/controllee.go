@@ -0,0 +1,37 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package transport
+
+import "github.com/geontech/vrtgen-go/vita49"
+
+// ControlleeHandler returns a Handler that passes each Control packet to c
+// and sends the acknowledgements it produces back on the connection. Other
+// packets are ignored.
+func ControlleeHandler(c *vita49.Controllee) Handler {
+	return HandlerFunc(func(conn *Conn, packet vita49.Packet) {
+		control, ok := packet.(*vita49.ControlPacket)
+		if !ok {
+			return
+		}
+		if acks := c.HandleControl(control); len(acks) > 0 {
+			conn.Send(acks...)
+		}
+	})
+}
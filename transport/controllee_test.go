@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package transport
+
+import (
+	"net"
+	"testing"
+
+	"github.com/geontech/vrtgen-go/vita49"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControlleeHandler(t *testing.T) {
+	gain := vita49.Gain{Stage1: 3}
+	controllee := vita49.NewControllee()
+	controllee.Handle(vita49.FieldID{Cif: 0, Bit: 23}, vita49.FieldHandlerFuncs{
+		ExecuteFunc: func(p *vita49.ControlPacket) (vita49.WarningErrorFields, vita49.WarningErrorFields) {
+			gain = p.Cif0.Gain
+			return vita49.WarningErrorFields{}, vita49.WarningErrorFields{}
+		},
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &Server{Handler: ControlleeHandler(controllee)}
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(l) }()
+	t.Cleanup(func() {
+		s.Close()
+		assert.ErrorIs(t, <-served, ErrClosed)
+	})
+
+	handler, packets := collect()
+	c := NewClient(l.Addr().String(), handler)
+	require.NoError(t, c.Connect())
+	defer c.Close()
+
+	request := control(5)
+	request.CAM = vita49.ControlCAM{CAM: vita49.CAM{ActionMode: vita49.Execute}, ReqX: true}
+	request.Cif0.IndicatorField0.Gain = true
+	request.Cif0.Gain = vita49.Gain{Stage1: 6}
+	// Packets other than Control packets are ignored
+	require.NoError(t, c.Send(&vita49.ContextPacket{StreamID: 1}, request))
+
+	ack, ok := receive(t, packets).(*vita49.ExecutionAckPacket)
+	require.True(t, ok)
+	assert.Equal(t, uint32(5), ack.MessageID)
+	assert.True(t, ack.CAM.ScheduledOrExecuted)
+	assert.Equal(t, vita49.Gain{Stage1: 6}, gain)
+}
@@ -0,0 +1,314 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/geontech/vrtgen-go/vita49"
+)
+
+var (
+	ErrMessageIDInUse = errors.New("transport: message ID in use by an outstanding request")
+	ErrCanceled       = errors.New("transport: request canceled")
+	ErrNoDeadline     = errors.New("transport: NackOnly request without a context deadline")
+)
+
+// PacketSender is satisfied by Client, Conn and Sender
+type PacketSender interface {
+	Send(packets ...vita49.Packet) error
+}
+
+// Result
+// Holds the acknowledgements received for a Control packet. An
+// acknowledgement that was not requested, or did not arrive, is nil.
+type Result struct {
+	Validation *vita49.ValidationAckPacket
+	Execution  *vita49.ExecutionAckPacket
+	Query      *vita49.QueryAckPacket
+}
+
+// Warnings returns the warnings reported for each field by the Validation
+// and Execution acknowledgements. A field reported by both carries the
+// union of the two.
+func (r *Result) Warnings() map[vita49.FieldID]vita49.WarningErrorFields {
+	fields := make(map[vita49.FieldID]vita49.WarningErrorFields)
+	if r.Validation != nil {
+		mergeWarningErrorFields(fields, r.Validation.Warnings)
+	}
+	if r.Execution != nil {
+		mergeWarningErrorFields(fields, r.Execution.Warnings)
+	}
+	return fields
+}
+
+// Errors returns the errors reported for each field by the Validation and
+// Execution acknowledgements. A field reported by both carries the union of
+// the two.
+func (r *Result) Errors() map[vita49.FieldID]vita49.WarningErrorFields {
+	fields := make(map[vita49.FieldID]vita49.WarningErrorFields)
+	if r.Validation != nil {
+		mergeWarningErrorFields(fields, r.Validation.Errors)
+	}
+	if r.Execution != nil {
+		mergeWarningErrorFields(fields, r.Execution.Errors)
+	}
+	return fields
+}
+
+func mergeWarningErrorFields(dst, src map[vita49.FieldID]vita49.WarningErrorFields) {
+	for id, field := range src {
+		current := dst[id]
+		merged := current.Pack()
+		for i, b := range field.Pack() {
+			merged[i] |= b
+		}
+		var f vita49.WarningErrorFields
+		f.Unpack(merged)
+		dst[id] = f
+	}
+}
+
+// controlleeIdentity is the Controllee identifier of a command packet, with
+// the unused form zeroed
+type controlleeIdentity struct {
+	enable bool
+	format vita49.IdentifierFormat
+	id     uint32
+	uuid   vita49.Uuid
+}
+
+func controlleeOf(prologue *vita49.CommandPrologue, cam *vita49.CAM) controlleeIdentity {
+	if !cam.ControlleeEnable {
+		return controlleeIdentity{}
+	}
+	if cam.ControlleeFormat == vita49.UUID {
+		return controlleeIdentity{enable: true, format: vita49.UUID, uuid: prologue.ControlleeUUID}
+	}
+	return controlleeIdentity{enable: true, format: vita49.Word, id: prologue.ControlleeID}
+}
+
+// pendingRequest is a Control packet awaiting its acknowledgements
+type pendingRequest struct {
+	cam        vita49.ControlCAM
+	controllee controlleeIdentity
+	result     Result
+	err        error
+	done       chan struct{}
+}
+
+func (r *pendingRequest) complete() bool {
+	return (!r.cam.ReqV || r.result.Validation != nil) &&
+		(!r.cam.ReqX || r.result.Execution != nil) &&
+		(!r.cam.ReqS || r.result.Query != nil)
+}
+
+// Controller
+// Sends Control packets through a PacketSender and waits for the
+// acknowledgements they request. Acknowledgements are matched to outstanding
+// requests by Message ID and, when the request names a controllee, by
+// Controllee identifier. They reach the Controller through ServePacket, so a
+// Controller is normally the Handler of the Client it sends through; packets
+// from other transports are passed to Deliver. Packets that do not match an
+// outstanding request are passed to Handler when it is set.
+//
+// When CancelOnDone is set and the context of a request is done before its
+// acknowledgements arrive, the Controller sends a cancellation packet for
+// the request. Request then returns the context error joined with any error
+// sending the cancellation.
+type Controller struct {
+	Handler      Handler
+	CancelOnDone bool
+
+	sender    PacketSender
+	messageID atomic.Uint32
+	mu        sync.Mutex
+	pending   map[uint32]*pendingRequest
+}
+
+func NewController(sender PacketSender) *Controller {
+	return &Controller{
+		sender:  sender,
+		pending: make(map[uint32]*pendingRequest),
+	}
+}
+
+// ServePacket implements Handler by delivering packet to the request
+// awaiting it, or to Handler when none is.
+func (c *Controller) ServePacket(conn *Conn, packet vita49.Packet) {
+	if !c.Deliver(packet) && c.Handler != nil {
+		c.Handler.ServePacket(conn, packet)
+	}
+}
+
+// Deliver hands an acknowledgement to the outstanding request awaiting it
+// and reports whether there was one.
+func (c *Controller) Deliver(packet vita49.Packet) bool {
+	var prologue *vita49.CommandPrologue
+	var cam *vita49.CAM
+	switch ack := packet.(type) {
+	case *vita49.ValidationAckPacket:
+		prologue, cam = &ack.CommandPrologue, &ack.CAM.CAM
+	case *vita49.ExecutionAckPacket:
+		prologue, cam = &ack.CommandPrologue, &ack.CAM.CAM
+	case *vita49.QueryAckPacket:
+		prologue, cam = &ack.CommandPrologue, &ack.CAM.CAM
+	default:
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.pending[prologue.MessageID]
+	if !ok {
+		return false
+	}
+	if r.controllee.enable && r.controllee != controlleeOf(prologue, cam) {
+		return false
+	}
+	switch ack := packet.(type) {
+	case *vita49.ValidationAckPacket:
+		if !r.cam.ReqV || r.result.Validation != nil {
+			return false
+		}
+		r.result.Validation = ack
+	case *vita49.ExecutionAckPacket:
+		if !r.cam.ReqX || r.result.Execution != nil {
+			return false
+		}
+		r.result.Execution = ack
+	case *vita49.QueryAckPacket:
+		if !r.cam.ReqS || r.result.Query != nil {
+			return false
+		}
+		r.result.Query = ack
+	}
+	if r.complete() {
+		delete(c.pending, prologue.MessageID)
+		close(r.done)
+	}
+	return true
+}
+
+// Request assigns p the next Message ID, sends it and waits until the
+// acknowledgements requested by its ControlCAM arrive or ctx is done.
+//
+// When ctx is done first, Request returns the acknowledgements received so
+// far along with the context error, and when the request is canceled with
+// Cancel it returns them along with ErrCanceled. With NackOnly set, a
+// controllee only sends Validation and Execution acknowledgements that
+// report warnings or errors, so their absence is not an error: Request then
+// waits for ctx and returns without error unless a requested Query
+// acknowledgement is missing. ctx must have a deadline for such a request,
+// or Request returns ErrNoDeadline without sending it.
+func (c *Controller) Request(ctx context.Context, p *vita49.ControlPacket) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if p.CAM.NackOnly && (p.CAM.ReqV || p.CAM.ReqX) {
+		if _, ok := ctx.Deadline(); !ok {
+			return nil, ErrNoDeadline
+		}
+	}
+	p.MessageID = c.messageID.Add(1)
+	p.Header.Cancellation = false
+	if !p.CAM.ReqV && !p.CAM.ReqX && !p.CAM.ReqS {
+		return &Result{}, c.sender.Send(p)
+	}
+	r := &pendingRequest{
+		cam:        p.CAM,
+		controllee: controlleeOf(&p.CommandPrologue, &p.CAM.CAM),
+		done:       make(chan struct{}),
+	}
+	c.mu.Lock()
+	if _, ok := c.pending[p.MessageID]; ok {
+		c.mu.Unlock()
+		return nil, ErrMessageIDInUse
+	}
+	c.pending[p.MessageID] = r
+	c.mu.Unlock()
+
+	if err := c.sender.Send(p); err != nil {
+		c.remove(p.MessageID, r)
+		return nil, err
+	}
+	select {
+	case <-r.done:
+		return &r.result, r.err
+	case <-ctx.Done():
+	}
+	if !c.remove(p.MessageID, r) {
+		// The last acknowledgement, or Cancel, arrived along with the deadline
+		return &r.result, r.err
+	}
+	if p.CAM.NackOnly && (!p.CAM.ReqS || r.result.Query != nil) {
+		return &r.result, nil
+	}
+	if c.CancelOnDone {
+		if err := c.sender.Send(cancellation(p)); err != nil {
+			return &r.result, errors.Join(ctx.Err(), err)
+		}
+	}
+	return &r.result, ctx.Err()
+}
+
+// Cancel sends a cancellation packet for p, a Control packet previously sent
+// with Request. The cancellation carries the Message ID and CIF fields of p
+// but requests no acknowledgements, since they could not be told apart from
+// those of p. When the request for p is still outstanding, it returns
+// ErrCanceled once the cancellation is sent, and acknowledgements for p that
+// arrive later are passed to Handler.
+func (c *Controller) Cancel(p *vita49.ControlPacket) error {
+	if err := c.sender.Send(cancellation(p)); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r, ok := c.pending[p.MessageID]; ok {
+		delete(c.pending, p.MessageID)
+		r.err = ErrCanceled
+		close(r.done)
+	}
+	return nil
+}
+
+func cancellation(p *vita49.ControlPacket) *vita49.ControlPacket {
+	cancel := *p
+	cancel.Header.Cancellation = true
+	cancel.CAM.ReqV = false
+	cancel.CAM.ReqX = false
+	cancel.CAM.ReqS = false
+	return &cancel
+}
+
+// remove drops r from the outstanding requests and reports whether it was
+// still outstanding
+func (c *Controller) remove(messageID uint32, r *pendingRequest) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending[messageID] != r {
+		return false
+	}
+	delete(c.pending, messageID)
+	return true
+}
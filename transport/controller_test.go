@@ -0,0 +1,369 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/geontech/vrtgen-go/vita49"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var gainID = vita49.FieldID{Cif: 0, Bit: 23}
+
+// controlleeLink passes the Control packets sent to it to controllee, when
+// set, and delivers the acknowledgements to controller. Sent packets are
+// recorded.
+type controlleeLink struct {
+	mu         sync.Mutex
+	controllee *vita49.Controllee
+	controller *Controller
+	sent       []vita49.ControlPacket
+	err        error
+}
+
+func (l *controlleeLink) Send(packets ...vita49.Packet) error {
+	if err := l.sendErr(); err != nil {
+		return err
+	}
+	for _, packet := range packets {
+		control := packet.(*vita49.ControlPacket)
+		l.mu.Lock()
+		l.sent = append(l.sent, *control)
+		l.mu.Unlock()
+		if l.controllee != nil {
+			for _, ack := range l.controllee.HandleControl(control) {
+				l.controller.Deliver(ack)
+			}
+		}
+	}
+	return nil
+}
+
+func (l *controlleeLink) sendErr() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+func (l *controlleeLink) setSendErr(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.err = err
+}
+
+func (l *controlleeLink) packets() []vita49.ControlPacket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]vita49.ControlPacket(nil), l.sent...)
+}
+
+// gainControllee warns about gains above 10 dB and applies any gain
+func gainControllee(gain *vita49.Gain) *vita49.Controllee {
+	c := vita49.NewControllee()
+	c.Handle(gainID, vita49.FieldHandlerFuncs{
+		ValidateFunc: func(p *vita49.ControlPacket) (vita49.WarningErrorFields, vita49.WarningErrorFields) {
+			if p.Cif0.Gain.Stage1 > 10 {
+				return vita49.WarningErrorFields{HazardousPowerLevels: true}, vita49.WarningErrorFields{}
+			}
+			return vita49.WarningErrorFields{}, vita49.WarningErrorFields{}
+		},
+		ExecuteFunc: func(p *vita49.ControlPacket) (vita49.WarningErrorFields, vita49.WarningErrorFields) {
+			*gain = p.Cif0.Gain
+			return vita49.WarningErrorFields{Distortion: true}, vita49.WarningErrorFields{}
+		},
+		QueryFunc: func(cifs *vita49.Cifs) {
+			cifs.Cif0.Gain = *gain
+		},
+	})
+	return c
+}
+
+func gainRequest(cam vita49.ControlCAM, stage1 float64) *vita49.ControlPacket {
+	p := &vita49.ControlPacket{CAM: cam}
+	p.Cif0.IndicatorField0.Gain = true
+	p.Cif0.Gain = vita49.Gain{Stage1: stage1}
+	return p
+}
+
+func newLinkedController(controllee *vita49.Controllee) (*Controller, *controlleeLink) {
+	l := &controlleeLink{controllee: controllee}
+	l.controller = NewController(l)
+	return l.controller, l
+}
+
+func TestControllerRequest(t *testing.T) {
+	var gain vita49.Gain
+	c, l := newLinkedController(gainControllee(&gain))
+
+	cam := vita49.ControlCAM{
+		CAM:  vita49.CAM{ActionMode: vita49.Execute, PermitWarnings: true},
+		ReqV: true, ReqX: true, ReqS: true, ReqW: true, ReqEr: true,
+	}
+	result, err := c.Request(context.Background(), gainRequest(cam, 12))
+	require.NoError(t, err)
+	require.NotNil(t, result.Validation)
+	require.NotNil(t, result.Execution)
+	require.NotNil(t, result.Query)
+	assert.Equal(t, uint32(1), result.Validation.MessageID)
+	assert.True(t, result.Execution.CAM.ScheduledOrExecuted)
+	assert.Equal(t, vita49.Gain{Stage1: 12}, result.Query.Cif0.Gain)
+	assert.Equal(t, map[vita49.FieldID]vita49.WarningErrorFields{
+		gainID: {HazardousPowerLevels: true, Distortion: true},
+	}, result.Warnings())
+	assert.Empty(t, result.Errors())
+	assert.Equal(t, vita49.Gain{Stage1: 12}, gain)
+
+	// Message IDs are assigned in sequence
+	result, err = c.Request(context.Background(), gainRequest(vita49.ControlCAM{ReqS: true}, 0))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), result.Query.MessageID)
+	assert.Nil(t, result.Validation)
+	assert.Nil(t, result.Execution)
+
+	// Without requested acknowledgements the packet is only sent
+	result, err = c.Request(context.Background(), gainRequest(vita49.ControlCAM{CAM: vita49.CAM{ActionMode: vita49.Execute}}, 3))
+	require.NoError(t, err)
+	assert.Equal(t, &Result{}, result)
+	assert.Equal(t, vita49.Gain{Stage1: 3}, gain)
+	assert.Len(t, l.packets(), 3)
+}
+
+func TestControllerTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		cam          vita49.ControlCAM
+		cancelOnDone bool
+		err          error
+	}{
+		{name: "timeout", cam: vita49.ControlCAM{ReqV: true}, err: context.DeadlineExceeded},
+		{name: "cancel on done", cam: vita49.ControlCAM{ReqX: true}, cancelOnDone: true, err: context.DeadlineExceeded},
+		{name: "nack only", cam: vita49.ControlCAM{CAM: vita49.CAM{NackOnly: true}, ReqV: true, ReqX: true}, cancelOnDone: true},
+		{name: "nack only query", cam: vita49.ControlCAM{CAM: vita49.CAM{NackOnly: true}, ReqS: true}, err: context.DeadlineExceeded},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, l := newLinkedController(nil)
+			c.CancelOnDone = test.cancelOnDone
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			result, err := c.Request(ctx, gainRequest(test.cam, 1))
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, &Result{}, result)
+
+			packets := l.packets()
+			if test.cancelOnDone && test.err != nil {
+				require.Len(t, packets, 2)
+				assert.True(t, packets[1].Header.Cancellation)
+				assert.Equal(t, packets[0].MessageID, packets[1].MessageID)
+				assert.False(t, packets[1].CAM.ReqX)
+				assert.Equal(t, packets[0].Cifs, packets[1].Cifs)
+			} else {
+				assert.Len(t, packets, 1)
+			}
+			// The request is no longer outstanding
+			assert.Empty(t, c.pending)
+		})
+	}
+}
+
+func TestControllerCancelOnDoneSendError(t *testing.T) {
+	c, l := newLinkedController(nil)
+	c.CancelOnDone = true
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Request(ctx, gainRequest(vita49.ControlCAM{ReqX: true}, 1))
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return len(l.packets()) == 1 }, time.Second, time.Millisecond)
+
+	// The cancellation cannot be sent once the request is done
+	sendErr := errors.New("send failed")
+	l.setSendErr(sendErr)
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, err, sendErr)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the request")
+	}
+	assert.Len(t, l.packets(), 1)
+	assert.Empty(t, c.pending)
+}
+
+func TestControllerCancel(t *testing.T) {
+	c, l := newLinkedController(nil)
+	request := gainRequest(vita49.ControlCAM{ReqV: true, ReqX: true}, 1)
+	type response struct {
+		result *Result
+		err    error
+	}
+	done := make(chan response, 1)
+	go func() {
+		result, err := c.Request(context.Background(), request)
+		done <- response{result, err}
+	}()
+	require.Eventually(t, func() bool { return len(l.packets()) == 1 }, time.Second, time.Millisecond)
+
+	// A validation arrives before the request is canceled
+	validation := &vita49.ValidationAckPacket{CommandPrologue: vita49.CommandPrologue{MessageID: request.MessageID}}
+	require.True(t, c.Deliver(validation))
+
+	require.NoError(t, c.Cancel(request))
+	select {
+	case r := <-done:
+		assert.ErrorIs(t, r.err, ErrCanceled)
+		assert.Equal(t, &Result{Validation: validation}, r.result)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the canceled request")
+	}
+	assert.Empty(t, c.pending)
+
+	// The cancellation carries the Message ID and fields of the request but
+	// requests no acknowledgements
+	packets := l.packets()
+	require.Len(t, packets, 2)
+	assert.True(t, packets[1].Header.Cancellation)
+	assert.Equal(t, request.MessageID, packets[1].MessageID)
+	assert.Equal(t, request.Cifs, packets[1].Cifs)
+	assert.False(t, packets[1].CAM.ReqV)
+	assert.False(t, packets[1].CAM.ReqX)
+	assert.False(t, request.Header.Cancellation)
+
+	// A late acknowledgement of the canceled request is unmatched
+	assert.False(t, c.Deliver(&vita49.ExecutionAckPacket{CommandPrologue: vita49.CommandPrologue{MessageID: request.MessageID}}))
+
+	// Canceling a request that is no longer outstanding only sends
+	require.NoError(t, c.Cancel(request))
+	assert.Len(t, l.packets(), 3)
+
+	l.err = errors.New("send failed")
+	assert.ErrorIs(t, c.Cancel(request), l.err)
+
+	// A context that is already done sends nothing
+	l.err = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.Request(ctx, request)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, l.packets(), 3)
+}
+
+func TestControllerNackOnlyDeadline(t *testing.T) {
+	c, l := newLinkedController(nil)
+	cam := vita49.ControlCAM{CAM: vita49.CAM{NackOnly: true}, ReqX: true}
+	_, err := c.Request(context.Background(), gainRequest(cam, 1))
+	assert.ErrorIs(t, err, ErrNoDeadline)
+	assert.Empty(t, l.packets())
+	assert.Empty(t, c.pending)
+
+	// A Query acknowledgement always arrives, so no deadline is needed
+	var gain vita49.Gain
+	c, _ = newLinkedController(gainControllee(&gain))
+	result, err := c.Request(context.Background(), gainRequest(vita49.ControlCAM{CAM: vita49.CAM{NackOnly: true}, ReqS: true}, 1))
+	require.NoError(t, err)
+	assert.NotNil(t, result.Query)
+}
+
+func TestControllerSendError(t *testing.T) {
+	c, l := newLinkedController(nil)
+	l.err = errors.New("send failed")
+	_, err := c.Request(context.Background(), gainRequest(vita49.ControlCAM{ReqV: true}, 1))
+	assert.ErrorIs(t, err, l.err)
+	assert.Empty(t, c.pending)
+}
+
+func TestControllerDeliver(t *testing.T) {
+	handler, unmatched := collect()
+	c, l := newLinkedController(nil)
+	c.Handler = handler
+
+	request := gainRequest(vita49.ControlCAM{ReqS: true}, 1)
+	request.CAM.ControlleeEnable = true
+	request.ControlleeID = 9
+	done := make(chan *Result, 1)
+	go func() {
+		result, err := c.Request(context.Background(), request)
+		assert.NoError(t, err)
+		done <- result
+	}()
+	require.Eventually(t, func() bool { return len(l.packets()) == 1 }, time.Second, time.Millisecond)
+	messageID := l.packets()[0].MessageID
+
+	ack := func(controlleeID uint32) *vita49.QueryAckPacket {
+		return &vita49.QueryAckPacket{
+			CommandPrologue: vita49.CommandPrologue{MessageID: messageID, ControlleeID: controlleeID},
+			CAM:             vita49.AcknowledgeCAM{CAM: vita49.CAM{ControlleeEnable: true}, AckS: true},
+		}
+	}
+	// Unsolicited and mismatched packets go to Handler
+	c.ServePacket(nil, &vita49.ContextPacket{StreamID: 1})
+	c.ServePacket(nil, ack(8))
+	c.ServePacket(nil, &vita49.ValidationAckPacket{CommandPrologue: vita49.CommandPrologue{MessageID: messageID}})
+	assert.IsType(t, &vita49.ContextPacket{}, receive(t, unmatched))
+	assert.Equal(t, uint32(8), receive(t, unmatched).(*vita49.QueryAckPacket).ControlleeID)
+	assert.IsType(t, &vita49.ValidationAckPacket{}, receive(t, unmatched))
+
+	c.ServePacket(nil, ack(9))
+	select {
+	case result := <-done:
+		assert.Equal(t, uint32(9), result.Query.ControlleeID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for result")
+	}
+	// The request is complete, so a repeated acknowledgement is unmatched
+	assert.False(t, c.Deliver(ack(9)))
+}
+
+func TestControllerTCP(t *testing.T) {
+	var gain vita49.Gain
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &Server{Handler: ControlleeHandler(gainControllee(&gain))}
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(l) }()
+	t.Cleanup(func() {
+		s.Close()
+		assert.ErrorIs(t, <-served, ErrClosed)
+	})
+
+	client := NewClient(l.Addr().String(), nil)
+	c := NewController(client)
+	client.Handler = c
+	require.NoError(t, client.Connect())
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cam := vita49.ControlCAM{CAM: vita49.CAM{ActionMode: vita49.Execute}, ReqX: true, ReqS: true, ReqW: true}
+	result, err := c.Request(ctx, gainRequest(cam, 4))
+	require.NoError(t, err)
+	assert.Equal(t, map[vita49.FieldID]vita49.WarningErrorFields{gainID: {Distortion: true}}, result.Warnings())
+	assert.Equal(t, vita49.Gain{Stage1: 4}, result.Query.Cif0.Gain)
+}
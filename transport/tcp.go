@@ -0,0 +1,447 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package transport
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/geontech/vrtgen-go/vita49"
+)
+
+const (
+	DefaultMinBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff = 10 * time.Second
+)
+
+var (
+	ErrClosed       = errors.New("transport: closed")
+	ErrNotConnected = errors.New("transport: not connected")
+)
+
+// Handler
+// Receives the packets read from a TCP connection. Packets from one
+// connection are handled in order on a single goroutine, and conn may be
+// used to reply, for example with an acknowledgement.
+type Handler interface {
+	ServePacket(conn *Conn, packet vita49.Packet)
+}
+
+// HandlerFunc adapts a function to a Handler
+type HandlerFunc func(conn *Conn, packet vita49.Packet)
+
+func (f HandlerFunc) ServePacket(conn *Conn, packet vita49.Packet) {
+	f(conn, packet)
+}
+
+// Conn
+// A TCP connection carrying VITA 49 packets in VITA 49.1 VRL frames. Send
+// may be called concurrently with ReadPacket and with itself.
+type Conn struct {
+	conn     net.Conn
+	reader   *vita49.VrlReader
+	pending  [][]byte
+	counts   packetCounts
+	counters *counters
+
+	writeMu     sync.Mutex
+	writer      *vita49.VrlWriter
+	buf         []byte
+	offsets     []int
+	frame       [][]byte
+	writeCounts packetCounts
+}
+
+// NewConn carries packets over conn, with a CRC in each frame written when
+// crc is set. The Conn takes ownership of conn.
+func NewConn(conn net.Conn, crc bool) *Conn {
+	return newConn(conn, crc, &counters{})
+}
+
+func newConn(conn net.Conn, crc bool, c *counters) *Conn {
+	return &Conn{
+		conn:        conn,
+		reader:      vita49.NewVrlReader(conn),
+		counts:      packetCounts{},
+		counters:    c,
+		writer:      vita49.NewVrlWriter(conn, crc),
+		writeCounts: packetCounts{},
+	}
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *Conn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Conn) Counters() Counters {
+	return c.counters.snapshot()
+}
+
+// ReadPacket returns the next packet, reading a frame when the packets of
+// the last one are exhausted. A corrupt frame or a packet that fails to
+// unpack returns an error from the vita49 package, after which reading may
+// continue; any other error means the connection is no longer usable. The
+// packet refers to a buffer that the next call may overwrite.
+func (c *Conn) ReadPacket() (vita49.Packet, error) {
+	for len(c.pending) == 0 {
+		frame, err := c.reader.ReadFrame()
+		if err != nil {
+			c.counters.errors.Add(1)
+			return nil, err
+		}
+		c.counters.datagrams.Add(1)
+		c.pending = frame.Packets
+	}
+	buf := c.pending[0]
+	c.pending = c.pending[1:]
+	packet, _, err := vita49.UnmarshalPacket(buf)
+	if err != nil {
+		c.counters.errors.Add(1)
+		return nil, err
+	}
+	c.counters.drops.Add(c.counts.missed(buf))
+	c.counters.packets.Add(1)
+	return packet, nil
+}
+
+// Send writes packets in a single VRL frame, numbering the PacketCount of
+// each stream as Sender does. Nothing is sent when a packet cannot be packed.
+func (c *Conn) Send(packets ...vita49.Packet) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.buf = c.buf[:0]
+	c.offsets = append(c.offsets[:0], 0)
+	for _, packet := range packets {
+		var err error
+		if c.buf, err = packet.AppendPack(c.buf); err != nil {
+			c.counters.errors.Add(1)
+			return err
+		}
+		c.offsets = append(c.offsets, len(c.buf))
+	}
+	c.frame = c.frame[:0]
+	for i := range packets {
+		start := c.offsets[i]
+		c.buf[start+1] = c.buf[start+1]&0xF0 | c.writeCounts.next(c.buf[start:])
+		c.frame = append(c.frame, c.buf[start:c.offsets[i+1]])
+	}
+	if err := c.writer.WriteFrame(c.frame...); err != nil {
+		c.counters.errors.Add(1)
+		return err
+	}
+	c.counters.datagrams.Add(1)
+	c.counters.packets.Add(uint64(len(packets)))
+	return nil
+}
+
+// serve hands packets to handler until the connection fails
+func (c *Conn) serve(handler Handler) error {
+	for {
+		packet, err := c.ReadPacket()
+		if err != nil {
+			if recoverable(err) {
+				continue
+			}
+			return err
+		}
+		if handler != nil {
+			handler.ServePacket(c, packet)
+		}
+	}
+}
+
+// recoverable reports whether err was a malformed frame or packet rather
+// than a failure of the connection
+func recoverable(err error) bool {
+	for _, target := range []error{
+		vita49.ErrFrameAlignment,
+		vita49.ErrCrc,
+		vita49.ErrShortBuffer,
+		vita49.ErrSizeMismatch,
+		vita49.ErrReservedBits,
+		vita49.ErrListCount,
+		vita49.ErrPacketType,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Server
+// Accepts TCP connections and hands the packets read from each to Handler.
+// Frames written carry a CRC when Crc is set.
+type Server struct {
+	Handler Handler
+	Crc     bool
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[*Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// ListenAndServe listens on address, such as ":4991", and serves
+func (s *Server) ListenAndServe(address string) error {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l until Close is called, when it returns
+// ErrClosed.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return ErrClosed
+	}
+	s.listener = l
+	if s.conns == nil {
+		s.conns = map[*Conn]struct{}{}
+	}
+	s.mu.Unlock()
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrClosed
+			}
+			return err
+		}
+		conn := NewConn(nc, s.Crc)
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return ErrClosed
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+		go func() {
+			defer s.wg.Done()
+			conn.serve(s.Handler)
+			conn.Close()
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Close stops accepting connections, closes the open ones and waits for
+// their handlers to return.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// Client
+// Keeps a TCP connection to a server open, redialing with exponential
+// backoff between MinBackoff and MaxBackoff when it fails, and hands the
+// packets it reads to Handler. Frames written carry a CRC when Crc is set.
+// Fields must be set before Connect. Counters accumulate across
+// connections.
+type Client struct {
+	Address    string
+	Handler    Handler
+	Crc        bool
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	Dialer     net.Dialer
+
+	mu       sync.Mutex
+	conn     *Conn
+	closed   bool
+	closing  chan struct{}
+	done     chan struct{}
+	counters counters
+}
+
+func NewClient(address string, handler Handler) *Client {
+	return &Client{
+		Address:    address,
+		Handler:    handler,
+		MinBackoff: DefaultMinBackoff,
+		MaxBackoff: DefaultMaxBackoff,
+	}
+}
+
+// Connect dials the server and, once connected, keeps the connection open
+// in the background until Close is called.
+func (c *Client) Connect() error {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	conn, err := c.dial()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	if c.closed || c.done != nil {
+		c.mu.Unlock()
+		conn.Close()
+		if c.closed {
+			return ErrClosed
+		}
+		return errors.New("transport: client already connected")
+	}
+	c.conn = conn
+	c.closing = make(chan struct{})
+	c.done = make(chan struct{})
+	c.mu.Unlock()
+	go c.run(conn)
+	return nil
+}
+
+func (c *Client) dial() (*Conn, error) {
+	nc, err := c.Dialer.Dial("tcp", c.Address)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(nc, c.Crc, &c.counters), nil
+}
+
+func (c *Client) run(conn *Conn) {
+	defer close(c.done)
+	for {
+		conn.serve(c.Handler)
+		conn.Close()
+		if conn = c.redial(); conn == nil {
+			return
+		}
+	}
+}
+
+// redial replaces the failed connection, returning nil once the client is
+// closed
+func (c *Client) redial() *Conn {
+	c.mu.Lock()
+	c.conn = nil
+	c.mu.Unlock()
+	backoff := c.MinBackoff
+	if backoff <= 0 {
+		backoff = DefaultMinBackoff
+	}
+	maxBackoff := max(c.MaxBackoff, backoff)
+	for {
+		select {
+		case <-c.closing:
+			return nil
+		case <-time.After(backoff):
+		}
+		conn, err := c.dial()
+		if err != nil {
+			backoff = min(2*backoff, maxBackoff)
+			continue
+		}
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			conn.Close()
+			return nil
+		}
+		c.conn = conn
+		c.mu.Unlock()
+		return conn
+	}
+}
+
+// Connected reports whether the client currently has a connection
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+// Send writes packets in a single frame on the current connection. It
+// returns ErrNotConnected while the client is redialing.
+func (c *Client) Send(packets ...vita49.Packet) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return ErrNotConnected
+	}
+	return conn.Send(packets...)
+}
+
+func (c *Client) Counters() Counters {
+	return c.counters.snapshot()
+}
+
+// Close closes the connection and stops redialing
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	conn := c.conn
+	done := c.done
+	if c.closing != nil {
+		close(c.closing)
+	}
+	c.mu.Unlock()
+	if conn != nil {
+		conn.Close()
+	}
+	if done != nil {
+		<-done
+	}
+	return nil
+}
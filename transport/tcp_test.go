@@ -0,0 +1,176 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package transport
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/geontech/vrtgen-go/vita49"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ackHandler answers each Control packet with a Query acknowledgement
+var ackHandler = HandlerFunc(func(conn *Conn, packet vita49.Packet) {
+	if control, ok := packet.(*vita49.ControlPacket); ok {
+		conn.Send(&vita49.QueryAckPacket{CommandPrologue: vita49.CommandPrologue{
+			StreamID:  control.StreamID,
+			MessageID: control.MessageID,
+		}})
+	}
+})
+
+func startServer(t *testing.T, address string) (*Server, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", address)
+	require.NoError(t, err)
+	s := &Server{Handler: ackHandler, Crc: true}
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(l) }()
+	t.Cleanup(func() {
+		s.Close()
+		assert.ErrorIs(t, <-served, ErrClosed)
+	})
+	return s, l.Addr().String()
+}
+
+func collect() (Handler, chan vita49.Packet) {
+	packets := make(chan vita49.Packet, 16)
+	return HandlerFunc(func(conn *Conn, packet vita49.Packet) {
+		packets <- packet
+	}), packets
+}
+
+func receive(t *testing.T, packets chan vita49.Packet) vita49.Packet {
+	t.Helper()
+	select {
+	case packet := <-packets:
+		return packet
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for packet")
+		return nil
+	}
+}
+
+func control(messageID uint32) *vita49.ControlPacket {
+	return &vita49.ControlPacket{CommandPrologue: vita49.CommandPrologue{StreamID: 1, MessageID: messageID}}
+}
+
+func TestTCPClientServer(t *testing.T) {
+	_, address := startServer(t, "127.0.0.1:0")
+	handler, packets := collect()
+	c := NewClient(address, handler)
+	c.Crc = true
+	require.NoError(t, c.Connect())
+	defer c.Close()
+	assert.True(t, c.Connected())
+
+	require.NoError(t, c.Send(control(1), control(2)))
+	require.NoError(t, c.Send(control(3)))
+	for _, expected := range []uint32{1, 2, 3} {
+		ack, ok := receive(t, packets).(*vita49.QueryAckPacket)
+		require.True(t, ok)
+		assert.Equal(t, expected, ack.MessageID)
+		assert.Equal(t, vita49.StreamID(1), ack.StreamID)
+	}
+	assert.Equal(t, Counters{Datagrams: 5, Packets: 6}, c.Counters())
+}
+
+func TestTCPReconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := l.Addr().String()
+	first := &Server{Handler: ackHandler}
+	go first.Serve(l)
+
+	handler, packets := collect()
+	c := NewClient(address, handler)
+	c.MinBackoff = 10 * time.Millisecond
+	c.MaxBackoff = 20 * time.Millisecond
+	require.NoError(t, c.Connect())
+	defer c.Close()
+	require.NoError(t, c.Send(control(1)))
+	receive(t, packets)
+
+	// The client notices the server going away and redials
+	first.Close()
+	require.Eventually(t, func() bool { return !c.Connected() }, 5*time.Second, time.Millisecond)
+	assert.ErrorIs(t, c.Send(control(2)), ErrNotConnected)
+
+	startServer(t, address)
+	require.Eventually(t, c.Connected, 5*time.Second, time.Millisecond)
+	require.NoError(t, c.Send(control(3)))
+	ack := receive(t, packets).(*vita49.QueryAckPacket)
+	assert.Equal(t, uint32(3), ack.MessageID)
+
+	require.NoError(t, c.Close())
+	assert.False(t, c.Connected())
+	assert.ErrorIs(t, c.Send(control(4)), ErrNotConnected)
+}
+
+func TestTCPConnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := l.Addr().String()
+	l.Close()
+	c := NewClient(address, nil)
+	assert.Error(t, c.Connect())
+	assert.NoError(t, c.Close())
+	assert.ErrorIs(t, c.Connect(), ErrClosed)
+}
+
+func TestConnRecovery(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	conn := NewConn(server, false)
+	defer conn.Close()
+
+	frame := func(packets ...[]byte) []byte {
+		return (&vita49.VrlFrame{Packets: packets, Crc: true}).Pack()
+	}
+	good := control(1).Pack()
+	bad := control(2).Pack()
+	bad[11] = 1
+	corrupt := frame(good)
+	corrupt[12] ^= 0xFF
+	go func() {
+		client.Write([]byte{0xDE, 0xAD, 0xBE, 0xEF, 0, 0, 0, 0})
+		client.Write(corrupt)
+		client.Write(frame(bad, good))
+	}()
+
+	_, err := conn.ReadPacket()
+	assert.ErrorIs(t, err, vita49.ErrFrameAlignment)
+	assert.True(t, recoverable(err))
+	_, err = conn.ReadPacket()
+	assert.ErrorIs(t, err, vita49.ErrCrc)
+	_, err = conn.ReadPacket()
+	assert.ErrorIs(t, err, vita49.ErrReservedBits)
+	packet, err := conn.ReadPacket()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), packet.(*vita49.ControlPacket).MessageID)
+	assert.Equal(t, Counters{Datagrams: 1, Packets: 1, Errors: 3}, conn.Counters())
+
+	client.Close()
+	_, err = conn.ReadPacket()
+	assert.False(t, recoverable(err))
+}
@@ -0,0 +1,320 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+// Package transport carries VITA 49 packets over network sockets.
+package transport
+
+import (
+	"encoding/binary"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/geontech/vrtgen-go/vita49"
+)
+
+const (
+	// MaxDatagramSize is the largest UDP payload over IPv4
+	MaxDatagramSize = 65507
+	// DefaultBatchSize is the number of datagrams a Receiver reads at once
+	DefaultBatchSize = 32
+)
+
+// Counters
+// A snapshot of the counters of a socket. Datagrams counts UDP datagrams or
+// VRL frames. Drops counts packets missing from the 4-bit PacketCount
+// sequence of each stream, so a run of 16 or more missing packets is
+// undercounted. Errors counts failed reads and writes, truncated datagrams,
+// corrupt frames and packets that failed to unpack.
+type Counters struct {
+	Datagrams uint64
+	Packets   uint64
+	Drops     uint64
+	Errors    uint64
+}
+
+type counters struct {
+	datagrams atomic.Uint64
+	packets   atomic.Uint64
+	drops     atomic.Uint64
+	errors    atomic.Uint64
+}
+
+func (c *counters) snapshot() Counters {
+	return Counters{
+		Datagrams: c.datagrams.Load(),
+		Packets:   c.packets.Load(),
+		Drops:     c.drops.Load(),
+		Errors:    c.errors.Load(),
+	}
+}
+
+// streamKey identifies a PacketCount sequence. Each packet type of a stream
+// counts separately.
+type streamKey struct {
+	streamID   uint32
+	packetType vita49.PacketType
+}
+
+// packetCounts follows the PacketCount of each stream
+type packetCounts map[streamKey]uint8
+
+func streamKeyOf(packet []byte) streamKey {
+	key := streamKey{packetType: vita49.PacketType(packet[0] >> 4)}
+	if key.packetType.HasStreamID() && len(packet) >= 8 {
+		key.streamID = binary.BigEndian.Uint32(packet[4:])
+	}
+	return key
+}
+
+// missed returns the number of packets skipped before packet, which must
+// hold at least a header
+func (p packetCounts) missed(packet []byte) uint64 {
+	key := streamKeyOf(packet)
+	count := packet[1] & 0x0F
+	last, ok := p[key]
+	p[key] = count
+	if !ok {
+		return 0
+	}
+	return uint64((count - last - 1) & 0x0F)
+}
+
+// next returns the PacketCount for the next packet of the stream
+func (p packetCounts) next(packet []byte) uint8 {
+	key := streamKeyOf(packet)
+	count, ok := p[key]
+	if ok {
+		count = (count + 1) & 0x0F
+	}
+	p[key] = count
+	return count
+}
+
+// Datagram
+// A buffer for ReadBatch. N is the length of the datagram read into Buf and
+// Addr is the address it came from. Truncated is set when the datagram did
+// not fit in Buf, which is only detected where batching is available.
+type Datagram struct {
+	Buf       []byte
+	N         int
+	Addr      *net.UDPAddr
+	Truncated bool
+}
+
+// Receiver
+// Reads VITA 49 packets from a UDP socket. On Linux datagrams are read in
+// batches with recvmmsg; elsewhere they are read one at a time. A Receiver
+// is not safe for concurrent reads, but Counters may be called at any time.
+type Receiver struct {
+	conn      *net.UDPConn
+	batch     batchState
+	datagrams []Datagram
+	next      int
+	read      int
+	pending   []byte
+	addr      *net.UDPAddr
+	counts    packetCounts
+	counters  counters
+}
+
+// ListenUDP listens for unicast datagrams on address, such as ":4991"
+func ListenUDP(address string) (*Receiver, error) {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewReceiver(conn, DefaultBatchSize), nil
+}
+
+// ListenMulticastUDP joins the multicast group address, such as
+// "239.1.2.3:4991", on ifi, or on the system-chosen interface when ifi is
+// nil.
+func ListenMulticastUDP(ifi *net.Interface, address string) (*Receiver, error) {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenMulticastUDP("udp", ifi, addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewReceiver(conn, DefaultBatchSize), nil
+}
+
+// NewReceiver reads from conn, reading up to batchSize datagrams at once.
+// The Receiver takes ownership of conn.
+func NewReceiver(conn *net.UDPConn, batchSize int) *Receiver {
+	r := &Receiver{
+		conn:      conn,
+		datagrams: make([]Datagram, max(batchSize, 1)),
+		counts:    packetCounts{},
+	}
+	for i := range r.datagrams {
+		r.datagrams[i].Buf = make([]byte, MaxDatagramSize)
+	}
+	return r
+}
+
+func (r *Receiver) LocalAddr() net.Addr {
+	return r.conn.LocalAddr()
+}
+
+func (r *Receiver) SetReadDeadline(t time.Time) error {
+	return r.conn.SetReadDeadline(t)
+}
+
+// SetReadBuffer sets the size of the operating system's receive buffer,
+// which is usually the first place packets are dropped at high rates.
+func (r *Receiver) SetReadBuffer(bytes int) error {
+	return r.conn.SetReadBuffer(bytes)
+}
+
+func (r *Receiver) Close() error {
+	return r.conn.Close()
+}
+
+func (r *Receiver) Counters() Counters {
+	return r.counters.snapshot()
+}
+
+// ReadBatch reads up to len(dst) datagrams into the caller's buffers,
+// blocking until at least one arrives, and returns the number read. The
+// datagrams are counted but not unpacked.
+func (r *Receiver) ReadBatch(dst []Datagram) (int, error) {
+	if len(dst) == 0 {
+		return 0, nil
+	}
+	n, err := r.readBatch(dst)
+	if err != nil {
+		r.counters.errors.Add(1)
+		return 0, err
+	}
+	r.counters.datagrams.Add(uint64(n))
+	for i := range dst[:n] {
+		if dst[i].Truncated {
+			r.counters.errors.Add(1)
+		}
+	}
+	return n, nil
+}
+
+// ReadPacket returns the next packet and the address it came from. A
+// datagram may hold several packets, and a packet that fails to unpack
+// discards the rest of its datagram. The packet refers to the receive
+// buffers and is only valid until the next call.
+func (r *Receiver) ReadPacket() (vita49.Packet, *net.UDPAddr, error) {
+	for len(r.pending) == 0 {
+		if r.next == r.read {
+			n, err := r.ReadBatch(r.datagrams)
+			if err != nil {
+				return nil, nil, err
+			}
+			r.next, r.read = 0, n
+		}
+		d := &r.datagrams[r.next]
+		r.next++
+		if !d.Truncated {
+			r.pending, r.addr = d.Buf[:d.N], d.Addr
+		}
+	}
+	packet, rest, err := vita49.UnmarshalPacket(r.pending)
+	if err != nil {
+		r.pending = nil
+		r.counters.errors.Add(1)
+		return nil, r.addr, err
+	}
+	r.counters.drops.Add(r.counts.missed(r.pending))
+	r.pending = rest
+	r.counters.packets.Add(1)
+	return packet, r.addr, nil
+}
+
+// Sender
+// Writes VITA 49 packets to a UDP socket. The Sender numbers the
+// PacketCount of each stream in the datagrams it writes, leaving the
+// packets themselves untouched. A Sender is not safe for concurrent use,
+// but Counters may be called at any time.
+type Sender struct {
+	conn     *net.UDPConn
+	buf      []byte
+	offsets  []int
+	counts   packetCounts
+	counters counters
+}
+
+// DialUDP sends to address, which may be a unicast or multicast address
+func DialUDP(address string) (*Sender, error) {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewSender(conn), nil
+}
+
+// NewSender writes to conn, which must be connected. The Sender takes
+// ownership of conn.
+func NewSender(conn *net.UDPConn) *Sender {
+	return &Sender{conn: conn, counts: packetCounts{}}
+}
+
+func (s *Sender) LocalAddr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+func (s *Sender) Close() error {
+	return s.conn.Close()
+}
+
+func (s *Sender) Counters() Counters {
+	return s.counters.snapshot()
+}
+
+// Send packs packets into a single datagram and writes it. Nothing is sent,
+// and no PacketCount is used up, when a packet cannot be packed.
+func (s *Sender) Send(packets ...vita49.Packet) error {
+	s.buf = s.buf[:0]
+	s.offsets = s.offsets[:0]
+	for _, packet := range packets {
+		s.offsets = append(s.offsets, len(s.buf))
+		var err error
+		if s.buf, err = packet.AppendPack(s.buf); err != nil {
+			s.counters.errors.Add(1)
+			return err
+		}
+	}
+	for _, start := range s.offsets {
+		s.buf[start+1] = s.buf[start+1]&0xF0 | s.counts.next(s.buf[start:])
+	}
+	if _, err := s.conn.Write(s.buf); err != nil {
+		s.counters.errors.Add(1)
+		return err
+	}
+	s.counters.datagrams.Add(1)
+	s.counters.packets.Add(uint64(len(packets)))
+	return nil
+}
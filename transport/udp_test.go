@@ -0,0 +1,172 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package transport
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/geontech/vrtgen-go/vita49"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loopback(t *testing.T) (*Receiver, *Sender) {
+	t.Helper()
+	r, err := ListenUDP("127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { r.Close() })
+	s, err := DialUDP(r.LocalAddr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	require.NoError(t, r.SetReadDeadline(time.Now().Add(5*time.Second)))
+	return r, s
+}
+
+func dataPacket(streamID vita49.StreamID, payload ...byte) *vita49.SignalDataPacket {
+	return &vita49.SignalDataPacket{
+		Header:   vita49.DataHeader{Header: vita49.Header{PacketType: vita49.SignalDataStreamID}},
+		StreamID: streamID,
+		Payload:  payload,
+	}
+}
+
+func TestUDPLoopback(t *testing.T) {
+	r, s := loopback(t)
+
+	context := &vita49.ContextPacket{StreamID: 1}
+	require.NoError(t, s.Send(dataPacket(1, 1, 2, 3, 4), context))
+	require.NoError(t, s.Send(dataPacket(1, 5, 6, 7, 8)))
+
+	packet, addr, err := r.ReadPacket()
+	require.NoError(t, err)
+	assert.Equal(t, s.LocalAddr().String(), addr.String())
+	data, ok := packet.(*vita49.SignalDataPacket)
+	require.True(t, ok)
+	assert.Equal(t, vita49.StreamID(1), data.StreamID)
+	assert.Equal(t, []byte{1, 2, 3, 4}, data.Payload)
+	assert.Equal(t, uint8(0), data.Header.PacketCount)
+
+	packet, _, err = r.ReadPacket()
+	require.NoError(t, err)
+	assert.IsType(t, &vita49.ContextPacket{}, packet)
+
+	packet, _, err = r.ReadPacket()
+	require.NoError(t, err)
+	data = packet.(*vita49.SignalDataPacket)
+	assert.Equal(t, []byte{5, 6, 7, 8}, data.Payload)
+	assert.Equal(t, uint8(1), data.Header.PacketCount)
+
+	assert.Equal(t, Counters{Datagrams: 2, Packets: 3}, s.Counters())
+	assert.Equal(t, Counters{Datagrams: 2, Packets: 3}, r.Counters())
+}
+
+func TestUDPCounters(t *testing.T) {
+	r, s := loopback(t)
+	raw, err := net.DialUDP("udp", nil, r.LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer raw.Close()
+
+	// Stream 1 skips two packets and stream 2 counts separately
+	for _, p := range []struct {
+		streamID vita49.StreamID
+		count    uint8
+	}{{1, 14}, {2, 0}, {1, 15}, {1, 2}, {2, 1}} {
+		packet := dataPacket(p.streamID, 0, 0, 0, 0)
+		packet.Header.PacketCount = p.count
+		_, err := raw.Write(packet.Pack())
+		require.NoError(t, err)
+	}
+	for i := 0; i < 5; i++ {
+		_, _, err := r.ReadPacket()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, Counters{Datagrams: 5, Packets: 5, Drops: 2}, r.Counters())
+
+	// A malformed datagram is an error that does not stop the stream
+	_, err = raw.Write([]byte{0x10, 0, 0, 9, 0, 0, 0, 1})
+	require.NoError(t, err)
+	require.NoError(t, s.Send(dataPacket(3)))
+	_, _, err = r.ReadPacket()
+	assert.ErrorIs(t, err, vita49.ErrShortBuffer)
+	packet, _, err := r.ReadPacket()
+	require.NoError(t, err)
+	assert.Equal(t, vita49.StreamID(3), packet.(*vita49.SignalDataPacket).StreamID)
+	assert.Equal(t, Counters{Datagrams: 7, Packets: 6, Drops: 2, Errors: 1}, r.Counters())
+
+	// Read deadlines surface as errors
+	require.NoError(t, r.SetReadDeadline(time.Now().Add(10*time.Millisecond)))
+	_, _, err = r.ReadPacket()
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+	assert.Equal(t, uint64(2), r.Counters().Errors)
+}
+
+func TestUDPReadBatch(t *testing.T) {
+	r, s := loopback(t)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.Send(dataPacket(vita49.StreamID(i), byte(i), 0, 0, 0)))
+	}
+	batch := make([]Datagram, 8)
+	for i := range batch {
+		batch[i].Buf = make([]byte, 64)
+	}
+	var received []byte
+	for len(received) < 5 {
+		n, err := r.ReadBatch(batch)
+		require.NoError(t, err)
+		assert.Greater(t, n, 0)
+		for _, d := range batch[:n] {
+			assert.Equal(t, 12, d.N)
+			assert.False(t, d.Truncated)
+			assert.Equal(t, s.LocalAddr().String(), d.Addr.String())
+			received = append(received, d.Buf[8])
+		}
+	}
+	assert.Equal(t, []byte{0, 1, 2, 3, 4}, received)
+	assert.Equal(t, uint64(5), r.Counters().Datagrams)
+}
+
+func TestUDPMulticast(t *testing.T) {
+	group := "239.255.49.2:0"
+	r, err := ListenMulticastUDP(nil, group)
+	if err != nil {
+		t.Skipf("multicast unavailable: %v", err)
+	}
+	defer r.Close()
+	port := r.LocalAddr().(*net.UDPAddr).Port
+	s, err := DialUDP(net.JoinHostPort("239.255.49.2", strconv.Itoa(port)))
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, r.SetReadDeadline(time.Now().Add(time.Second)))
+	if err := s.Send(dataPacket(9)); err != nil {
+		t.Skipf("multicast unavailable: %v", err)
+	}
+	packet, _, err := r.ReadPacket()
+	if err, ok := err.(net.Error); ok && err.Timeout() {
+		t.Skip("multicast not looped back")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, vita49.StreamID(9), packet.(*vita49.SignalDataPacket).StreamID)
+}
@@ -20,12 +20,68 @@
 package vita49
 
 import (
-	"bytes"
 	"encoding/binary"
+	"time"
 )
 
+// Cif0
+// Holds the CIF0 indicator bits along with the value of each field. A value
+// is only packed when its indicator bit is set.
 type Cif0 struct {
 	IndicatorField0
+	ReferencePointID         StreamID
+	Bandwidth                Frequency
+	IfRefFrequency           Frequency
+	RfRefFrequency           Frequency
+	RfRefFrequencyOffset     Frequency
+	IfBandOffset             Frequency
+	ReferenceLevel           ReferenceLevel
+	Gain                     Gain
+	OverRangeCount           uint32
+	SampleRate               Frequency
+	TimestampAdjustment      TimestampAdjustment
+	TimestampCalibrationTime uint32
+	Temperature              Temperature
+	DeviceID                 DeviceIdentifier
+	StateEventIndicators     StateEventIndicators
+	SignalDataFormat         PayloadFormat
+	FormattedGps             Geolocation
+	FormattedIns             Geolocation
+	EcefEphemeris            Ephemeris
+	RelativeEphemeris        Ephemeris
+	EphemerisRefID           uint32
+	GpsAscii                 GpsAscii
+	ContextAssociationLists  ContextAssociationLists
+}
+
+// fields lists the CIF0 fields in packing order (bit 31 down to bit 0)
+func (c *Cif0) fields() []cifField {
+	f := &c.IndicatorField0
+	return []cifField{
+		{f.ReferencePointID, &c.ReferencePointID},
+		{f.Bandwidth, &c.Bandwidth},
+		{f.IfRefFrequency, &c.IfRefFrequency},
+		{f.RfRefFrequency, &c.RfRefFrequency},
+		{f.RfRefFrequencyOffset, &c.RfRefFrequencyOffset},
+		{f.IfBandOffset, &c.IfBandOffset},
+		{f.ReferenceLevel, &c.ReferenceLevel},
+		{f.Gain, &c.Gain},
+		{f.OverRangeCount, (*word32)(&c.OverRangeCount)},
+		{f.SampleRate, &c.SampleRate},
+		{f.TimestampAdjustment, &c.TimestampAdjustment},
+		{f.TimestampCalibrationTime, (*word32)(&c.TimestampCalibrationTime)},
+		{f.Temperature, &c.Temperature},
+		{f.DeviceID, &c.DeviceID},
+		{f.StateEventIndicators, &c.StateEventIndicators},
+		{f.SignalDataFormat, &c.SignalDataFormat},
+		{f.FormattedGps, &c.FormattedGps},
+		{f.FormattedIns, &c.FormattedIns},
+		{f.EcefEphemeris, &c.EcefEphemeris},
+		{f.RelativeEphemeris, &c.RelativeEphemeris},
+		{f.EphemerisRefID, (*word32)(&c.EphemerisRefID)},
+		{f.GpsAscii, &c.GpsAscii},
+		{f.ContextAssociationLists, &c.ContextAssociationLists},
+	}
 }
 
 // Gain
@@ -40,9 +96,18 @@ func (g *Gain) Size() uint32 {
 
 func (g *Gain) Pack() []byte {
 	buf := make([]byte, g.Size())
+	g.PackInto(buf)
+	return buf
+}
+
+func (g *Gain) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Gain", buf, g.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:g.Size()]
 	binary.BigEndian.PutUint16(buf[2:], uint16(ToFixed16(g.Stage1, 7)))
 	binary.BigEndian.PutUint16(buf[0:], uint16(ToFixed16(g.Stage2, 7)))
-	return buf
+	return len(buf), nil
 }
 
 func (g *Gain) Unpack(buf []byte) {
@@ -50,6 +115,188 @@ func (g *Gain) Unpack(buf []byte) {
 	g.Stage2 = FromFixed(int16(binary.BigEndian.Uint16(buf[0:])), 7)
 }
 
+func (g *Gain) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Gain", buf, g.Size()); err != nil {
+		return err
+	}
+	g.Unpack(buf)
+	return nil
+}
+
+// Frequency
+// A frequency in Hz, packed as a 64-bit two's-complement fixed-point number
+// with the radix point to the right of bit 20. Bandwidth, the IF and RF
+// reference frequencies and their offsets, and the sample rate all use this
+// format, giving a range of about +/-8.79 THz with a resolution of 0.95 uHz.
+type Frequency float64
+
+func (f *Frequency) Size() uint32 {
+	return 8
+}
+
+func (f *Frequency) Pack() []byte {
+	buf := make([]byte, f.Size())
+	f.PackInto(buf)
+	return buf
+}
+
+func (f *Frequency) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Frequency", buf, f.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint64(buf, uint64(ToFixed64(float64(*f), 20)))
+	return int(f.Size()), nil
+}
+
+func (f *Frequency) Unpack(buf []byte) {
+	*f = Frequency(FromFixed(int64(binary.BigEndian.Uint64(buf)), 20))
+}
+
+func (f *Frequency) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Frequency", buf, f.Size()); err != nil {
+		return err
+	}
+	f.Unpack(buf)
+	return nil
+}
+
+// ReferenceLevel
+// The power level in dBm corresponding to a full-scale sample, packed in the
+// lower 16 bits of the word as a two's-complement fixed-point number with the
+// radix point to the right of bit 7. The upper 16 bits are reserved.
+type ReferenceLevel float64
+
+func (r *ReferenceLevel) Size() uint32 {
+	return 4
+}
+
+func (r *ReferenceLevel) Pack() []byte {
+	buf := make([]byte, r.Size())
+	r.PackInto(buf)
+	return buf
+}
+
+func (r *ReferenceLevel) PackInto(buf []byte) (int, error) {
+	if err := checkSize("ReferenceLevel", buf, r.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint16(buf[0:], 0) // reserved bits
+	binary.BigEndian.PutUint16(buf[2:], uint16(ToFixed16(float64(*r), 7)))
+	return int(r.Size()), nil
+}
+
+func (r *ReferenceLevel) Unpack(buf []byte) {
+	*r = ReferenceLevel(FromFixed(int16(binary.BigEndian.Uint16(buf[2:])), 7))
+}
+
+func (r *ReferenceLevel) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("ReferenceLevel", buf, r.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("ReferenceLevel", buf, 0, 0xFFFF0000); err != nil {
+		return err
+	}
+	r.Unpack(buf)
+	return nil
+}
+
+// Femtoseconds
+// A time interval in femtoseconds, packed as a 64-bit two's-complement
+// integer. The CIF0 Timestamp Adjustment and the CIF3 temporal fields use
+// this format.
+type Femtoseconds int64
+
+// Duration returns the interval as a time.Duration, truncated to whole
+// nanoseconds
+func (f Femtoseconds) Duration() time.Duration {
+	return time.Duration(f / 1e6)
+}
+
+// FemtosecondsFromDuration converts d to femtoseconds
+func FemtosecondsFromDuration(d time.Duration) Femtoseconds {
+	return Femtoseconds(d) * 1e6
+}
+
+// TimestampAdjustment
+// The time in femtoseconds to add to the packet timestamps to obtain the
+// time of the reference point. It is packed as Femtoseconds.
+type TimestampAdjustment = Femtoseconds
+
+// TimestampAdjustmentFromDuration converts d to femtoseconds
+func TimestampAdjustmentFromDuration(d time.Duration) TimestampAdjustment {
+	return FemtosecondsFromDuration(d)
+}
+
+func (f *Femtoseconds) Size() uint32 {
+	return 8
+}
+
+func (f *Femtoseconds) Pack() []byte {
+	buf := make([]byte, f.Size())
+	f.PackInto(buf)
+	return buf
+}
+
+func (f *Femtoseconds) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Femtoseconds", buf, f.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint64(buf, uint64(*f))
+	return int(f.Size()), nil
+}
+
+func (f *Femtoseconds) Unpack(buf []byte) {
+	*f = Femtoseconds(binary.BigEndian.Uint64(buf))
+}
+
+func (f *Femtoseconds) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Femtoseconds", buf, f.Size()); err != nil {
+		return err
+	}
+	f.Unpack(buf)
+	return nil
+}
+
+// Temperature
+// A temperature in degrees Celsius, packed in the lower 16 bits of the word
+// as a two's-complement fixed-point number with the radix point to the right
+// of bit 6. The upper 16 bits are reserved.
+type Temperature float64
+
+func (t *Temperature) Size() uint32 {
+	return 4
+}
+
+func (t *Temperature) Pack() []byte {
+	buf := make([]byte, t.Size())
+	t.PackInto(buf)
+	return buf
+}
+
+func (t *Temperature) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Temperature", buf, t.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint16(buf[0:], 0) // reserved bits
+	binary.BigEndian.PutUint16(buf[2:], uint16(ToFixed16(float64(*t), 6)))
+	return int(t.Size()), nil
+}
+
+func (t *Temperature) Unpack(buf []byte) {
+	*t = Temperature(FromFixed(int16(binary.BigEndian.Uint16(buf[2:])), 6))
+}
+
+func (t *Temperature) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Temperature", buf, t.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("Temperature", buf, 0, 0xFFFF0000); err != nil {
+		return err
+	}
+	t.Unpack(buf)
+	return nil
+}
+
 // Device ID
 type DeviceIdentifier struct {
 	ManufacturerOui uint32
@@ -62,11 +309,20 @@ func (d *DeviceIdentifier) Size() uint32 {
 
 func (d *DeviceIdentifier) Pack() []byte {
 	buf := make([]byte, d.Size())
+	d.PackInto(buf)
+	return buf
+}
+
+func (d *DeviceIdentifier) PackInto(buf []byte) (int, error) {
+	if err := checkSize("DeviceIdentifier", buf, d.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:d.Size()]
 	d.ManufacturerOui &= 0x00FFFFFF
 	binary.BigEndian.PutUint32(buf[0:], d.ManufacturerOui)
 	binary.BigEndian.PutUint16(buf[4:], 0) // reserved bits
 	binary.BigEndian.PutUint16(buf[6:], d.DeviceCode)
-	return buf
+	return len(buf), nil
 }
 
 func (d *DeviceIdentifier) Unpack(buf []byte) {
@@ -74,6 +330,20 @@ func (d *DeviceIdentifier) Unpack(buf []byte) {
 	d.DeviceCode = binary.BigEndian.Uint16(buf[6:])
 }
 
+func (d *DeviceIdentifier) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("DeviceIdentifier", buf, d.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("DeviceIdentifier", buf, 0, 0xFF000000); err != nil {
+		return err
+	}
+	if err := checkReserved("DeviceIdentifier", buf, 4, 0xFFFF0000); err != nil {
+		return err
+	}
+	d.Unpack(buf)
+	return nil
+}
+
 // Ephemeris
 type Ephemeris struct {
 	Tsi                 Tsi
@@ -115,8 +385,35 @@ func (e *Ephemeris) Size() uint32 {
 	return 52
 }
 
+// Timestamp returns the timestamp words as described by Tsi and Tsf
+func (e *Ephemeris) Timestamp() Timestamp {
+	return Timestamp{
+		Tsi:        e.Tsi,
+		Tsf:        e.Tsf,
+		Integer:    e.IntegerTimestamp,
+		Fractional: e.FractionalTimestamp,
+	}
+}
+
+// SetTimestamp sets Tsi, Tsf and the timestamp words from t
+func (e *Ephemeris) SetTimestamp(t Timestamp) {
+	e.Tsi = t.Tsi
+	e.Tsf = t.Tsf
+	e.IntegerTimestamp = t.Integer
+	e.FractionalTimestamp = t.Fractional
+}
+
 func (e *Ephemeris) Pack() []byte {
 	buf := make([]byte, e.Size())
+	e.PackInto(buf)
+	return buf
+}
+
+func (e *Ephemeris) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Ephemeris", buf, e.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:e.Size()]
 	word1 := uint32(0)
 	word1 |= uint32((e.Tsi & 3)) << 26
 	word1 |= uint32((e.Tsf & 3)) << 24
@@ -133,7 +430,7 @@ func (e *Ephemeris) Pack() []byte {
 	binary.BigEndian.PutUint32(buf[40:], uint32(ToFixed32(e.VelocityDx, 16)))
 	binary.BigEndian.PutUint32(buf[44:], uint32(ToFixed32(e.VelocityDy, 16)))
 	binary.BigEndian.PutUint32(buf[48:], uint32(ToFixed32(e.VelocityDz, 16)))
-	return buf
+	return len(buf), nil
 }
 
 func (e *Ephemeris) Unpack(buf []byte) {
@@ -154,6 +451,17 @@ func (e *Ephemeris) Unpack(buf []byte) {
 	e.VelocityDz = FromFixed(int32(binary.BigEndian.Uint32(buf[48:])), 16)
 }
 
+func (e *Ephemeris) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Ephemeris", buf, e.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("Ephemeris", buf, 0, 0xF0000000); err != nil {
+		return err
+	}
+	e.Unpack(buf)
+	return nil
+}
+
 // Geolocation
 type Geolocation struct {
 	Tsi                 Tsi
@@ -191,8 +499,35 @@ func (g *Geolocation) Size() uint32 {
 	return 44
 }
 
+// Timestamp returns the timestamp words as described by Tsi and Tsf
+func (g *Geolocation) Timestamp() Timestamp {
+	return Timestamp{
+		Tsi:        g.Tsi,
+		Tsf:        g.Tsf,
+		Integer:    g.IntegerTimestamp,
+		Fractional: g.FractionalTimestamp,
+	}
+}
+
+// SetTimestamp sets Tsi, Tsf and the timestamp words from t
+func (g *Geolocation) SetTimestamp(t Timestamp) {
+	g.Tsi = t.Tsi
+	g.Tsf = t.Tsf
+	g.IntegerTimestamp = t.Integer
+	g.FractionalTimestamp = t.Fractional
+}
+
 func (g *Geolocation) Pack() []byte {
 	buf := make([]byte, g.Size())
+	g.PackInto(buf)
+	return buf
+}
+
+func (g *Geolocation) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Geolocation", buf, g.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:g.Size()]
 	word1 := uint32(0)
 	word1 |= uint32((g.Tsi & 3)) << 26
 	word1 |= uint32((g.Tsf & 3)) << 24
@@ -208,7 +543,7 @@ func (g *Geolocation) Pack() []byte {
 	binary.BigEndian.PutUint32(buf[32:], uint32(ToFixed32(g.HeadingAngle, 22)))
 	binary.BigEndian.PutUint32(buf[36:], uint32(ToFixed32(g.TrackAngle, 22)))
 	binary.BigEndian.PutUint32(buf[40:], uint32(ToFixed32(g.MagneticVariation, 22)))
-	return buf
+	return len(buf), nil
 }
 
 func (g *Geolocation) Unpack(buf []byte) {
@@ -227,6 +562,17 @@ func (g *Geolocation) Unpack(buf []byte) {
 	g.MagneticVariation = FromFixed(int32(binary.BigEndian.Uint32(buf[40:])), 22)
 }
 
+func (g *Geolocation) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Geolocation", buf, g.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("Geolocation", buf, 0, 0xF0000000); err != nil {
+		return err
+	}
+	g.Unpack(buf)
+	return nil
+}
+
 // GPS ASCII
 type GpsAscii struct {
 	ManufacturerOui uint32
@@ -247,20 +593,42 @@ func (g *GpsAscii) Size() uint32 {
 
 func (g *GpsAscii) Pack() []byte {
 	buf := make([]byte, g.Size())
+	g.PackInto(buf)
+	return buf
+}
+
+func (g *GpsAscii) PackInto(buf []byte) (int, error) {
+	if err := checkSize("GpsAscii", buf, g.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:g.Size()]
+	clear(buf)
 	binary.BigEndian.PutUint32(buf[0:], g.ManufacturerOui)
 	buf[0] = 0x00
 	binary.BigEndian.PutUint32(buf[4:], g.NumberOfWords)
+	// Padding when number of ascii bytes not divis by 4 was cleared above
 	copy(buf[8:], g.AsciiSentences)
-	// Add padding when number of ascii bytes not divis by 4
-	padding := 4*g.NumberOfWords - uint32(len(g.AsciiSentences))
-	copy(buf[8+len(g.AsciiSentences):], make([]byte, padding))
-	return buf
+	return len(buf), nil
 }
 
 func (g *GpsAscii) Unpack(buf []byte) {
 	g.ManufacturerOui = binary.BigEndian.Uint32(buf[0:]) & 0x00FFFFFF
 	g.NumberOfWords = binary.BigEndian.Uint32(buf[4:])
-	g.AsciiSentences = buf[8:]
+	g.AsciiSentences = buf[8 : 8+g.NumberOfWords*4]
+}
+
+func (g *GpsAscii) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("GpsAscii", buf, 8); err != nil {
+		return err
+	}
+	if err := checkReserved("GpsAscii", buf, 0, 0xFF000000); err != nil {
+		return err
+	}
+	if err := checkWords("GpsAscii", buf, 8, binary.BigEndian.Uint32(buf[4:])); err != nil {
+		return err
+	}
+	g.Unpack(buf)
+	return nil
 }
 
 // Payload Format
@@ -284,6 +652,16 @@ func (p *PayloadFormat) Size() uint32 {
 
 func (p *PayloadFormat) Pack() []byte {
 	buf := make([]byte, p.Size())
+	p.PackInto(buf)
+	return buf
+}
+
+func (p *PayloadFormat) PackInto(buf []byte) (int, error) {
+	if err := checkSize("PayloadFormat", buf, p.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:p.Size()]
+	clear(buf)
 	word1 := uint32(0)
 	if p.PackingMethod {
 		word1 |= uint32(1) << 31
@@ -319,7 +697,7 @@ func (p *PayloadFormat) Pack() []byte {
 	} else {
 		binary.BigEndian.PutUint16(buf[6:], 0)
 	}
-	return buf
+	return len(buf), nil
 }
 
 func (p *PayloadFormat) Unpack(buf []byte) {
@@ -360,6 +738,14 @@ func (p *PayloadFormat) Unpack(buf []byte) {
 	}
 }
 
+func (p *PayloadFormat) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("PayloadFormat", buf, p.Size()); err != nil {
+		return err
+	}
+	p.Unpack(buf)
+	return nil
+}
+
 // Context Association Lists
 type ContextAssociationLists struct {
 	SourceListSize     uint8
@@ -398,8 +784,17 @@ func (c *ContextAssociationLists) Size() uint32 {
 
 func (c *ContextAssociationLists) Pack() []byte {
 	buf := make([]byte, c.Size())
+	c.PackInto(buf)
+	return buf
+}
+
+func (c *ContextAssociationLists) PackInto(buf []byte) (int, error) {
+	if err := checkSize("ContextAssociationLists", buf, c.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:c.Size()]
+	clear(buf)
 	asyncTag := uint16(0)
-	offset := uint16(0)
 
 	word1 := uint32(0)
 	word1 |= uint32(c.SourceListSize) << 16
@@ -415,16 +810,13 @@ func (c *ContextAssociationLists) Pack() []byte {
 	word2 |= uint32(c.VectorListSize) << 16
 	binary.BigEndian.PutUint32(buf[4:], word2)
 
-	copy(buf[8:], uint32ToBytes(c.SourceList))
-	offset += 8 + uint16(c.SourceListSize*4)
-	copy(buf[offset:], uint32ToBytes(c.SystemList))
-	offset += uint16(c.SystemListSize * 4)
-	copy(buf[offset:], uint32ToBytes(c.VectorList))
-	offset += c.VectorListSize * 4
-	copy(buf[offset:], uint32ToBytes(c.AsyncList))
-	offset += c.AsyncListSize * 4
-	copy(buf[offset:], uint32ToBytes(c.AsyncTagList))
-	return buf
+	offset := uint32(8)
+	offset += putUint32Slice(buf[offset:], c.SourceList, uint32(c.SourceListSize))
+	offset += putUint32Slice(buf[offset:], c.SystemList, uint32(c.SystemListSize))
+	offset += putUint32Slice(buf[offset:], c.VectorList, uint32(c.VectorListSize))
+	offset += putUint32Slice(buf[offset:], c.AsyncList, uint32(c.AsyncListSize))
+	putUint32Slice(buf[offset:], c.AsyncTagList, uint32(c.AsyncListSize*asyncTag))
+	return len(buf), nil
 }
 
 func (c *ContextAssociationLists) Unpack(buf []byte) {
@@ -455,13 +847,35 @@ func (c *ContextAssociationLists) Unpack(buf []byte) {
 	c.AsyncTagList = bytesToUint32Slice(buf[offset:nextOffset])
 }
 
-// Convert Context Assocation lists into byte slices
-func uint32ToBytes(list []uint32) []byte {
-	var buf bytes.Buffer
-	for _, i := range list {
-		binary.Write(&buf, binary.BigEndian, i)
+func (c *ContextAssociationLists) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("ContextAssociationLists", buf, 8); err != nil {
+		return err
+	}
+	if err := checkReserved("ContextAssociationLists", buf, 0, 0xFE00FE00); err != nil {
+		return err
+	}
+	word1 := binary.BigEndian.Uint32(buf[0:])
+	word2 := binary.BigEndian.Uint32(buf[4:])
+	asyncListSize := word2 & 0x7FFF
+	count := (word1 >> 16) + (word1 & 0xFFFF) + (word2 >> 16) + asyncListSize
+	if word2&0x8000 != 0 {
+		count += asyncListSize
+	}
+	if err := checkWords("ContextAssociationLists", buf, 8, count); err != nil {
+		return err
+	}
+	c.Unpack(buf)
+	return nil
+}
+
+// putUint32Slice writes the first count entries of list into buf and
+// returns the number of bytes the count occupies. Entries missing from list
+// are not written.
+func putUint32Slice(buf []byte, list []uint32, count uint32) uint32 {
+	for i := 0; i < len(list) && uint32(i) < count; i++ {
+		binary.BigEndian.PutUint32(buf[i*4:], list[i])
 	}
-	return buf.Bytes()
+	return 4 * count
 }
 
 // Byte slices into CA lists
@@ -470,10 +884,7 @@ func bytesToUint32Slice(buf []byte) []uint32 {
 	uint32Slice := make([]uint32, uint32Len)
 
 	for i := 0; i < uint32Len; i++ {
-		uint32Slice[i] = uint32(buf[i*4]) |
-			(uint32(buf[i*4+1]) << 8) |
-			(uint32(buf[i*4+2]) << 16) |
-			(uint32(buf[i*4+3]) << 24)
+		uint32Slice[i] = binary.BigEndian.Uint32(buf[i*4:])
 	}
 
 	return uint32Slice
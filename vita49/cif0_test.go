@@ -23,6 +23,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -137,6 +138,203 @@ func TestGain(t *testing.T) {
 	}
 }
 
+func TestFrequencySize(t *testing.T) {
+	f := Frequency(0)
+	assert.Equal(t, uint32(8), f.Size())
+}
+
+func TestFrequency(t *testing.T) {
+	cases := []struct {
+		name      string
+		frequency Frequency
+		expected  []byte
+	}{
+		{
+			name:      "1 Hz",
+			frequency: 1.0,
+			expected:  []byte{0, 0, 0, 0, 0, 0x10, 0, 0},
+		},
+		{
+			name:      "-1 Hz",
+			frequency: -1.0,
+			expected:  []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xF0, 0, 0},
+		},
+		{
+			name:      "0.95 uHz",
+			frequency: 1.0 / (1 << 20),
+			expected:  []byte{0, 0, 0, 0, 0, 0, 0, 0x01},
+		},
+		{
+			name:      "20 MHz",
+			frequency: 20e6,
+			expected:  []byte{0, 0, 0x13, 0x12, 0xD0, 0, 0, 0},
+		},
+		{
+			name:      "2.4 GHz",
+			frequency: 2.4e9,
+			expected:  []byte{0, 0x08, 0xF0, 0xD1, 0x80, 0, 0, 0},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := tc.frequency
+			// Pack
+			packed := f.Pack()
+			assert.Equal(t, tc.expected, packed)
+			// Unpack
+			f = 0
+			assert.NoError(t, f.UnmarshalBinary(packed))
+			assert.Equal(t, tc.frequency, f)
+		})
+	}
+	f := Frequency(0)
+	assert.ErrorIs(t, f.UnmarshalBinary([]byte{0, 0, 0, 0}), ErrShortBuffer)
+}
+
+func TestReferenceLevelSize(t *testing.T) {
+	r := ReferenceLevel(0)
+	assert.Equal(t, uint32(4), r.Size())
+}
+
+func TestReferenceLevel(t *testing.T) {
+	cases := []struct {
+		name     string
+		level    ReferenceLevel
+		expected []byte
+	}{
+		{
+			name:     "1 dBm",
+			level:    1.0,
+			expected: []byte{0, 0, 0, 0x80},
+		},
+		{
+			name:     "-1 dBm",
+			level:    -1.0,
+			expected: []byte{0, 0, 0xFF, 0x80},
+		},
+		{
+			name:     "0.0078125 dBm",
+			level:    0.0078125,
+			expected: []byte{0, 0, 0, 0x01},
+		},
+		{
+			name:     "-10.5 dBm",
+			level:    -10.5,
+			expected: []byte{0, 0, 0xFA, 0xC0},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := tc.level
+			// Pack
+			packed := r.Pack()
+			assert.Equal(t, tc.expected, packed)
+			// Unpack
+			r = 0
+			assert.NoError(t, r.UnmarshalBinary(packed))
+			assert.Equal(t, tc.level, r)
+		})
+	}
+	r := ReferenceLevel(0)
+	assert.ErrorIs(t, r.UnmarshalBinary([]byte{0, 0}), ErrShortBuffer)
+	assert.ErrorIs(t, r.UnmarshalBinary([]byte{0, 0x01, 0, 0}), ErrReservedBits)
+}
+
+func TestTimestampAdjustmentSize(t *testing.T) {
+	a := TimestampAdjustment(0)
+	assert.Equal(t, uint32(8), a.Size())
+}
+
+func TestTimestampAdjustment(t *testing.T) {
+	cases := []struct {
+		name       string
+		adjustment TimestampAdjustment
+		duration   time.Duration
+		expected   []byte
+	}{
+		{
+			name:       "1 femtosecond",
+			adjustment: 1,
+			duration:   0,
+			expected:   []byte{0, 0, 0, 0, 0, 0, 0, 0x01},
+		},
+		{
+			name:       "1 nanosecond",
+			adjustment: 1e6,
+			duration:   time.Nanosecond,
+			expected:   []byte{0, 0, 0, 0, 0, 0x0F, 0x42, 0x40},
+		},
+		{
+			name:       "-1 microsecond",
+			adjustment: -1e9,
+			duration:   -time.Microsecond,
+			expected:   []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xC4, 0x65, 0x36, 0x00},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := tc.adjustment
+			assert.Equal(t, tc.duration, a.Duration())
+			// Pack
+			packed := a.Pack()
+			assert.Equal(t, tc.expected, packed)
+			// Unpack
+			a = 0
+			assert.NoError(t, a.UnmarshalBinary(packed))
+			assert.Equal(t, tc.adjustment, a)
+		})
+	}
+	assert.Equal(t, TimestampAdjustment(-1e9), TimestampAdjustmentFromDuration(-time.Microsecond))
+}
+
+func TestTemperatureSize(t *testing.T) {
+	c := Temperature(0)
+	assert.Equal(t, uint32(4), c.Size())
+}
+
+func TestTemperature(t *testing.T) {
+	cases := []struct {
+		name        string
+		temperature Temperature
+		expected    []byte
+	}{
+		{
+			name:        "1 degree",
+			temperature: 1.0,
+			expected:    []byte{0, 0, 0, 0x40},
+		},
+		{
+			name:        "-1 degree",
+			temperature: -1.0,
+			expected:    []byte{0, 0, 0xFF, 0xC0},
+		},
+		{
+			name:        "0.015625 degrees",
+			temperature: 0.015625,
+			expected:    []byte{0, 0, 0, 0x01},
+		},
+		{
+			name:        "-273.15625 degrees",
+			temperature: -273.15625,
+			expected:    []byte{0, 0, 0xBB, 0xB6},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.temperature
+			// Pack
+			packed := c.Pack()
+			assert.Equal(t, tc.expected, packed)
+			// Unpack
+			c = 0
+			assert.NoError(t, c.UnmarshalBinary(packed))
+			assert.Equal(t, tc.temperature, c)
+		})
+	}
+	c := Temperature(0)
+	assert.ErrorIs(t, c.UnmarshalBinary([]byte{0x80, 0, 0, 0}), ErrReservedBits)
+}
+
 func TestDeviceIdentifierSize(t *testing.T) {
 	d := DeviceIdentifier{}
 	assert.Equal(t, uint32(8), d.Size())
@@ -1131,3 +1329,25 @@ func TestContextAssociationLists(t *testing.T) {
 	}
 
 }
+
+func TestGpsAsciiUnmarshalBinary(t *testing.T) {
+	g := GpsAscii{}
+	assert.ErrorIs(t, g.UnmarshalBinary([]byte{0, 0, 0, 0}), ErrShortBuffer)
+	assert.ErrorIs(t, g.UnmarshalBinary([]byte{0x01, 0, 0, 0, 0, 0, 0, 0}), ErrReservedBits)
+	// NumberOfWords claims more words than the buffer holds
+	assert.ErrorIs(t, g.UnmarshalBinary([]byte{0, 0, 0, 0, 0, 0, 0, 2, 'a', 'b', 'c', 'd'}), ErrShortBuffer)
+	assert.NoError(t, g.UnmarshalBinary([]byte{0, 0, 0, 0, 0, 0, 0, 1, 'a', 'b', 'c', 'd'}))
+	assert.Equal(t, uint32(1), g.NumberOfWords)
+}
+
+func TestContextAssociationListsUnmarshalBinary(t *testing.T) {
+	c := ContextAssociationLists{}
+	assert.ErrorIs(t, c.UnmarshalBinary([]byte{0, 0, 0, 0}), ErrShortBuffer)
+	assert.ErrorIs(t, c.UnmarshalBinary([]byte{0, 0, 0x02, 0, 0, 0, 0, 0}), ErrReservedBits)
+	// One source and one async entry with tags needs three list words
+	buf := []byte{0, 1, 0, 0, 0, 0, 0x80, 1, 0, 0, 0, 1, 0, 0, 0, 2}
+	assert.ErrorIs(t, c.UnmarshalBinary(buf), ErrShortBuffer)
+	buf = append(buf, 0, 0, 0, 3)
+	assert.NoError(t, c.UnmarshalBinary(buf))
+	assert.Equal(t, uint32(len(buf)), c.Size())
+}
@@ -21,30 +21,143 @@ package vita49
 
 import (
 	"encoding/binary"
-	"fmt"
+	"math"
 )
 
 const (
-	polarizationBytes         = uint32(4)
-	pointingVectorBytes       = uint32(4)
-	spatialReferenceTypeBytes = uint32(4)
-	beamWidthBytes            = uint32(4)
-	ebNoBERBytes              = uint32(4)
-	thresholdBytes            = uint32(4)
-	interceptPointsBytes      = uint32(4)
-	snrNoiseBytes             = uint32(4)
-	spectrumTypeBytes         = uint32(4)
-	windowTypeBytes           = uint32(4)
-	spectrumF1F2IndiciesBytes = uint32(8)
-	spectrumBytes             = uint32(52)
-	sectorStepScanCIFBytes    = uint32(4)
-	sectorStepScanRecordBytes = uint32(16)
-	sectorStepScanBytes       = uint32(8) // Variable
-	versionInformationBytes   = uint32(8)
+	phaseOffsetBytes             = uint32(4)
+	polarizationBytes            = uint32(4)
+	pointingVectorBytes          = uint32(4)
+	pointingVectorCIFBytes       = uint32(4)
+	pointingVectorRecordBytes    = uint32(24)
+	pointingVectorStructureBytes = uint32(12) // Header words, records are variable
+	arrayOfCifsBytes             = uint32(8)  // Header words, records are variable
+	spatialScanTypeBytes         = uint32(4)
+	rangeBytes                   = uint32(4)
+	compressionPointBytes        = uint32(4)
+	healthStatusBytes            = uint32(4)
+	v49SpecComplianceBytes       = uint32(4)
+	bufferSizeBytes              = uint32(8)
+	spatialReferenceTypeBytes    = uint32(4)
+	beamWidthBytes               = uint32(4)
+	ebNoBERBytes                 = uint32(4)
+	thresholdBytes               = uint32(4)
+	interceptPointsBytes         = uint32(4)
+	snrNoiseBytes                = uint32(4)
+	spectrumTypeBytes            = uint32(4)
+	windowTypeBytes              = uint32(4)
+	spectrumF1F2IndiciesBytes    = uint32(8)
+	spectrumBytes                = uint32(52)
+	sectorStepScanCIFBytes       = uint32(4)
+	sectorStepScanRecordBytes    = uint32(64)
+	sectorStepScanBytes          = uint32(12) // Header words, records are variable
+	versionInformationBytes      = uint32(4)
 )
 
+// Cif1
+// Holds the CIF1 indicator bits along with the value of each field. A value
+// is only packed when its indicator bit is set.
 type Cif1 struct {
 	IndicatorField1
+	PhaseOffset             PhaseOffset
+	Polarization            Polarization
+	PointingVector          PointingVector
+	PointingVectorStructure PointingVectorStructure
+	SpatialScanType         SpatialScanType
+	SpatialReferenceType    SpatialReferenceType
+	BeamWidth               BeamWidth
+	Range                   Range
+	EbnoBer                 EbNoBER
+	Threshold               Threshold
+	CompressionPoint        CompressionPoint
+	InterceptPoints         InterceptPoints
+	SnrNoiseFigure          SNRNoise
+	AuxFrequency            Frequency
+	AuxGain                 Gain
+	AuxBandwidth            Frequency
+	ArrayOfCifs             ArrayOfCifs
+	Spectrum                Spectrum
+	SectorStepScan          SectorStepScan
+	IndexList               IndexList
+	DiscreteIO32            uint32
+	DiscreteIO64            uint64
+	HealthStatus            HealthStatus
+	V49SpecCompliance       V49SpecCompliance
+	VersionInformation      VersionInformation
+	BufferSize              BufferSize
+}
+
+// fields lists the CIF1 fields in packing order (bit 31 down to bit 0)
+func (c *Cif1) fields() []cifField {
+	f := &c.IndicatorField1
+	return []cifField{
+		{f.PhaseOffset, &c.PhaseOffset},
+		{f.Polarization, &c.Polarization},
+		{f.PointingVector, &c.PointingVector},
+		{f.PointingVectorStructure, &c.PointingVectorStructure},
+		{f.SpatialScanType, &c.SpatialScanType},
+		{f.SpatialReferenceType, &c.SpatialReferenceType},
+		{f.BeamWidth, &c.BeamWidth},
+		{f.Range, &c.Range},
+		{f.EbnoBer, &c.EbnoBer},
+		{f.Threshold, &c.Threshold},
+		{f.CompressionPoint, &c.CompressionPoint},
+		{f.InterceptPoints, &c.InterceptPoints},
+		{f.SnrNoiseFigure, &c.SnrNoiseFigure},
+		{f.AuxFrequency, &c.AuxFrequency},
+		{f.AuxGain, &c.AuxGain},
+		{f.AuxBandwidth, &c.AuxBandwidth},
+		{f.ArrayOfCifs, &c.ArrayOfCifs},
+		{f.Spectrum, &c.Spectrum},
+		{f.SectorStepScan, &c.SectorStepScan},
+		{f.IndexList, &c.IndexList},
+		{f.DiscreteIO32, (*word32)(&c.DiscreteIO32)},
+		{f.DiscreteIO64, (*word64)(&c.DiscreteIO64)},
+		{f.HealthStatus, &c.HealthStatus},
+		{f.V49SpecCompliance, &c.V49SpecCompliance},
+		{f.VersionInformation, &c.VersionInformation},
+		{f.BufferSize, &c.BufferSize},
+	}
+}
+
+// PhaseOffset
+// The phase offset of the signal in radians, packed in the lower 16 bits of
+// the word as a two's-complement fixed-point number with the radix point to
+// the right of bit 7. The upper 16 bits are reserved.
+type PhaseOffset float64
+
+func (p *PhaseOffset) Size() uint32 {
+	return phaseOffsetBytes
+}
+
+func (p *PhaseOffset) Pack() []byte {
+	buf := make([]byte, p.Size())
+	p.PackInto(buf)
+	return buf
+}
+
+func (p *PhaseOffset) PackInto(buf []byte) (int, error) {
+	if err := checkSize("PhaseOffset", buf, p.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint16(buf[0:], 0) // reserved bits
+	binary.BigEndian.PutUint16(buf[2:], uint16(ToFixed16(float64(*p), 7)))
+	return int(p.Size()), nil
+}
+
+func (p *PhaseOffset) Unpack(buf []byte) {
+	*p = PhaseOffset(FromFixed(int16(binary.BigEndian.Uint16(buf[2:])), 7))
+}
+
+func (p *PhaseOffset) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("PhaseOffset", buf, p.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("PhaseOffset", buf, 0, 0xFFFF0000); err != nil {
+		return err
+	}
+	p.Unpack(buf)
+	return nil
 }
 
 // Polarization
@@ -59,10 +172,19 @@ func (p *Polarization) Size() uint32 {
 }
 
 func (p *Polarization) Pack() []byte {
-	retval := make([]byte, p.Size())
+	buf := make([]byte, p.Size())
+	p.PackInto(buf)
+	return buf
+}
+
+func (p *Polarization) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Polarization", buf, p.Size()); err != nil {
+		return 0, err
+	}
+	retval := buf[:p.Size()]
 	binary.BigEndian.PutUint16(retval[2:], uint16(ToFixed16(p.EllipticityAngle, 13)))
 	binary.BigEndian.PutUint16(retval[0:], uint16(ToFixed16(p.TiltAngle, 13)))
-	return retval
+	return len(retval), nil
 }
 
 func (p *Polarization) Unpack(buf []byte) {
@@ -70,6 +192,14 @@ func (p *Polarization) Unpack(buf []byte) {
 	p.TiltAngle = FromFixed(int16(binary.BigEndian.Uint16(buf[0:])), 13)
 }
 
+func (p *Polarization) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Polarization", buf, p.Size()); err != nil {
+		return err
+	}
+	p.Unpack(buf)
+	return nil
+}
+
 // PointingVector
 // Allows for reporting or controlling the direction of RF energy from a system
 type PointingVector struct {
@@ -82,10 +212,19 @@ func (p *PointingVector) Size() uint32 {
 }
 
 func (p *PointingVector) Pack() []byte {
-	retval := make([]byte, p.Size())
+	buf := make([]byte, p.Size())
+	p.PackInto(buf)
+	return buf
+}
+
+func (p *PointingVector) PackInto(buf []byte) (int, error) {
+	if err := checkSize("PointingVector", buf, p.Size()); err != nil {
+		return 0, err
+	}
+	retval := buf[:p.Size()]
 	binary.BigEndian.PutUint16(retval[2:], uint16(ToFixed16(p.Azimuthal, 7)))
 	binary.BigEndian.PutUint16(retval[0:], uint16(ToFixed16(p.Elevation, 7)))
-	return retval
+	return len(retval), nil
 }
 
 func (p *PointingVector) Unpack(buf []byte) {
@@ -93,6 +232,284 @@ func (p *PointingVector) Unpack(buf []byte) {
 	p.Elevation = FromFixed(int16(binary.BigEndian.Uint16(buf[0:])), 7)
 }
 
+func (p *PointingVector) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("PointingVector", buf, p.Size()); err != nil {
+		return err
+	}
+	p.Unpack(buf)
+	return nil
+}
+
+// PointingVectorCIF
+// Selects the subfields held by each PointingVectorStructure record. Each
+// subfield is enabled by the bit of the CIF1 field it shares a format with;
+// the other bits are reserved.
+type PointingVectorCIF struct {
+	Polarization         bool // bit position 30
+	PointingVector       bool // bit position 29
+	SpatialScanType      bool // bit position 27
+	SpatialReferenceType bool // bit position 26
+	BeamWidth            bool // bit position 25
+	Range                bool // bit position 24
+}
+
+func (c *PointingVectorCIF) Size() uint32 {
+	return pointingVectorCIFBytes
+}
+
+func (c *PointingVectorCIF) Pack() []byte {
+	buf := make([]byte, c.Size())
+	c.PackInto(buf)
+	return buf
+}
+
+func (c *PointingVectorCIF) PackInto(buf []byte) (int, error) {
+	if err := checkSize("PointingVectorCIF", buf, c.Size()); err != nil {
+		return 0, err
+	}
+	var bitmap uint32
+	bitmap |= indicatorFieldUint(c.Polarization, 30)
+	bitmap |= indicatorFieldUint(c.PointingVector, 29)
+	bitmap |= indicatorFieldUint(c.SpatialScanType, 27)
+	bitmap |= indicatorFieldUint(c.SpatialReferenceType, 26)
+	bitmap |= indicatorFieldUint(c.BeamWidth, 25)
+	bitmap |= indicatorFieldUint(c.Range, 24)
+	binary.BigEndian.PutUint32(buf, bitmap)
+	return int(c.Size()), nil
+}
+
+func (c *PointingVectorCIF) Unpack(buf []byte) {
+	bitmap := binary.BigEndian.Uint32(buf)
+	c.Polarization = indicatorFieldBool(bitmap, 30)
+	c.PointingVector = indicatorFieldBool(bitmap, 29)
+	c.SpatialScanType = indicatorFieldBool(bitmap, 27)
+	c.SpatialReferenceType = indicatorFieldBool(bitmap, 26)
+	c.BeamWidth = indicatorFieldBool(bitmap, 25)
+	c.Range = indicatorFieldBool(bitmap, 24)
+}
+
+func (c *PointingVectorCIF) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("PointingVectorCIF", buf, c.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("PointingVectorCIF", buf, 0, 0x90FFFFFF); err != nil {
+		return err
+	}
+	c.Unpack(buf)
+	return nil
+}
+
+// recordSize returns the number of bytes in a record holding the subfields
+// enabled in the CIF
+func (c *PointingVectorCIF) recordSize() uint32 {
+	var r PointingVectorRecord
+	return cifFieldsSize(r.subfields(c))
+}
+
+// allPointingVectorSubfields enables every record subfield
+var allPointingVectorSubfields = PointingVectorCIF{
+	Polarization:         true,
+	PointingVector:       true,
+	SpatialScanType:      true,
+	SpatialReferenceType: true,
+	BeamWidth:            true,
+	Range:                true,
+}
+
+// PointingVectorRecord
+// One record of a PointingVectorStructure. On the wire a record only carries
+// the subfields enabled in the subfield CIF, in CIF bit order. Size, Pack and
+// Unpack handle a record with every subfield present.
+type PointingVectorRecord struct {
+	Polarization         Polarization
+	PointingVector       PointingVector
+	SpatialScanType      SpatialScanType
+	SpatialReferenceType SpatialReferenceType
+	BeamWidth            BeamWidth
+	Range                Range
+}
+
+// subfields lists the record subfields in packing order (bit 31 down to
+// bit 0), enabled as cif selects
+func (r *PointingVectorRecord) subfields(cif *PointingVectorCIF) []cifField {
+	return []cifField{
+		{cif.Polarization, &r.Polarization},
+		{cif.PointingVector, &r.PointingVector},
+		{cif.SpatialScanType, &r.SpatialScanType},
+		{cif.SpatialReferenceType, &r.SpatialReferenceType},
+		{cif.BeamWidth, &r.BeamWidth},
+		{cif.Range, &r.Range},
+	}
+}
+
+func (r *PointingVectorRecord) Size() uint32 {
+	return pointingVectorRecordBytes
+}
+
+func (r *PointingVectorRecord) Pack() []byte {
+	buf := make([]byte, r.Size())
+	r.PackInto(buf)
+	return buf
+}
+
+func (r *PointingVectorRecord) PackInto(buf []byte) (int, error) {
+	if err := checkSize("PointingVectorRecord", buf, r.Size()); err != nil {
+		return 0, err
+	}
+	if err := packCifFields(buf, r.subfields(&allPointingVectorSubfields)); err != nil {
+		return 0, err
+	}
+	return int(r.Size()), nil
+}
+
+func (r *PointingVectorRecord) Unpack(buf []byte) {
+	unpackCifFields(buf, r.subfields(&allPointingVectorSubfields))
+}
+
+func (r *PointingVectorRecord) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("PointingVectorRecord", buf, r.Size()); err != nil {
+		return err
+	}
+	_, err := unmarshalCifFields(buf, r.subfields(&allPointingVectorSubfields))
+	return err
+}
+
+// PointingVectorStructure
+// The 3-D Pointing Vector Structure, an array of records whose subfields are
+// selected by SubfieldCif. ArraySize, HeaderSize, NumWordsRecord and
+// NumRecords are derived from SubfieldCif and Records when packed.
+type PointingVectorStructure struct {
+	ArraySize      uint32
+	HeaderSize     uint8
+	NumWordsRecord uint16
+	NumRecords     uint16
+	SubfieldCif    PointingVectorCIF
+	Records        []PointingVectorRecord
+}
+
+func (p *PointingVectorStructure) Size() uint32 {
+	return pointingVectorStructureBytes + uint32(len(p.Records))*p.SubfieldCif.recordSize()
+}
+
+func (p *PointingVectorStructure) Pack() []byte {
+	return mustPack(p)
+}
+
+func (p *PointingVectorStructure) PackInto(buf []byte) (int, error) {
+	size := p.Size()
+	if err := checkSize("PointingVectorStructure", buf, size); err != nil {
+		return 0, err
+	}
+	if err := checkArrayCount("PointingVectorStructure", "NumRecords", uint32(len(p.Records))); err != nil {
+		return 0, err
+	}
+	p.HeaderSize = uint8(pointingVectorStructureBytes / 4)
+	p.NumWordsRecord = uint16(p.SubfieldCif.recordSize() / 4)
+	p.NumRecords = uint16(len(p.Records))
+	p.ArraySize = size / 4
+
+	binary.BigEndian.PutUint32(buf[0:], p.ArraySize)
+	headerSizeWord := uint32(p.HeaderSize) << 24
+	headerSizeWord |= uint32(p.NumWordsRecord) << 12
+	headerSizeWord |= uint32(p.NumRecords)
+	binary.BigEndian.PutUint32(buf[4:], headerSizeWord)
+	p.SubfieldCif.PackInto(buf[8:])
+
+	offset := pointingVectorStructureBytes
+	for i := range p.Records {
+		fields := p.Records[i].subfields(&p.SubfieldCif)
+		if err := packCifFields(buf[offset:], fields); err != nil {
+			return 0, err
+		}
+		offset += cifFieldsSize(fields)
+	}
+	return int(size), nil
+}
+
+func (p *PointingVectorStructure) Unpack(buf []byte) {
+	p.ArraySize = binary.BigEndian.Uint32(buf[0:])
+	headerSizeWord := binary.BigEndian.Uint32(buf[4:])
+	p.HeaderSize = uint8(headerSizeWord >> 24)
+	p.NumWordsRecord = uint16((headerSizeWord >> 12) & 0xFFF)
+	p.NumRecords = uint16(headerSizeWord & 0xFFF)
+	p.SubfieldCif.Unpack(buf[8:])
+
+	offset := 4 * uint32(p.HeaderSize)
+	stride := 4 * uint32(p.NumWordsRecord)
+	p.Records = make([]PointingVectorRecord, p.NumRecords)
+	for i := range p.Records {
+		unpackCifFields(buf[offset:], p.Records[i].subfields(&p.SubfieldCif))
+		offset += stride
+	}
+}
+
+func (p *PointingVectorStructure) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("PointingVectorStructure", buf, pointingVectorStructureBytes); err != nil {
+		return err
+	}
+	var cif PointingVectorCIF
+	if err := cif.UnmarshalBinary(buf[8:]); err != nil {
+		return err
+	}
+	arraySize := binary.BigEndian.Uint32(buf[0:])
+	if err := checkWords("PointingVectorStructure", buf, 0, arraySize); err != nil {
+		return err
+	}
+	headerSizeWord := binary.BigEndian.Uint32(buf[4:])
+	headerSize := headerSizeWord >> 24
+	numWordsRecord := (headerSizeWord >> 12) & 0xFFF
+	numRecords := headerSizeWord & 0xFFF
+	if headerSize < pointingVectorStructureBytes/4 {
+		return &ListCountError{Type: "PointingVectorStructure", List: "HeaderSize", Count: headerSize, Expected: pointingVectorStructureBytes / 4}
+	}
+	if recordWords := cif.recordSize() / 4; numWordsRecord < recordWords {
+		return &ListCountError{Type: "PointingVectorStructure", List: "NumWordsRecord", Count: numWordsRecord, Expected: recordWords}
+	}
+	if expected := headerSize + numRecords*numWordsRecord; arraySize != expected {
+		return &ListCountError{Type: "PointingVectorStructure", List: "ArraySize", Count: arraySize, Expected: expected}
+	}
+	p.Unpack(buf)
+	return nil
+}
+
+// SpatialScanType
+// Identifies the scan type of the antenna, packed in the lower 16 bits of
+// the word. The upper 16 bits are reserved.
+type SpatialScanType uint16
+
+func (s *SpatialScanType) Size() uint32 {
+	return spatialScanTypeBytes
+}
+
+func (s *SpatialScanType) Pack() []byte {
+	buf := make([]byte, s.Size())
+	s.PackInto(buf)
+	return buf
+}
+
+func (s *SpatialScanType) PackInto(buf []byte) (int, error) {
+	if err := checkSize("SpatialScanType", buf, s.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint32(buf, uint32(*s))
+	return int(s.Size()), nil
+}
+
+func (s *SpatialScanType) Unpack(buf []byte) {
+	*s = SpatialScanType(binary.BigEndian.Uint16(buf[2:]))
+}
+
+func (s *SpatialScanType) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("SpatialScanType", buf, s.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("SpatialScanType", buf, 0, 0xFFFF0000); err != nil {
+		return err
+	}
+	s.Unpack(buf)
+	return nil
+}
+
 // Spatial Reference Type
 // Describes the reference point for the antenna scan
 type SpatialReferenceType struct {
@@ -106,13 +523,22 @@ func (s *SpatialReferenceType) Size() uint32 {
 }
 
 func (s *SpatialReferenceType) Pack() []byte {
-	retval := make([]byte, s.Size())
+	buf := make([]byte, s.Size())
+	s.PackInto(buf)
+	return buf
+}
+
+func (s *SpatialReferenceType) PackInto(buf []byte) (int, error) {
+	if err := checkSize("SpatialReferenceType", buf, s.Size()); err != nil {
+		return 0, err
+	}
+	retval := buf[:s.Size()]
 	word1 := uint32(0)
 	word1 |= uint32(s.SpatialIdentifier) << 16    // Bits 16-31
 	word1 |= uint32(s.DefinedReference&0x03) << 2 // Bits 2 & 3
 	word1 |= uint32(s.BeamType & 0x03)            // Bits 0 & 1
 	binary.BigEndian.PutUint32(retval[0:], word1)
-	return retval
+	return len(retval), nil
 }
 
 func (s *SpatialReferenceType) Unpack(buf []byte) {
@@ -122,6 +548,17 @@ func (s *SpatialReferenceType) Unpack(buf []byte) {
 	s.BeamType = uint8(word1 & 0x03)
 }
 
+func (s *SpatialReferenceType) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("SpatialReferenceType", buf, s.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("SpatialReferenceType", buf, 0, 0x0000FFF0); err != nil {
+		return err
+	}
+	s.Unpack(buf)
+	return nil
+}
+
 // Beam Width
 // The 3dB width of the main lobe
 type BeamWidth struct {
@@ -134,10 +571,19 @@ func (b *BeamWidth) Size() uint32 {
 }
 
 func (b *BeamWidth) Pack() []byte {
-	retval := make([]byte, b.Size())
+	buf := make([]byte, b.Size())
+	b.PackInto(buf)
+	return buf
+}
+
+func (b *BeamWidth) PackInto(buf []byte) (int, error) {
+	if err := checkSize("BeamWidth", buf, b.Size()); err != nil {
+		return 0, err
+	}
+	retval := buf[:b.Size()]
 	binary.BigEndian.PutUint16(retval[2:], uint16(ToFixed16(b.Vertical, 7)))
 	binary.BigEndian.PutUint16(retval[0:], uint16(ToFixed16(b.Horizontal, 7)))
-	return retval
+	return len(retval), nil
 }
 
 func (b *BeamWidth) Unpack(buf []byte) {
@@ -145,6 +591,49 @@ func (b *BeamWidth) Unpack(buf []byte) {
 	b.Horizontal = FromFixed(int16(binary.BigEndian.Uint16(buf[0:])), 7)
 }
 
+func (b *BeamWidth) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("BeamWidth", buf, b.Size()); err != nil {
+		return err
+	}
+	b.Unpack(buf)
+	return nil
+}
+
+// Range
+// The range in meters, packed as a 32-bit unsigned fixed-point number with
+// the radix point to the right of bit 6. Negative values pack as zero.
+type Range float64
+
+func (r *Range) Size() uint32 {
+	return rangeBytes
+}
+
+func (r *Range) Pack() []byte {
+	buf := make([]byte, r.Size())
+	r.PackInto(buf)
+	return buf
+}
+
+func (r *Range) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Range", buf, r.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint32(buf, uint32(math.Round(math.Max(float64(*r), 0)*64)))
+	return int(r.Size()), nil
+}
+
+func (r *Range) Unpack(buf []byte) {
+	*r = Range(float64(binary.BigEndian.Uint32(buf)) / 64)
+}
+
+func (r *Range) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Range", buf, r.Size()); err != nil {
+		return err
+	}
+	r.Unpack(buf)
+	return nil
+}
+
 // EbNoBER
 // EbNo - Energy per bit to noise density ratio
 // A measure of the energy per bit to naise power per hertz of the signal for the signal
@@ -169,10 +658,19 @@ func (e *EbNoBER) Size() uint32 {
 }
 
 func (e *EbNoBER) Pack() []byte {
-	retval := make([]byte, e.Size())
+	buf := make([]byte, e.Size())
+	e.PackInto(buf)
+	return buf
+}
+
+func (e *EbNoBER) PackInto(buf []byte) (int, error) {
+	if err := checkSize("EbNoBER", buf, e.Size()); err != nil {
+		return 0, err
+	}
+	retval := buf[:e.Size()]
 	binary.BigEndian.PutUint16(retval[0:], uint16(ToFixed16(e.Ebno, 7)))
 	binary.BigEndian.PutUint16(retval[2:], uint16(ToFixed16(e.Ber, 7)))
-	return retval
+	return len(retval), nil
 }
 
 func (e *EbNoBER) Unpack(buf []byte) {
@@ -180,6 +678,14 @@ func (e *EbNoBER) Unpack(buf []byte) {
 	e.Ber = FromFixed(int16(binary.BigEndian.Uint16(buf[2:])), 7)
 }
 
+func (e *EbNoBER) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("EbNoBER", buf, e.Size()); err != nil {
+		return err
+	}
+	e.Unpack(buf)
+	return nil
+}
+
 // Threshold
 // Provides the ability to set a signal threshold level in dB or dBm,
 // to trigger some signal based action
@@ -193,10 +699,19 @@ func (t *Threshold) Size() uint32 {
 }
 
 func (t *Threshold) Pack() []byte {
-	retval := make([]byte, t.Size())
+	buf := make([]byte, t.Size())
+	t.PackInto(buf)
+	return buf
+}
+
+func (t *Threshold) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Threshold", buf, t.Size()); err != nil {
+		return 0, err
+	}
+	retval := buf[:t.Size()]
 	binary.BigEndian.PutUint16(retval[2:], uint16(ToFixed16(t.Stage2, 7)))
 	binary.BigEndian.PutUint16(retval[0:], uint16(ToFixed16(t.Stage1, 7)))
-	return retval
+	return len(retval), nil
 }
 
 func (t *Threshold) Unpack(buf []byte) {
@@ -204,6 +719,54 @@ func (t *Threshold) Unpack(buf []byte) {
 	t.Stage1 = FromFixed(int16(binary.BigEndian.Uint16(buf[0:])), 7)
 }
 
+func (t *Threshold) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Threshold", buf, t.Size()); err != nil {
+		return err
+	}
+	t.Unpack(buf)
+	return nil
+}
+
+// CompressionPoint
+// The 1 dB compression point in dBm, packed in the lower 16 bits of the word
+// as a two's-complement fixed-point number with the radix point to the right
+// of bit 7. The upper 16 bits are reserved.
+type CompressionPoint float64
+
+func (c *CompressionPoint) Size() uint32 {
+	return compressionPointBytes
+}
+
+func (c *CompressionPoint) Pack() []byte {
+	buf := make([]byte, c.Size())
+	c.PackInto(buf)
+	return buf
+}
+
+func (c *CompressionPoint) PackInto(buf []byte) (int, error) {
+	if err := checkSize("CompressionPoint", buf, c.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint16(buf[0:], 0) // reserved bits
+	binary.BigEndian.PutUint16(buf[2:], uint16(ToFixed16(float64(*c), 7)))
+	return int(c.Size()), nil
+}
+
+func (c *CompressionPoint) Unpack(buf []byte) {
+	*c = CompressionPoint(FromFixed(int16(binary.BigEndian.Uint16(buf[2:])), 7))
+}
+
+func (c *CompressionPoint) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("CompressionPoint", buf, c.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("CompressionPoint", buf, 0, 0xFFFF0000); err != nil {
+		return err
+	}
+	c.Unpack(buf)
+	return nil
+}
+
 // InterceptPoints
 // Second and third order intercept points are combined into a single word
 // for efficiency; they are often considered together as measures of a tuners distortion performance
@@ -212,15 +775,24 @@ type InterceptPoints struct {
 	ThirdOrder  float64
 }
 
-func (i InterceptPoints) Size() uint32 {
+func (i *InterceptPoints) Size() uint32 {
 	return interceptPointsBytes
 }
 
 func (i *InterceptPoints) Pack() []byte {
-	retval := make([]byte, 4)
+	buf := make([]byte, i.Size())
+	i.PackInto(buf)
+	return buf
+}
+
+func (i *InterceptPoints) PackInto(buf []byte) (int, error) {
+	if err := checkSize("InterceptPoints", buf, i.Size()); err != nil {
+		return 0, err
+	}
+	retval := buf[:i.Size()]
 	binary.BigEndian.PutUint16(retval[2:], uint16(ToFixed16(i.ThirdOrder, 7)))
 	binary.BigEndian.PutUint16(retval[0:], uint16(ToFixed16(i.SecondOrder, 7)))
-	return retval
+	return len(retval), nil
 }
 
 func (i *InterceptPoints) Unpack(buf []byte) {
@@ -228,6 +800,14 @@ func (i *InterceptPoints) Unpack(buf []byte) {
 	i.SecondOrder = FromFixed(int16(binary.BigEndian.Uint16(buf[0:])), 7)
 }
 
+func (i *InterceptPoints) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("InterceptPoints", buf, i.Size()); err != nil {
+		return err
+	}
+	i.Unpack(buf)
+	return nil
+}
+
 // SNRNoise
 // Signal to noise ratio - a measure of the signal power to noise power (dB)
 type SNRNoise struct {
@@ -240,10 +820,19 @@ func (s *SNRNoise) Size() uint32 {
 }
 
 func (s *SNRNoise) Pack() []byte {
-	retval := make([]byte, s.Size())
+	buf := make([]byte, s.Size())
+	s.PackInto(buf)
+	return buf
+}
+
+func (s *SNRNoise) PackInto(buf []byte) (int, error) {
+	if err := checkSize("SNRNoise", buf, s.Size()); err != nil {
+		return 0, err
+	}
+	retval := buf[:s.Size()]
 	binary.BigEndian.PutUint16(retval[2:], uint16(ToFixed16(s.Noise, 7)))
 	binary.BigEndian.PutUint16(retval[0:], uint16(ToFixed16(s.Snr, 7)))
-	return retval
+	return len(retval), nil
 }
 
 func (s *SNRNoise) Unpack(buf []byte) {
@@ -251,6 +840,134 @@ func (s *SNRNoise) Unpack(buf []byte) {
 	s.Snr = FromFixed(int16(binary.BigEndian.Uint16(buf[0:])), 7)
 }
 
+func (s *SNRNoise) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("SNRNoise", buf, s.Size()); err != nil {
+		return err
+	}
+	s.Unpack(buf)
+	return nil
+}
+
+// ArrayOfCifs
+// The Array-of-CIFs structure, an array of records that each carry their own
+// CIF words followed by the fields those words enable, so that one packet
+// can describe many channels or beams. Records are indexed in the order
+// packed. ArraySize, HeaderSize, NumWordsRecord and NumRecords are derived
+// from Records when packed, with every record padded to the longest one.
+type ArrayOfCifs struct {
+	ArraySize      uint32
+	HeaderSize     uint8
+	NumWordsRecord uint16
+	NumRecords     uint16
+	Records        []Cifs
+}
+
+func (a *ArrayOfCifs) numWordsRecord() uint32 {
+	var words uint32
+	for i := range a.Records {
+		words = max(words, a.Records[i].Size()/4)
+	}
+	return words
+}
+
+func (a *ArrayOfCifs) Size() uint32 {
+	return arrayOfCifsBytes + 4*uint32(len(a.Records))*a.numWordsRecord()
+}
+
+func (a *ArrayOfCifs) Pack() []byte {
+	return mustPack(a)
+}
+
+func (a *ArrayOfCifs) PackInto(buf []byte) (int, error) {
+	size := a.Size()
+	if err := checkSize("ArrayOfCifs", buf, size); err != nil {
+		return 0, err
+	}
+	numWordsRecord := a.numWordsRecord()
+	if err := checkArrayCount("ArrayOfCifs", "NumWordsRecord", numWordsRecord); err != nil {
+		return 0, err
+	}
+	if err := checkArrayCount("ArrayOfCifs", "NumRecords", uint32(len(a.Records))); err != nil {
+		return 0, err
+	}
+	a.HeaderSize = uint8(arrayOfCifsBytes / 4)
+	a.NumWordsRecord = uint16(numWordsRecord)
+	a.NumRecords = uint16(len(a.Records))
+	a.ArraySize = size / 4
+
+	binary.BigEndian.PutUint32(buf[0:], a.ArraySize)
+	headerSizeWord := uint32(a.HeaderSize) << 24
+	headerSizeWord |= (uint32(a.NumWordsRecord) & 0xFFF) << 12
+	headerSizeWord |= uint32(a.NumRecords) & 0xFFF
+	binary.BigEndian.PutUint32(buf[4:], headerSizeWord)
+
+	offset := arrayOfCifsBytes
+	recordBytes := 4 * uint32(a.NumWordsRecord)
+	for i := range a.Records {
+		record := buf[offset : offset+recordBytes]
+		n, err := a.Records[i].PackInto(record)
+		if err != nil {
+			return 0, err
+		}
+		clear(record[n:])
+		offset += recordBytes
+	}
+	return int(size), nil
+}
+
+func (a *ArrayOfCifs) Unpack(buf []byte) {
+	a.ArraySize = binary.BigEndian.Uint32(buf[0:])
+	headerSizeWord := binary.BigEndian.Uint32(buf[4:])
+	a.HeaderSize = uint8(headerSizeWord >> 24)
+	a.NumWordsRecord = uint16((headerSizeWord >> 12) & 0xFFF)
+	a.NumRecords = uint16(headerSizeWord & 0xFFF)
+
+	offset := 4 * uint32(a.HeaderSize)
+	recordBytes := 4 * uint32(a.NumWordsRecord)
+	a.Records = make([]Cifs, a.NumRecords)
+	for i := range a.Records {
+		a.Records[i].Unpack(buf[offset : offset+recordBytes])
+		offset += recordBytes
+	}
+}
+
+// UnmarshalBinary is the checked form of Unpack. Each record must fit in
+// NumWordsRecord words.
+func (a *ArrayOfCifs) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("ArrayOfCifs", buf, arrayOfCifsBytes); err != nil {
+		return err
+	}
+	arraySize := binary.BigEndian.Uint32(buf[0:])
+	if err := checkWords("ArrayOfCifs", buf, 0, arraySize); err != nil {
+		return err
+	}
+	headerSizeWord := binary.BigEndian.Uint32(buf[4:])
+	headerSize := headerSizeWord >> 24
+	numWordsRecord := (headerSizeWord >> 12) & 0xFFF
+	numRecords := headerSizeWord & 0xFFF
+	if headerSize < arrayOfCifsBytes/4 {
+		return &ListCountError{Type: "ArrayOfCifs", List: "HeaderSize", Count: headerSize, Expected: arrayOfCifsBytes / 4}
+	}
+	if expected := headerSize + numRecords*numWordsRecord; arraySize != expected {
+		return &ListCountError{Type: "ArrayOfCifs", List: "ArraySize", Count: arraySize, Expected: expected}
+	}
+	records := make([]Cifs, numRecords)
+	offset := 4 * headerSize
+	recordBytes := 4 * numWordsRecord
+	for i := range records {
+		if err := records[i].UnmarshalBinary(buf[offset : offset+recordBytes]); err != nil {
+			return err
+		}
+		offset += recordBytes
+	}
+	a.ArraySize = arraySize
+	a.HeaderSize = uint8(headerSize)
+	a.NumWordsRecord = uint16(numWordsRecord)
+	a.NumRecords = uint16(numRecords)
+	a.Records = records
+	return nil
+}
+
 // SpectrumType
 // Describes or sets the basic characteristics of the spectral data
 type SpectrumType struct {
@@ -264,13 +981,22 @@ func (s *SpectrumType) Size() uint32 {
 }
 
 func (s *SpectrumType) Pack() []byte {
-	retval := make([]byte, s.Size())
+	buf := make([]byte, s.Size())
+	s.PackInto(buf)
+	return buf
+}
+
+func (s *SpectrumType) PackInto(buf []byte) (int, error) {
+	if err := checkSize("SpectrumType", buf, s.Size()); err != nil {
+		return 0, err
+	}
+	retval := buf[:s.Size()]
 	word1 := uint32(0)
 	word1 |= uint32(s.WindowTime&0x0F) << 16
 	word1 |= uint32(s.AveragingType&0xFF) << 8
 	word1 |= uint32(s.SpectrumType & 0xFF)
 	binary.BigEndian.PutUint32(retval[0:], word1)
-	return retval
+	return len(retval), nil
 }
 
 func (s *SpectrumType) Unpack(buf []byte) {
@@ -280,6 +1006,17 @@ func (s *SpectrumType) Unpack(buf []byte) {
 	s.SpectrumType = uint8(word1 & 0xFF)
 }
 
+func (s *SpectrumType) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("SpectrumType", buf, s.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("SpectrumType", buf, 0, 0xFFF00000); err != nil {
+		return err
+	}
+	s.Unpack(buf)
+	return nil
+}
+
 // WindowType
 // Indicates the time-domain window that was used on the time-domain data before
 // being transformed to the frequency domain
@@ -292,11 +1029,20 @@ func (w *WindowType) Size() uint32 {
 }
 
 func (w *WindowType) Pack() []byte {
-	retval := make([]byte, w.Size())
+	buf := make([]byte, w.Size())
+	w.PackInto(buf)
+	return buf
+}
+
+func (w *WindowType) PackInto(buf []byte) (int, error) {
+	if err := checkSize("WindowType", buf, w.Size()); err != nil {
+		return 0, err
+	}
+	retval := buf[:w.Size()]
 	word1 := uint32(0)
 	word1 |= uint32(w.WindowType) // Byte 3
 	binary.BigEndian.PutUint32(retval[0:], word1)
-	return retval
+	return len(retval), nil
 }
 
 func (w *WindowType) Unpack(buf []byte) {
@@ -304,6 +1050,17 @@ func (w *WindowType) Unpack(buf []byte) {
 	w.WindowType = uint8((word1 & 0xFF))
 }
 
+func (w *WindowType) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("WindowType", buf, w.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("WindowType", buf, 0, 0xFFFFFF00); err != nil {
+		return err
+	}
+	w.Unpack(buf)
+	return nil
+}
+
 // SpectrumF1F2Indicies
 // Used to indicate if only a subset of the total spectrum
 // points that were computed are provided in the data packet
@@ -317,10 +1074,19 @@ func (s *SpectrumF1F2Indicies) Size() uint32 {
 }
 
 func (s *SpectrumF1F2Indicies) Pack() []byte {
-	retval := make([]byte, s.Size())
+	buf := make([]byte, s.Size())
+	s.PackInto(buf)
+	return buf
+}
+
+func (s *SpectrumF1F2Indicies) PackInto(buf []byte) (int, error) {
+	if err := checkSize("SpectrumF1F2Indicies", buf, s.Size()); err != nil {
+		return 0, err
+	}
+	retval := buf[:s.Size()]
 	binary.BigEndian.PutUint32(retval[4:], s.F1Index)
 	binary.BigEndian.PutUint32(retval[0:], s.F2Index)
-	return retval
+	return len(retval), nil
 }
 
 func (s *SpectrumF1F2Indicies) Unpack(buf []byte) {
@@ -328,6 +1094,14 @@ func (s *SpectrumF1F2Indicies) Unpack(buf []byte) {
 	s.F2Index = binary.BigEndian.Uint32(buf[0:])
 }
 
+func (s *SpectrumF1F2Indicies) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("SpectrumF1F2Indicies", buf, s.Size()); err != nil {
+		return err
+	}
+	s.Unpack(buf)
+	return nil
+}
+
 // Spectrum
 // Describes control or context for spectral information
 type Spectrum struct {
@@ -348,7 +1122,16 @@ func (s *Spectrum) Size() uint32 {
 }
 
 func (s *Spectrum) Pack() []byte {
-	retval := make([]byte, s.Size())
+	buf := make([]byte, s.Size())
+	s.PackInto(buf)
+	return buf
+}
+
+func (s *Spectrum) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Spectrum", buf, s.Size()); err != nil {
+		return 0, err
+	}
+	retval := buf[:s.Size()]
 
 	// Spectrum type - 4 bytes
 	spectrumWord := uint32(0)
@@ -387,7 +1170,7 @@ func (s *Spectrum) Pack() []byte {
 	// Window time-delta - 4 bytes
 	binary.BigEndian.PutUint32(retval[0:], s.WindowTimeDelta) // Start at offset 0
 
-	return retval
+	return len(retval), nil
 }
 
 func (s *Spectrum) Unpack(buf []byte) {
@@ -428,6 +1211,20 @@ func (s *Spectrum) Unpack(buf []byte) {
 	s.SpectrumType.WindowTime = uint8((spectrumWord >> 16) & 0x0F)   // Bits 16-19
 }
 
+func (s *Spectrum) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Spectrum", buf, s.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("Spectrum", buf, 44, 0xFFFFFF00); err != nil {
+		return err
+	}
+	if err := checkReserved("Spectrum", buf, 48, 0xFFF00000); err != nil {
+		return err
+	}
+	s.Unpack(buf)
+	return nil
+}
+
 // SectorStepScanCIF
 // Provides a way to setup & report a multi-sectored scanning reciever
 // Also provides for stepping individual frequencies, vs scanning
@@ -446,13 +1243,22 @@ type SectorStepScanCIF struct {
 	Time4               bool
 }
 
-func (s SectorStepScanCIF) Size() uint32 {
+func (s *SectorStepScanCIF) Size() uint32 {
 	return sectorStepScanCIFBytes
 }
 
 func (s *SectorStepScanCIF) Pack() []byte {
+	buf := make([]byte, s.Size())
+	s.PackInto(buf)
+	return buf
+}
+
+func (s *SectorStepScanCIF) PackInto(buf []byte) (int, error) {
+	if err := checkSize("SectorStepScanCIF", buf, s.Size()); err != nil {
+		return 0, err
+	}
 	// 1 word
-	retval := make([]byte, 4)
+	retval := buf[:s.Size()]
 
 	// Initialize all bits to zero
 	var bits uint32
@@ -498,7 +1304,7 @@ func (s *SectorStepScanCIF) Pack() []byte {
 	// Store the packed bits in retval
 	binary.BigEndian.PutUint32(retval, bits)
 
-	return retval
+	return len(retval), nil
 }
 
 func (s *SectorStepScanCIF) Unpack(buf []byte) {
@@ -519,7 +1325,64 @@ func (s *SectorStepScanCIF) Unpack(buf []byte) {
 	s.Time4 = (bits & (1 << 20)) != 0               // Bit 20
 }
 
+func (s *SectorStepScanCIF) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("SectorStepScanCIF", buf, s.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("SectorStepScanCIF", buf, 0, 0x000FFFFF); err != nil {
+		return err
+	}
+	s.Unpack(buf)
+	return nil
+}
+
+// recordSize returns the number of bytes in a record holding only the
+// subfields enabled in the CIF
+func (s *SectorStepScanCIF) recordSize() uint32 {
+	var size uint32
+	for _, f := range []struct {
+		enabled bool
+		bytes   uint32
+	}{
+		{s.SectorNumber, 4},
+		{s.F1StartFrequency, 8},
+		{s.F2StartFrequency, 8},
+		{s.ResolutionBandwidth, 8},
+		{s.TuneStepSize, 8},
+		{s.NumberPoints, 4},
+		{s.DefaultGain, 4},
+		{s.Threshold, 4},
+		{s.DwellTime, 4},
+		{s.StartTime, 4},
+		{s.Time3, 4},
+		{s.Time4, 4},
+	} {
+		if f.enabled {
+			size += f.bytes
+		}
+	}
+	return size
+}
+
+// allSectorStepScanSubfields enables every record subfield
+var allSectorStepScanSubfields = SectorStepScanCIF{
+	SectorNumber:        true,
+	F1StartFrequency:    true,
+	F2StartFrequency:    true,
+	ResolutionBandwidth: true,
+	TuneStepSize:        true,
+	NumberPoints:        true,
+	DefaultGain:         true,
+	Threshold:           true,
+	DwellTime:           true,
+	StartTime:           true,
+	Time3:               true,
+	Time4:               true,
+}
+
 // SectorStepScanRecord
+// On the wire a record only carries the subfields enabled in the subfield
+// CIF. Size, Pack and Unpack handle a record with every subfield present.
 type SectorStepScanRecord struct {
 	SectorNumber        uint32
 	F1StartFrequency    uint64
@@ -535,72 +1398,112 @@ type SectorStepScanRecord struct {
 	Time4               uint32
 }
 
-func (s SectorStepScanRecord) Size() uint32 {
+func (s *SectorStepScanRecord) Size() uint32 {
 	return sectorStepScanRecordBytes
 }
 
 func (s *SectorStepScanRecord) Pack() []byte {
-	buf1 := make([]byte, 28)
-	binary.BigEndian.PutUint32(buf1[0:], s.Time4)
-	binary.BigEndian.PutUint32(buf1[8:], s.Time3)
-	binary.BigEndian.PutUint32(buf1[16:], s.StartTime)
-	binary.BigEndian.PutUint32(buf1[24:], s.DwellTime)
-	// Appending the values this way sucks,
-	// but the go compiler thinks these .Pack() functions return an int and won't accept using copy()
-	buf1 = append(buf1, s.Threshold.Pack()...)
-	buf1 = append(buf1, s.DefaultGain.Pack()...)
-	buf2 := make([]byte, 32)
-	binary.BigEndian.PutUint32(buf2[0:], s.NumberPoints)
-	binary.BigEndian.PutUint64(buf2[4:], s.TuneStepSize)
-	binary.BigEndian.PutUint64(buf2[12:], s.ResolutionBandwidth)
-	binary.BigEndian.PutUint64(buf2[20:], s.F2StopFrequency)
-	binary.BigEndian.PutUint64(buf2[28:], s.F1StartFrequency)
-	binary.BigEndian.PutUint32(buf2[36:], s.SectorNumber)
-	retval := append(buf1, buf2...)
-	return retval
+	buf := make([]byte, s.Size())
+	s.PackInto(buf)
+	return buf
 }
 
-func (s *SectorStepScanRecord) Unpack(buf []byte) {
-
-	// Unpack the SectorNumber (32 bits)
-	s.SectorNumber = binary.BigEndian.Uint32(buf[76:])
-
-	// Unpack F1StartFrequency (64 bits)
-	s.F1StartFrequency = binary.BigEndian.Uint64(buf[68:])
-
-	// Unpack F2StartFrequency (64 bits)
-	s.F2StopFrequency = binary.BigEndian.Uint64(buf[60:])
-
-	// Unpack ResolutionBandwidth (64 bits)
-	s.ResolutionBandwidth = binary.BigEndian.Uint64(buf[52:])
-
-	// Unpack TuneStepSize (64 bits)
-	s.TuneStepSize = binary.BigEndian.Uint64(buf[44:])
-
-	// Unpack NumberPoints (32 bits)
-	s.NumberPoints = binary.BigEndian.Uint32(buf[40:])
-
-	// Unpack DefaultGain (32 bits)
-	s.DefaultGain.Unpack(buf[36:40])
-
-	// Unpack Threshold (32 bits)
-	s.Threshold.Unpack(buf[32:36])
-
-	// Unpack DwellTime (32 bits)
-	s.DwellTime = binary.BigEndian.Uint32(buf[24:])
-
-	// Unpack StartTime (32 bits)
-	s.StartTime = binary.BigEndian.Uint32(buf[16:])
-
-	// Unpack Time3 (32 bits)
-	s.Time3 = binary.BigEndian.Uint32(buf[8:])
+func (s *SectorStepScanRecord) PackInto(buf []byte) (int, error) {
+	if err := checkSize("SectorStepScanRecord", buf, s.Size()); err != nil {
+		return 0, err
+	}
+	return int(s.packSubfields(buf, &allSectorStepScanSubfields)), nil
+}
 
-	// Unpack Time4 (32 bits)
-	s.Time4 = binary.BigEndian.Uint32(buf[0:])
+func (s *SectorStepScanRecord) Unpack(buf []byte) {
+	s.unpackSubfields(buf, &allSectorStepScanSubfields)
+}
 
+func (s *SectorStepScanRecord) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("SectorStepScanRecord", buf, s.Size()); err != nil {
+		return err
+	}
+	s.Unpack(buf)
+	return nil
+}
+
+// packSubfields packs the subfields enabled in cif into buf in CIF bit order
+// and returns the number of bytes written
+func (s *SectorStepScanRecord) packSubfields(buf []byte, cif *SectorStepScanCIF) uint32 {
+	var offset uint32
+	put32 := func(enabled bool, v uint32) {
+		if enabled {
+			binary.BigEndian.PutUint32(buf[offset:], v)
+			offset += 4
+		}
+	}
+	put64 := func(enabled bool, v uint64) {
+		if enabled {
+			binary.BigEndian.PutUint64(buf[offset:], v)
+			offset += 8
+		}
+	}
+	put32(cif.SectorNumber, s.SectorNumber)
+	put64(cif.F1StartFrequency, s.F1StartFrequency)
+	put64(cif.F2StartFrequency, s.F2StopFrequency)
+	put64(cif.ResolutionBandwidth, s.ResolutionBandwidth)
+	put64(cif.TuneStepSize, s.TuneStepSize)
+	put32(cif.NumberPoints, s.NumberPoints)
+	if cif.DefaultGain {
+		s.DefaultGain.PackInto(buf[offset:])
+		offset += 4
+	}
+	if cif.Threshold {
+		s.Threshold.PackInto(buf[offset:])
+		offset += 4
+	}
+	put32(cif.DwellTime, s.DwellTime)
+	put32(cif.StartTime, s.StartTime)
+	put32(cif.Time3, s.Time3)
+	put32(cif.Time4, s.Time4)
+	return offset
+}
+
+// unpackSubfields unpacks the subfields enabled in cif from buf and returns
+// the number of bytes consumed
+func (s *SectorStepScanRecord) unpackSubfields(buf []byte, cif *SectorStepScanCIF) uint32 {
+	var offset uint32
+	get32 := func(enabled bool, v *uint32) {
+		if enabled {
+			*v = binary.BigEndian.Uint32(buf[offset:])
+			offset += 4
+		}
+	}
+	get64 := func(enabled bool, v *uint64) {
+		if enabled {
+			*v = binary.BigEndian.Uint64(buf[offset:])
+			offset += 8
+		}
+	}
+	get32(cif.SectorNumber, &s.SectorNumber)
+	get64(cif.F1StartFrequency, &s.F1StartFrequency)
+	get64(cif.F2StartFrequency, &s.F2StopFrequency)
+	get64(cif.ResolutionBandwidth, &s.ResolutionBandwidth)
+	get64(cif.TuneStepSize, &s.TuneStepSize)
+	get32(cif.NumberPoints, &s.NumberPoints)
+	if cif.DefaultGain {
+		s.DefaultGain.Unpack(buf[offset:])
+		offset += 4
+	}
+	if cif.Threshold {
+		s.Threshold.Unpack(buf[offset:])
+		offset += 4
+	}
+	get32(cif.DwellTime, &s.DwellTime)
+	get32(cif.StartTime, &s.StartTime)
+	get32(cif.Time3, &s.Time3)
+	get32(cif.Time4, &s.Time4)
+	return offset
 }
 
 // SectorStepScan
+// ArraySize, HeaderSize, NumWordsRecord and NumRecords are derived from the
+// subfield CIF and Records when packed.
 type SectorStepScan struct {
 	ArraySize      uint32
 	HeaderSize     uint8
@@ -610,72 +1513,92 @@ type SectorStepScan struct {
 	Records        []SectorStepScanRecord
 }
 
-func (s SectorStepScan) Size() uint32 {
-	return sectorStepScanBytes
+func (s *SectorStepScan) Size() uint32 {
+	return sectorStepScanBytes + uint32(len(s.Records))*s.SubfieldCif.recordSize()
 }
 
 func (s *SectorStepScan) Pack() []byte {
-	// 3 words + the number of words required for each record (NumRecords)
-	retval := make([]byte, 80+(4*int(s.NumRecords)))
-
-	// Array Size - 4 bytes
-	arraySizeWord := uint32(0)
-	arraySizeWord |= uint32(s.ArraySize)
-	binary.BigEndian.PutUint32(retval[0:], arraySizeWord) // word 1 in the buffer
-
-	// Header Size/NumWordsRecord/NumRecords
-	headerSizeWord := uint32(0)
-	headerSizeWord |= (uint32(s.HeaderSize) << 24)
-	headerSizeWord |= (uint32(s.NumWordsRecord) << 12)
-	headerSizeWord |= uint32(s.NumRecords)
-	binary.BigEndian.PutUint32(retval[32:], headerSizeWord) // word 2 in the buffer
-
-	// CIF
-	cifWord := uint32(0)
-	cifWord |= binary.BigEndian.Uint32(s.SubfieldCif.Pack())
-	binary.BigEndian.PutUint32(retval[63:], cifWord) // word 3 in the buffer
+	return mustPack(s)
+}
 
-	// Append N records to the slice - 8 bytes each record
-	for _, record := range s.Records {
-		retval = append(retval, record.Pack()...)
+func (s *SectorStepScan) PackInto(buf []byte) (int, error) {
+	if err := checkSize("SectorStepScan", buf, s.Size()); err != nil {
+		return 0, err
+	}
+	if err := checkArrayCount("SectorStepScan", "NumRecords", uint32(len(s.Records))); err != nil {
+		return 0, err
 	}
+	s.HeaderSize = uint8(sectorStepScanBytes / 4)
+	s.NumWordsRecord = uint16(s.SubfieldCif.recordSize() / 4)
+	s.NumRecords = uint16(len(s.Records))
+	s.ArraySize = s.Size() / 4
+
+	binary.BigEndian.PutUint32(buf[0:], s.ArraySize)
+	headerSizeWord := uint32(s.HeaderSize) << 24
+	headerSizeWord |= uint32(s.NumWordsRecord) << 12
+	headerSizeWord |= uint32(s.NumRecords)
+	binary.BigEndian.PutUint32(buf[4:], headerSizeWord)
+	s.SubfieldCif.PackInto(buf[8:])
 
-	return retval
+	offset := sectorStepScanBytes
+	for i := range s.Records {
+		offset += s.Records[i].packSubfields(buf[offset:], &s.SubfieldCif)
+	}
+	return int(offset), nil
 }
 
 func (s *SectorStepScan) Unpack(buf []byte) {
-
-	s.ArraySize = binary.BigEndian.Uint32(buf[0:4])
-	fmt.Printf("Unpacking header word:\n")
-
-	headerSizeWord := binary.BigEndian.Uint32(buf[32:36])
-
-	fmt.Printf("Unpacking header size:\n")
+	s.ArraySize = binary.BigEndian.Uint32(buf[0:])
+	headerSizeWord := binary.BigEndian.Uint32(buf[4:])
 	s.HeaderSize = uint8(headerSizeWord >> 24)
-
-	fmt.Printf("Unpacking num words record:\n")
 	s.NumWordsRecord = uint16((headerSizeWord >> 12) & 0xFFF)
-
-	fmt.Printf("Unpacking num records:\n")
 	s.NumRecords = uint16(headerSizeWord & 0xFFF)
+	s.SubfieldCif.Unpack(buf[8:])
 
-	fmt.Printf("Unpacking records:\n")
-
-	s.SubfieldCif.Unpack(buf[63:])
-
-	// Unpack Entries (each entry is 4 bytes)
+	offset := 4 * uint32(s.HeaderSize)
+	stride := 4 * uint32(s.NumWordsRecord)
 	s.Records = make([]SectorStepScanRecord, s.NumRecords)
+	for i := range s.Records {
+		s.Records[i].unpackSubfields(buf[offset:], &s.SubfieldCif)
+		offset += stride
+	}
+}
 
-	for i, record := range s.Records {
-		record.Unpack(buf[79*(i*4):]) // Each record is 4 bytes
-		s.Records[i] = record
+func (s *SectorStepScan) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("SectorStepScan", buf, sectorStepScanBytes); err != nil {
+		return err
+	}
+	if err := checkReserved("SectorStepScan", buf, 8, 0x000FFFFF); err != nil {
+		return err
+	}
+	arraySize := binary.BigEndian.Uint32(buf[0:])
+	if err := checkWords("SectorStepScan", buf, 0, arraySize); err != nil {
+		return err
+	}
+	headerSizeWord := binary.BigEndian.Uint32(buf[4:])
+	headerSize := headerSizeWord >> 24
+	numWordsRecord := (headerSizeWord >> 12) & 0xFFF
+	numRecords := headerSizeWord & 0xFFF
+	if headerSize < sectorStepScanBytes/4 {
+		return &ListCountError{Type: "SectorStepScan", List: "HeaderSize", Count: headerSize, Expected: sectorStepScanBytes / 4}
 	}
+	var cif SectorStepScanCIF
+	cif.Unpack(buf[8:])
+	if recordWords := cif.recordSize() / 4; numWordsRecord < recordWords {
+		return &ListCountError{Type: "SectorStepScan", List: "NumWordsRecord", Count: numWordsRecord, Expected: recordWords}
+	}
+	if expected := headerSize + numRecords*numWordsRecord; arraySize != expected {
+		return &ListCountError{Type: "SectorStepScan", List: "ArraySize", Count: arraySize, Expected: expected}
+	}
+	s.Unpack(buf)
+	return nil
 }
 
 // IndexList
 // Supports records containing an Index subfield.
 // Specifies the indicies of the records (which could be a subset of the entire
-// collection) upon which a device is to act.
+// collection) upon which a device is to act. TotalSize and NumEntries are
+// derived from Entries when packed.
 type IndexList struct {
 	TotalSize  uint32
 	EntrySize  uint8
@@ -683,16 +1606,24 @@ type IndexList struct {
 	Entries    []uint32
 }
 
-func (s IndexList) Size() uint32 {
-	if s.NumEntries > 0 {
-		return uint32(32+32+4*s.NumEntries) / 8
-	}
-	return uint32(8) // TotalSize + EntrySize and NumEntries
+func (s *IndexList) Size() uint32 {
+	return 8 + 4*uint32(len(s.Entries)) // TotalSize + EntrySize and NumEntries + Entries
 }
 
 func (s *IndexList) Pack() []byte {
-	totalSize := 8 + 4*int(s.NumEntries)
-	retval := make([]byte, totalSize)
+	return mustPack(s)
+}
+
+func (s *IndexList) PackInto(buf []byte) (int, error) {
+	if err := checkSize("IndexList", buf, s.Size()); err != nil {
+		return 0, err
+	}
+	if numEntries := uint32(len(s.Entries)); numEntries > 0xFFFFF {
+		return 0, &ListCountError{Type: "IndexList", List: "NumEntries", Count: numEntries, Expected: 0xFFFFF}
+	}
+	retval := buf[:s.Size()]
+	s.NumEntries = uint32(len(s.Entries))
+	s.TotalSize = s.Size() / 4
 
 	// Total Size - 4 bytes
 	binary.BigEndian.PutUint32(retval[0:], s.TotalSize)
@@ -700,16 +1631,16 @@ func (s *IndexList) Pack() []byte {
 	// Entry Size  - 4 bytes
 	secondWord := uint32(s.EntrySize) << 28
 	// Num Entries - 20 bits
-	secondWord |= (s.NumEntries & 0x000FFFFF)
+	secondWord |= s.NumEntries
 
 	binary.BigEndian.PutUint32(retval[4:], secondWord)
 
-	// Entries - 1 byte each
+	// Entries - 1 word each
 	for i, entry := range s.Entries {
 		binary.BigEndian.PutUint32(retval[8+i*4:], entry)
 	}
 
-	return retval
+	return len(retval), nil
 }
 
 func (s *IndexList) Unpack(buf []byte) {
@@ -729,6 +1660,103 @@ func (s *IndexList) Unpack(buf []byte) {
 	}
 }
 
+func (s *IndexList) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("IndexList", buf, 8); err != nil {
+		return err
+	}
+	if err := checkReserved("IndexList", buf, 4, 0x0FF00000); err != nil {
+		return err
+	}
+	numEntries := binary.BigEndian.Uint32(buf[4:]) & 0x000FFFFF
+	if err := checkWords("IndexList", buf, 8, numEntries); err != nil {
+		return err
+	}
+	if totalSize := binary.BigEndian.Uint32(buf[0:]); totalSize != 2+numEntries {
+		return &ListCountError{Type: "IndexList", List: "TotalSize", Count: totalSize, Expected: 2 + numEntries}
+	}
+	s.Unpack(buf)
+	return nil
+}
+
+// HealthStatus
+// A user-defined health status code, packed in the lower 16 bits of the
+// word. The upper 16 bits are reserved.
+type HealthStatus uint16
+
+func (h *HealthStatus) Size() uint32 {
+	return healthStatusBytes
+}
+
+func (h *HealthStatus) Pack() []byte {
+	buf := make([]byte, h.Size())
+	h.PackInto(buf)
+	return buf
+}
+
+func (h *HealthStatus) PackInto(buf []byte) (int, error) {
+	if err := checkSize("HealthStatus", buf, h.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint32(buf, uint32(*h))
+	return int(h.Size()), nil
+}
+
+func (h *HealthStatus) Unpack(buf []byte) {
+	*h = HealthStatus(binary.BigEndian.Uint16(buf[2:]))
+}
+
+func (h *HealthStatus) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("HealthStatus", buf, h.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("HealthStatus", buf, 0, 0xFFFF0000); err != nil {
+		return err
+	}
+	h.Unpack(buf)
+	return nil
+}
+
+// V49SpecCompliance
+// The version of the VITA 49 standard the emitter complies with
+type V49SpecCompliance uint32
+
+const (
+	V49Spec0 V49SpecCompliance = iota + 1 // VITA 49.0
+	V49Spec1                              // VITA 49.1
+	V49SpecA                              // VITA 49A
+	V49Spec2                              // VITA 49.2
+)
+
+func (v *V49SpecCompliance) Size() uint32 {
+	return v49SpecComplianceBytes
+}
+
+func (v *V49SpecCompliance) Pack() []byte {
+	buf := make([]byte, v.Size())
+	v.PackInto(buf)
+	return buf
+}
+
+func (v *V49SpecCompliance) PackInto(buf []byte) (int, error) {
+	if err := checkSize("V49SpecCompliance", buf, v.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint32(buf, uint32(*v))
+	return int(v.Size()), nil
+}
+
+func (v *V49SpecCompliance) Unpack(buf []byte) {
+	*v = V49SpecCompliance(binary.BigEndian.Uint32(buf))
+}
+
+func (v *V49SpecCompliance) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("V49SpecCompliance", buf, v.Size()); err != nil {
+		return err
+	}
+	v.Unpack(buf)
+	return nil
+}
+
 // VersionInformation
 type VersionInformation struct {
 	Year        uint8
@@ -737,13 +1765,22 @@ type VersionInformation struct {
 	UserDefined uint16
 }
 
-func (s VersionInformation) Size() uint32 {
+func (s *VersionInformation) Size() uint32 {
 	return versionInformationBytes
 }
 
 func (s *VersionInformation) Pack() []byte {
+	buf := make([]byte, s.Size())
+	s.PackInto(buf)
+	return buf
+}
+
+func (s *VersionInformation) PackInto(buf []byte) (int, error) {
+	if err := checkSize("VersionInformation", buf, s.Size()); err != nil {
+		return 0, err
+	}
 	// 1 word (4 bytes)
-	retval := make([]byte, 4)
+	retval := buf[:s.Size()]
 
 	// Create a 32-bit word (uint32)
 	var word uint32
@@ -768,7 +1805,7 @@ func (s *VersionInformation) Pack() []byte {
 	// Convert the 32-bit word to a byte slice
 	binary.BigEndian.PutUint32(retval, word)
 
-	return retval
+	return len(retval), nil
 }
 
 func (s *VersionInformation) Unpack(buf []byte) {
@@ -783,3 +1820,68 @@ func (s *VersionInformation) Unpack(buf []byte) {
 
 	s.UserDefined = uint16(word & 0x3FF)
 }
+
+func (s *VersionInformation) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("VersionInformation", buf, s.Size()); err != nil {
+		return err
+	}
+	s.Unpack(buf)
+	return nil
+}
+
+// BufferSize
+// The size of the emitter's buffer in bytes along with its fill level and
+// status. Level is the fill level in units of 1/256 of the buffer size.
+type BufferSize struct {
+	BufferSize  uint32
+	Level       uint8
+	Overflow    bool
+	AlmostFull  bool
+	AlmostEmpty bool
+	Underflow   bool
+}
+
+func (b *BufferSize) Size() uint32 {
+	return bufferSizeBytes
+}
+
+func (b *BufferSize) Pack() []byte {
+	buf := make([]byte, b.Size())
+	b.PackInto(buf)
+	return buf
+}
+
+func (b *BufferSize) PackInto(buf []byte) (int, error) {
+	if err := checkSize("BufferSize", buf, b.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint32(buf[0:], b.BufferSize)
+	word := uint32(b.Level) << 8
+	word |= indicatorFieldUint(b.Overflow, 3)
+	word |= indicatorFieldUint(b.AlmostFull, 2)
+	word |= indicatorFieldUint(b.AlmostEmpty, 1)
+	word |= indicatorFieldUint(b.Underflow, 0)
+	binary.BigEndian.PutUint32(buf[4:], word)
+	return int(b.Size()), nil
+}
+
+func (b *BufferSize) Unpack(buf []byte) {
+	b.BufferSize = binary.BigEndian.Uint32(buf[0:])
+	word := binary.BigEndian.Uint32(buf[4:])
+	b.Level = uint8(word >> 8)
+	b.Overflow = indicatorFieldBool(word, 3)
+	b.AlmostFull = indicatorFieldBool(word, 2)
+	b.AlmostEmpty = indicatorFieldBool(word, 1)
+	b.Underflow = indicatorFieldBool(word, 0)
+}
+
+func (b *BufferSize) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("BufferSize", buf, b.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("BufferSize", buf, 4, 0xFFFF00F0); err != nil {
+		return err
+	}
+	b.Unpack(buf)
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPolarizationBytes(t *testing.T) {
@@ -1358,12 +1359,12 @@ func TestIndexListDefault(t *testing.T) {
 
 	// Pack
 	packed := s.Pack()
-	expected := []byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0}
+	expected := []byte{0x0, 0x0, 0x0, 0x2, 0x0, 0x0, 0x0, 0x0}
 	assert.Equal(t, expected, packed)
 
 	// Unpack
 	s.Unpack(packed)
-	assert.Equal(t, uint32(0), s.TotalSize)
+	assert.Equal(t, uint32(2), s.TotalSize)
 	assert.Equal(t, uint8(0), s.EntrySize)
 	assert.Equal(t, uint32(0), s.NumEntries)
 	assert.Empty(t, s.Entries)
@@ -1380,11 +1381,11 @@ func TestIndexList(t *testing.T) {
 	}{
 		{
 			name:       "Rule 9.3.2.4/1",
-			TotalSize:  1,
+			TotalSize:  2,
 			EntrySize:  0xF,
 			NumEntries: 0,
 			Entries:    []uint32{},
-			expected:   []byte{0x0, 0x0, 0x0, 0x1, 0xF0, 0x0, 0x0, 0x0},
+			expected:   []byte{0x0, 0x0, 0x0, 0x2, 0xF0, 0x0, 0x0, 0x0},
 		},
 	}
 
@@ -1558,243 +1559,164 @@ func TestSectorStepScanRecordBytes(t *testing.T) {
 	assert.Equal(t, sectorStepScanRecordBytes, s.Size())
 }
 
-// func TestSectorStepScanRecordDefault(t *testing.T) {
-// 	s := SectorStepScanRecord{}
-// 	assert.Equal(t, uint32(0), s.SectorNumber)
-// 	assert.Equal(t, uint64(0), s.F1StartFrequency)
-// 	assert.Equal(t, uint64(0), s.F2StopFrequency)
-// 	assert.Equal(t, uint64(0), s.ResolutionBandwidth)
-// 	assert.Equal(t, uint64(0), s.TuneStepSize)
-// 	assert.Equal(t, uint32(0), s.NumberPoints)
-// 	assert.Equal(t, Gain{}, s.DefaultGain) // Assuming Gain is a struct
-// 	assert.Equal(t, Gain{}, s.Threshold)   // Assuming Gain is a struct
-// 	assert.Equal(t, uint32(0), s.DwellTime)
-// 	assert.Equal(t, uint32(0), s.StartTime)
-// 	assert.Equal(t, uint32(0), s.Time3)
-// 	assert.Equal(t, uint32(0), s.Time4)
-
-// 	// Pack
-// 	packed := s.Pack()
-// 	expected := make([]byte, 80)
-// 	assert.Equal(t, expected, packed)
-
-// 	// Unpack
-// 	s.Unpack(packed)
-// 	assert.Equal(t, uint32(0), s.SectorNumber)
-// 	assert.Equal(t, uint64(0), s.F1StartFrequency)
-// 	assert.Equal(t, uint64(0), s.F2StopFrequency)
-// 	assert.Equal(t, uint64(0), s.ResolutionBandwidth)
-// 	assert.Equal(t, uint64(0), s.TuneStepSize)
-// 	assert.Equal(t, uint32(0), s.NumberPoints)
-// 	assert.Equal(t, Gain{}, s.DefaultGain)
-// 	assert.Equal(t, Gain{}, s.Threshold)
-// 	assert.Equal(t, uint32(0), s.DwellTime)
-// 	assert.Equal(t, uint32(0), s.StartTime)
-// 	assert.Equal(t, uint32(0), s.Time3)
-// 	assert.Equal(t, uint32(0), s.Time4)
-// }
-
-// func TestSectorStepScanRecord(t *testing.T) {
-// 	cases := []struct {
-// 		name                string
-// 		SectorNumber        uint32
-// 		F1StartFrequency    uint64
-// 		F2StopFrequency     uint64
-// 		ResolutionBandwidth uint64
-// 		TuneStepSize        uint64
-// 		NumberPoints        uint32
-// 		DefaultGain         Gain
-// 		Threshold           Gain
-// 		DwellTime           uint32
-// 		StartTime           uint32
-// 		Time3               uint32
-// 		Time4               uint32
-// 		expected            []byte
-// 	}{
-// 		{
-// 			name:                "Populate arbitrary record",
-// 			SectorNumber:        1,
-// 			F1StartFrequency:    1000,
-// 			F2StopFrequency:     2000,
-// 			ResolutionBandwidth: 100,
-// 			TuneStepSize:        200,
-// 			NumberPoints:        256,
-// 			DefaultGain:         Gain{},
-// 			Threshold:           Gain{},
-// 			DwellTime:           3,
-// 			StartTime:           10,
-// 			Time3:               15,
-// 			Time4:               20,
-// 			expected:            []byte{0x0, 0x0, 0x0, 0x14, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xf, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xa, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xc8, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x64, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x7, 0xd0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x3, 0xe8, 0x0, 0x0, 0x0, 0x1},
-// 		},
-// 		{
-// 			name:                "Record with only required fields",
-// 			SectorNumber:        1,
-// 			F1StartFrequency:    500,
-// 			F2StopFrequency:     0,
-// 			ResolutionBandwidth: 0,
-// 			TuneStepSize:        0,
-// 			NumberPoints:        0,
-// 			DefaultGain:         Gain{},
-// 			Threshold:           Gain{},
-// 			DwellTime:           0,
-// 			StartTime:           0,
-// 			Time3:               5,
-// 			Time4:               0,
-// 			expected:            []byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x5, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1, 0xf4, 0x0, 0x0, 0x0, 0x1},
-// 		},
-// 	}
-
-// 	for _, tc := range cases {
-// 		t.Run(tc.name, func(t *testing.T) {
-// 			s := SectorStepScanRecord{
-// 				SectorNumber:        tc.SectorNumber,
-// 				F1StartFrequency:    tc.F1StartFrequency,
-// 				F2StopFrequency:     tc.F2StopFrequency,
-// 				ResolutionBandwidth: tc.ResolutionBandwidth,
-// 				TuneStepSize:        tc.TuneStepSize,
-// 				NumberPoints:        tc.NumberPoints,
-// 				DefaultGain:         tc.DefaultGain,
-// 				Threshold:           tc.Threshold,
-// 				DwellTime:           tc.DwellTime,
-// 				StartTime:           tc.StartTime,
-// 				Time3:               tc.Time3,
-// 				Time4:               tc.Time4,
-// 			}
-
-// 			// Pack
-// 			packed := s.Pack()
-// 			assert.Equal(t, tc.expected, packed)
-
-// 			// Unpack
-// 			s.Unpack(packed)
-// 			assert.Equal(t, tc.SectorNumber, s.SectorNumber)
-// 			assert.Equal(t, tc.F1StartFrequency, s.F1StartFrequency)
-// 			assert.Equal(t, tc.F2StopFrequency, s.F2StopFrequency)
-// 			assert.Equal(t, tc.ResolutionBandwidth, s.ResolutionBandwidth)
-// 			assert.Equal(t, tc.TuneStepSize, s.TuneStepSize)
-// 			assert.Equal(t, tc.NumberPoints, s.NumberPoints)
-// 			assert.Equal(t, tc.DefaultGain, s.DefaultGain)
-// 			assert.Equal(t, tc.Threshold, s.Threshold)
-// 			assert.Equal(t, tc.DwellTime, s.DwellTime)
-// 			assert.Equal(t, tc.StartTime, s.StartTime)
-// 			assert.Equal(t, tc.Time3, s.Time3)
-// 			assert.Equal(t, tc.Time4, s.Time4)
-// 		})
-// 	}
-// }
-
-// func TestSectorStepScanBytes(t *testing.T) {
-// 	s := SectorStepScan{}
-// 	assert.Equal(t, sectorStepScanBytes, s.Size())
-// }
-
-// func TestSectorStepScanDefault(t *testing.T) {
-// 	s := SectorStepScan{}
-// 	assert.Equal(t, uint32(0), s.ArraySize)
-// 	assert.Equal(t, uint8(0), s.HeaderSize)
-// 	assert.Equal(t, uint16(0), s.NumWordsRecord)
-// 	assert.Equal(t, uint16(0), s.NumRecords)
-// 	assert.Equal(t, SectorStepScanCIF{}, s.SubfieldCif)
-// 	assert.Empty(t, s.Records)
-
-// 	// Pack
-// 	packed := s.Pack()
-// 	expected := make([]byte, 80) // Adjust based on expected size
-// 	assert.Equal(t, expected, packed)
-
-// 	// Unpack
-// 	s.Unpack(packed)
-// 	assert.Equal(t, uint32(0), s.ArraySize)
-// 	assert.Equal(t, uint8(0), s.HeaderSize)
-// 	assert.Equal(t, uint16(0), s.NumWordsRecord)
-// 	assert.Equal(t, uint16(0), s.NumRecords)
-// 	assert.Equal(t, SectorStepScanCIF{}, s.SubfieldCif)
-// 	assert.Empty(t, s.Records)
-// }
-
-// func TestSectorStepScan(t *testing.T) {
-// 	cif := SectorStepScanCIF{
-// 		SectorNumber:        true,
-// 		F1StartFrequency:    true,
-// 		F2StartFrequency:    false,
-// 		ResolutionBandwidth: false,
-// 		TuneStepSize:        false,
-// 		NumberPoints:        false,
-// 		DefaultGain:         false,
-// 		Threshold:           false,
-// 		DwellTime:           false,
-// 		StartTime:           false,
-// 		Time3:               false,
-// 		Time4:               false,
-// 	}
-
-// 	records := []SectorStepScanRecord{
-// 		{
-// 			SectorNumber:        1,
-// 			F1StartFrequency:    1000,
-// 			F2StopFrequency:     0,
-// 			ResolutionBandwidth: 0,
-// 			TuneStepSize:        0,
-// 			NumberPoints:        0,
-// 			DefaultGain:         Gain{Stage1: 0, Stage2: 0}, // Replace with actual Gain initialization
-// 			Threshold:           Gain{Stage1: 0, Stage2: 0}, // Replace with actual Gain initialization
-// 			DwellTime:           0,
-// 			StartTime:           0,
-// 			Time3:               0,
-// 			Time4:               0,
-// 		},
-// 	}
-
-// 	cases := []struct {
-// 		name           string
-// 		arraySize      uint32
-// 		headerSize     uint8
-// 		numWordsRecord uint16
-// 		numRecords     uint16
-// 		subfieldCif    SectorStepScanCIF
-// 		records        []SectorStepScanRecord
-// 		expected       []byte
-// 	}{
-// 		{
-// 			name:           "Single Record",
-// 			arraySize:      1,
-// 			headerSize:     4,
-// 			numWordsRecord: 8,
-// 			numRecords:     1,
-// 			subfieldCif:    cif,
-// 			records:        records,
-// 			expected:       []byte{0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x4, 0x0, 0x80, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xc0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0},
-// 		},
-// 	}
-
-// 	for _, tc := range cases {
-// 		t.Run(tc.name, func(t *testing.T) {
-// 			s := SectorStepScan{
-// 				ArraySize:      tc.arraySize,
-// 				HeaderSize:     tc.headerSize,
-// 				NumWordsRecord: tc.numWordsRecord,
-// 				NumRecords:     tc.numRecords,
-// 				SubfieldCif:    tc.subfieldCif,
-// 				Records:        tc.records,
-// 			}
-
-// 			// Pack
-// 			packed := s.Pack()
-// 			assert.Equal(t, tc.expected, packed)
-
-// 			// Unpack
-// 			s.Unpack(packed)
-
-// 			assert.Equal(t, tc.arraySize, s.ArraySize)
-// 			assert.Equal(t, tc.headerSize, s.HeaderSize)
-// 			assert.Equal(t, tc.numWordsRecord, s.NumWordsRecord)
-// 			assert.Equal(t, tc.numRecords, s.NumRecords)
-// 			assert.Equal(t, tc.subfieldCif, s.SubfieldCif)
-// 			assert.Equal(t, tc.records, s.Records)
-// 		})
-// 	}
-// }
+func TestSectorStepScanRecord(t *testing.T) {
+	s := SectorStepScanRecord{
+		SectorNumber:        1,
+		F1StartFrequency:    1000,
+		F2StopFrequency:     2000,
+		ResolutionBandwidth: 100,
+		TuneStepSize:        200,
+		NumberPoints:        256,
+		DefaultGain:         Gain{Stage1: 1},
+		Threshold:           Gain{Stage2: 1},
+		DwellTime:           3,
+		StartTime:           10,
+		Time3:               15,
+		Time4:               20,
+	}
+	expected := []byte{
+		0x0, 0x0, 0x0, 0x1,
+		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x3, 0xe8,
+		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x7, 0xd0,
+		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x64,
+		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xc8,
+		0x0, 0x0, 0x1, 0x0,
+		0x0, 0x0, 0x0, 0x80,
+		0x0, 0x80, 0x0, 0x0,
+		0x0, 0x0, 0x0, 0x3,
+		0x0, 0x0, 0x0, 0xa,
+		0x0, 0x0, 0x0, 0xf,
+		0x0, 0x0, 0x0, 0x14,
+	}
+
+	packed := s.Pack()
+	assert.Equal(t, expected, packed)
+
+	unpacked := SectorStepScanRecord{}
+	assert.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, s, unpacked)
+
+	assert.ErrorIs(t, unpacked.UnmarshalBinary(packed[:60]), ErrShortBuffer)
+}
+
+func TestSectorStepScanBytes(t *testing.T) {
+	s := SectorStepScan{}
+	assert.Equal(t, sectorStepScanBytes, s.Size())
+}
+
+func TestSectorStepScan(t *testing.T) {
+	cases := []struct {
+		name     string
+		cif      SectorStepScanCIF
+		records  []SectorStepScanRecord
+		expected []byte
+	}{
+		{
+			name:     "No records",
+			expected: []byte{0x0, 0x0, 0x0, 0x3, 0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0},
+		},
+		{
+			name: "Required subfields",
+			cif:  SectorStepScanCIF{SectorNumber: true, F1StartFrequency: true},
+			records: []SectorStepScanRecord{
+				{SectorNumber: 1, F1StartFrequency: 1000},
+				{SectorNumber: 2, F1StartFrequency: 2000},
+			},
+			expected: []byte{
+				0x0, 0x0, 0x0, 0x9,
+				0x3, 0x0, 0x30, 0x2,
+				0xc0, 0x0, 0x0, 0x0,
+				0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x3, 0xe8,
+				0x0, 0x0, 0x0, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x7, 0xd0,
+			},
+		},
+		{
+			name: "Sparse subfields",
+			cif:  SectorStepScanCIF{SectorNumber: true, F1StartFrequency: true, DwellTime: true},
+			records: []SectorStepScanRecord{
+				{SectorNumber: 1, F1StartFrequency: 1000, DwellTime: 5},
+			},
+			expected: []byte{
+				0x0, 0x0, 0x0, 0x7,
+				0x3, 0x0, 0x40, 0x1,
+				0xc0, 0x80, 0x0, 0x0,
+				0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x3, 0xe8, 0x0, 0x0, 0x0, 0x5,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := SectorStepScan{
+				SubfieldCif: tc.cif,
+				Records:     tc.records,
+			}
+
+			// Pack
+			packed := s.Pack()
+			assert.Equal(t, tc.expected, packed)
+			assert.Equal(t, uint32(len(tc.expected)/4), s.ArraySize)
+			assert.Equal(t, uint16(len(tc.records)), s.NumRecords)
+
+			// Unpack
+			unpacked := SectorStepScan{}
+			assert.NoError(t, unpacked.UnmarshalBinary(packed))
+			assert.Equal(t, s.ArraySize, unpacked.ArraySize)
+			assert.Equal(t, s.HeaderSize, unpacked.HeaderSize)
+			assert.Equal(t, s.NumWordsRecord, unpacked.NumWordsRecord)
+			assert.Equal(t, s.NumRecords, unpacked.NumRecords)
+			assert.Equal(t, tc.cif, unpacked.SubfieldCif)
+			assert.Equal(t, len(tc.records), len(unpacked.Records))
+			for i := range tc.records {
+				assert.Equal(t, tc.records[i], unpacked.Records[i])
+			}
+		})
+	}
+}
+
+func TestSectorStepScanUnmarshalErrors(t *testing.T) {
+	valid := (&SectorStepScan{
+		SubfieldCif: SectorStepScanCIF{SectorNumber: true, F1StartFrequency: true},
+		Records:     []SectorStepScanRecord{{SectorNumber: 1, F1StartFrequency: 1000}},
+	}).Pack()
+	corrupt := func(offset int, value byte) []byte {
+		buf := append([]byte{}, valid...)
+		buf[offset] = value
+		return buf
+	}
+
+	cases := []struct {
+		name     string
+		buf      []byte
+		expected error
+	}{
+		{"Short header", valid[:8], ErrShortBuffer},
+		{"Short records", valid[:16], ErrShortBuffer},
+		{"Reserved CIF bits", corrupt(11, 0x01), ErrReservedBits},
+		{"Header size too small", corrupt(4, 0x02), ErrListCount},
+		{"Record too small for CIF", corrupt(6, 0x20), ErrListCount},
+		{"Array size disagrees with records", corrupt(7, 0x00), ErrListCount},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := SectorStepScan{}
+			assert.ErrorIs(t, s.UnmarshalBinary(tc.buf), tc.expected)
+		})
+	}
+}
+
+func TestSectorStepScanCounts(t *testing.T) {
+	// NumRecords is packed in 12 bits
+	s := SectorStepScan{SubfieldCif: SectorStepScanCIF{SectorNumber: true}, Records: make([]SectorStepScanRecord, 4095)}
+	_, err := s.PackInto(make([]byte, s.Size()))
+	assert.NoError(t, err)
+
+	s.Records = make([]SectorStepScanRecord, 4096)
+	_, err = s.PackInto(make([]byte, s.Size()))
+	assert.ErrorIs(t, err, ErrListCount)
+	assert.Panics(t, func() { s.Pack() })
+}
 
 func TestVersionInformationBytes(t *testing.T) {
 	s := VersionInformation{}
@@ -1869,3 +1791,465 @@ func TestVersionInformation(t *testing.T) {
 		})
 	}
 }
+
+func TestIndexListCounts(t *testing.T) {
+	// TotalSize and NumEntries are derived from Entries
+	s := IndexList{EntrySize: 4, TotalSize: 9, NumEntries: 1, Entries: []uint32{1, 2, 3}}
+	packed := s.Pack()
+	assert.Equal(t, []byte{0, 0, 0, 5, 0x40, 0, 0, 3, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3}, packed)
+	assert.Equal(t, uint32(5), s.TotalSize)
+	assert.Equal(t, uint32(3), s.NumEntries)
+
+	unpacked := IndexList{}
+	require.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, s, unpacked)
+
+	// NumEntries is packed in 20 bits
+	s.Entries = make([]uint32, 0x100000)
+	_, err := s.PackInto(make([]byte, s.Size()))
+	assert.ErrorIs(t, err, ErrListCount)
+}
+
+func TestIndexListUnmarshalBinary(t *testing.T) {
+	s := IndexList{}
+	assert.ErrorIs(t, s.UnmarshalBinary([]byte{0, 0, 0, 2}), ErrShortBuffer)
+	assert.ErrorIs(t, s.UnmarshalBinary([]byte{0, 0, 0, 2, 0x01, 0, 0, 0}), ErrReservedBits)
+	assert.ErrorIs(t, s.UnmarshalBinary([]byte{0, 0, 0, 3, 0, 0, 0, 1}), ErrShortBuffer)
+	assert.ErrorIs(t, s.UnmarshalBinary([]byte{0, 0, 0, 2, 0, 0, 0, 1, 0, 0, 0, 7}), ErrListCount)
+	assert.NoError(t, s.UnmarshalBinary([]byte{0, 0, 0, 3, 0x20, 0, 0, 1, 0, 0, 0, 7}))
+	assert.Equal(t, []uint32{7}, s.Entries)
+}
+
+func TestPhaseOffset(t *testing.T) {
+	cases := []struct {
+		name     string
+		offset   PhaseOffset
+		expected []byte
+	}{
+		{name: "1 radian", offset: 1.0, expected: []byte{0, 0, 0, 0x80}},
+		{name: "-pi/2 radians", offset: -1.5703125, expected: []byte{0, 0, 0xFF, 0x37}},
+		{name: "resolution", offset: 0.0078125, expected: []byte{0, 0, 0, 0x01}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := tc.offset
+			assert.Equal(t, phaseOffsetBytes, p.Size())
+			packed := p.Pack()
+			assert.Equal(t, tc.expected, packed)
+			p = 0
+			assert.NoError(t, p.UnmarshalBinary(packed))
+			assert.Equal(t, tc.offset, p)
+		})
+	}
+	p := PhaseOffset(0)
+	assert.ErrorIs(t, p.UnmarshalBinary([]byte{0, 0x01, 0, 0}), ErrReservedBits)
+}
+
+func TestPointingVectorCIF(t *testing.T) {
+	c := PointingVectorCIF{}
+	assert.Equal(t, pointingVectorCIFBytes, c.Size())
+	assert.Equal(t, []byte{0, 0, 0, 0}, c.Pack())
+
+	// Each subfield uses the bit of the CIF1 field it shares a format with
+	packed := allPointingVectorSubfields.Pack()
+	assert.Equal(t, []byte{0x6F, 0, 0, 0}, packed)
+	assert.NoError(t, c.UnmarshalBinary(packed))
+	assert.Equal(t, allPointingVectorSubfields, c)
+
+	assert.ErrorIs(t, c.UnmarshalBinary([]byte{0x80, 0, 0, 0}), ErrReservedBits)
+	assert.ErrorIs(t, c.UnmarshalBinary([]byte{0x10, 0, 0, 0}), ErrReservedBits)
+	assert.ErrorIs(t, c.UnmarshalBinary([]byte{0, 0, 0, 1}), ErrReservedBits)
+	assert.ErrorIs(t, c.UnmarshalBinary([]byte{0, 0, 0}), ErrShortBuffer)
+}
+
+func TestPointingVectorRecord(t *testing.T) {
+	r := PointingVectorRecord{
+		Polarization:         Polarization{TiltAngle: 1},
+		PointingVector:       PointingVector{Elevation: 1, Azimuthal: 2},
+		SpatialScanType:      5,
+		SpatialReferenceType: SpatialReferenceType{SpatialIdentifier: 7, BeamType: 1},
+		BeamWidth:            BeamWidth{Horizontal: 1, Vertical: 0.5},
+		Range:                2,
+	}
+	assert.Equal(t, pointingVectorRecordBytes, r.Size())
+	expected := []byte{
+		0x20, 0, 0, 0,
+		0, 0x80, 0x01, 0,
+		0, 0, 0, 5,
+		0, 7, 0, 1,
+		0, 0x80, 0, 0x40,
+		0, 0, 0, 0x80,
+	}
+	packed := r.Pack()
+	assert.Equal(t, expected, packed)
+
+	unpacked := PointingVectorRecord{}
+	assert.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, r, unpacked)
+
+	assert.ErrorIs(t, unpacked.UnmarshalBinary(packed[:20]), ErrShortBuffer)
+}
+
+func TestPointingVectorStructure(t *testing.T) {
+	cases := []struct {
+		name     string
+		cif      PointingVectorCIF
+		records  []PointingVectorRecord
+		expected []byte
+	}{
+		{
+			name:     "No records",
+			expected: []byte{0, 0, 0, 3, 0x03, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "Sparse subfields",
+			cif:  PointingVectorCIF{PointingVector: true, Range: true},
+			records: []PointingVectorRecord{
+				{PointingVector: PointingVector{Elevation: 1, Azimuthal: 2}, Range: 2},
+				{PointingVector: PointingVector{Elevation: -1}, Range: 1},
+			},
+			expected: []byte{
+				0, 0, 0, 7,
+				0x03, 0, 0x20, 0x02,
+				0x21, 0, 0, 0,
+				0, 0x80, 0x01, 0, 0, 0, 0, 0x80,
+				0xFF, 0x80, 0, 0, 0, 0, 0, 0x40,
+			},
+		},
+		{
+			name: "All subfields",
+			cif:  allPointingVectorSubfields,
+			records: []PointingVectorRecord{
+				{Polarization: Polarization{TiltAngle: 1}, SpatialScanType: 5, BeamWidth: BeamWidth{Vertical: 0.5}},
+			},
+			expected: []byte{
+				0, 0, 0, 9,
+				0x03, 0, 0x60, 0x01,
+				0x6F, 0, 0, 0,
+				0x20, 0, 0, 0,
+				0, 0, 0, 0,
+				0, 0, 0, 5,
+				0, 0, 0, 0,
+				0, 0, 0, 0x40,
+				0, 0, 0, 0,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := PointingVectorStructure{
+				SubfieldCif: tc.cif,
+				Records:     tc.records,
+			}
+
+			// Pack
+			packed := p.Pack()
+			assert.Equal(t, tc.expected, packed)
+			assert.Equal(t, uint32(len(tc.expected)/4), p.ArraySize)
+			assert.Equal(t, uint16(len(tc.records)), p.NumRecords)
+
+			// Unpack
+			unpacked := PointingVectorStructure{}
+			assert.NoError(t, unpacked.UnmarshalBinary(packed))
+			assert.Equal(t, p.ArraySize, unpacked.ArraySize)
+			assert.Equal(t, p.HeaderSize, unpacked.HeaderSize)
+			assert.Equal(t, p.NumWordsRecord, unpacked.NumWordsRecord)
+			assert.Equal(t, p.NumRecords, unpacked.NumRecords)
+			assert.Equal(t, tc.cif, unpacked.SubfieldCif)
+			assert.Equal(t, len(tc.records), len(unpacked.Records))
+			for i := range tc.records {
+				assert.Equal(t, tc.records[i], unpacked.Records[i])
+			}
+		})
+	}
+}
+
+func TestPointingVectorStructureUnmarshalErrors(t *testing.T) {
+	valid := (&PointingVectorStructure{
+		SubfieldCif: PointingVectorCIF{PointingVector: true, Range: true},
+		Records:     []PointingVectorRecord{{Range: 1}},
+	}).Pack()
+	corrupt := func(offset int, value byte) []byte {
+		buf := append([]byte{}, valid...)
+		buf[offset] = value
+		return buf
+	}
+
+	cases := []struct {
+		name     string
+		buf      []byte
+		expected error
+	}{
+		{"Short header", valid[:8], ErrShortBuffer},
+		{"Short records", valid[:16], ErrShortBuffer},
+		{"Reserved CIF bits", corrupt(11, 0x01), ErrReservedBits},
+		{"Header size too small", corrupt(4, 0x02), ErrListCount},
+		{"Record too small for CIF", corrupt(6, 0x10), ErrListCount},
+		{"Array size disagrees with records", corrupt(7, 0x00), ErrListCount},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := PointingVectorStructure{}
+			assert.ErrorIs(t, p.UnmarshalBinary(tc.buf), tc.expected)
+		})
+	}
+}
+
+func TestPointingVectorStructureCounts(t *testing.T) {
+	// NumRecords is packed in 12 bits
+	p := PointingVectorStructure{SubfieldCif: PointingVectorCIF{Range: true}, Records: make([]PointingVectorRecord, 4095)}
+	packed := make([]byte, p.Size())
+	_, err := p.PackInto(packed)
+	require.NoError(t, err)
+	assert.NoError(t, (&PointingVectorStructure{}).UnmarshalBinary(packed))
+
+	p.Records = make([]PointingVectorRecord, 4096)
+	_, err = p.PackInto(make([]byte, p.Size()))
+	assert.ErrorIs(t, err, ErrListCount)
+	assert.Panics(t, func() { p.Pack() })
+}
+
+func TestSpatialScanType(t *testing.T) {
+	s := SpatialScanType(0xABCD)
+	assert.Equal(t, spatialScanTypeBytes, s.Size())
+	packed := s.Pack()
+	assert.Equal(t, []byte{0, 0, 0xAB, 0xCD}, packed)
+	s = 0
+	assert.NoError(t, s.UnmarshalBinary(packed))
+	assert.Equal(t, SpatialScanType(0xABCD), s)
+	assert.ErrorIs(t, s.UnmarshalBinary([]byte{0x80, 0, 0, 0}), ErrReservedBits)
+}
+
+func TestRange(t *testing.T) {
+	cases := []struct {
+		name     string
+		rng      Range
+		expected []byte
+		unpacked Range
+	}{
+		{name: "1 meter", rng: 1.0, expected: []byte{0, 0, 0, 0x40}, unpacked: 1.0},
+		{name: "resolution", rng: 0.015625, expected: []byte{0, 0, 0, 0x01}, unpacked: 0.015625},
+		{name: "maximum", rng: 67108863.984375, expected: []byte{0xFF, 0xFF, 0xFF, 0xFF}, unpacked: 67108863.984375},
+		{name: "negative", rng: -1.0, expected: []byte{0, 0, 0, 0}, unpacked: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := tc.rng
+			assert.Equal(t, rangeBytes, r.Size())
+			packed := r.Pack()
+			assert.Equal(t, tc.expected, packed)
+			assert.NoError(t, r.UnmarshalBinary(packed))
+			assert.Equal(t, tc.unpacked, r)
+		})
+	}
+}
+
+func TestCompressionPoint(t *testing.T) {
+	c := CompressionPoint(-12.25)
+	assert.Equal(t, compressionPointBytes, c.Size())
+	packed := c.Pack()
+	assert.Equal(t, []byte{0, 0, 0xF9, 0xE0}, packed)
+	c = 0
+	assert.NoError(t, c.UnmarshalBinary(packed))
+	assert.Equal(t, CompressionPoint(-12.25), c)
+	assert.ErrorIs(t, c.UnmarshalBinary([]byte{0, 0x80, 0, 0}), ErrReservedBits)
+}
+
+func TestArrayOfCifs(t *testing.T) {
+	a := ArrayOfCifs{
+		Records: []Cifs{
+			{Cif0: Cif0{IndicatorField0: IndicatorField0{Bandwidth: true}, Bandwidth: 20e6}},
+			{
+				Cif0: Cif0{IndicatorField0: IndicatorField0{Temperature: true, If1Enable: true}, Temperature: 25.5},
+				Cif1: Cif1{IndicatorField1: IndicatorField1{Range: true}, Range: 2.0},
+			},
+		},
+	}
+	assert.Equal(t, uint32(40), a.Size())
+	packed := a.Pack()
+	assert.Equal(t, []byte{
+		0, 0, 0, 10,
+		0x02, 0, 0x40, 0x02,
+		0x20, 0, 0, 0, 0, 0, 0x13, 0x12, 0xD0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0x04, 0, 0x02, 0x01, 0, 0, 0, 0, 0, 0x06, 0x60, 0, 0, 0, 0x80,
+	}, packed)
+	assert.Equal(t, uint32(10), a.ArraySize)
+	assert.Equal(t, uint16(4), a.NumWordsRecord)
+	assert.Equal(t, uint16(2), a.NumRecords)
+
+	unpacked := ArrayOfCifs{}
+	assert.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, a, unpacked)
+	assert.Equal(t, Temperature(25.5), unpacked.Records[1].Cif0.Temperature)
+	unpacked = ArrayOfCifs{}
+	unpacked.Unpack(packed)
+	assert.Equal(t, a, unpacked)
+
+	// The array is carried by CIF1 like any other field
+	c := Cifs{
+		Cif0: Cif0{IndicatorField0: IndicatorField0{If1Enable: true}},
+		Cif1: Cif1{IndicatorField1: IndicatorField1{ArrayOfCifs: true}, ArrayOfCifs: a},
+	}
+	packed = c.Pack()
+	assert.Equal(t, []byte{0, 0, 0, 0x02, 0, 0, 0x08, 0}, packed[:8])
+	cifs := Cifs{}
+	assert.NoError(t, cifs.UnmarshalBinary(packed))
+	assert.Equal(t, c, cifs)
+
+	// An empty array is only the header
+	empty := ArrayOfCifs{}
+	assert.Equal(t, []byte{0, 0, 0, 2, 0x02, 0, 0, 0}, empty.Pack())
+}
+
+func TestArrayOfCifsUnmarshalErrors(t *testing.T) {
+	a := ArrayOfCifs{}
+	assert.ErrorIs(t, a.UnmarshalBinary([]byte{0, 0, 0, 2}), ErrShortBuffer)
+	// ArraySize claims more words than the buffer holds
+	assert.ErrorIs(t, a.UnmarshalBinary([]byte{0, 0, 0, 3, 0x02, 0, 0, 0}), ErrShortBuffer)
+	// HeaderSize smaller than the header
+	assert.ErrorIs(t, a.UnmarshalBinary([]byte{0, 0, 0, 2, 0x01, 0, 0, 0}), ErrListCount)
+	// ArraySize disagrees with the record counts
+	assert.ErrorIs(t, a.UnmarshalBinary([]byte{0, 0, 0, 2, 0x02, 0, 0x10, 0x01}), ErrListCount)
+	// A record whose fields overrun NumWordsRecord
+	assert.ErrorIs(t, a.UnmarshalBinary([]byte{0, 0, 0, 3, 0x02, 0, 0x10, 0x01, 0x20, 0, 0, 0}), ErrShortBuffer)
+	assert.Nil(t, a.Records)
+}
+
+func TestArrayOfCifsCounts(t *testing.T) {
+	// The record counts are packed in 12 bits
+	a := ArrayOfCifs{Records: make([]Cifs, 4095)}
+	_, err := a.PackInto(make([]byte, a.Size()))
+	require.NoError(t, err)
+
+	long := Cifs{Cif0: Cif0{IndicatorField0: IndicatorField0{GpsAscii: true}, GpsAscii: GpsAscii{NumberOfWords: 4096}}}
+	cases := []struct {
+		name    string
+		records []Cifs
+	}{
+		{"Too many records", make([]Cifs, 4096)},
+		{"Record too long", []Cifs{long}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := ArrayOfCifs{Records: tc.records}
+			_, err := a.PackInto(make([]byte, a.Size()))
+			assert.ErrorIs(t, err, ErrListCount)
+			assert.Panics(t, func() { a.Pack() })
+
+			// The error reaches the packet carrying the array
+			p := ContextPacket{Cifs: Cifs{
+				Cif0: Cif0{IndicatorField0: IndicatorField0{If1Enable: true}},
+				Cif1: Cif1{IndicatorField1: IndicatorField1{ArrayOfCifs: true}, ArrayOfCifs: a},
+			}}
+			_, err = p.AppendPack(nil)
+			assert.ErrorIs(t, err, ErrListCount)
+		})
+	}
+}
+
+func TestHealthStatus(t *testing.T) {
+	h := HealthStatus(0x1234)
+	assert.Equal(t, healthStatusBytes, h.Size())
+	packed := h.Pack()
+	assert.Equal(t, []byte{0, 0, 0x12, 0x34}, packed)
+	h = 0
+	assert.NoError(t, h.UnmarshalBinary(packed))
+	assert.Equal(t, HealthStatus(0x1234), h)
+	assert.ErrorIs(t, h.UnmarshalBinary([]byte{0, 0x01, 0, 0}), ErrReservedBits)
+}
+
+func TestV49SpecCompliance(t *testing.T) {
+	cases := []struct {
+		name       string
+		compliance V49SpecCompliance
+		expected   []byte
+	}{
+		{name: "VITA 49.0", compliance: V49Spec0, expected: []byte{0, 0, 0, 1}},
+		{name: "VITA 49.1", compliance: V49Spec1, expected: []byte{0, 0, 0, 2}},
+		{name: "VITA 49A", compliance: V49SpecA, expected: []byte{0, 0, 0, 3}},
+		{name: "VITA 49.2", compliance: V49Spec2, expected: []byte{0, 0, 0, 4}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := tc.compliance
+			assert.Equal(t, v49SpecComplianceBytes, v.Size())
+			packed := v.Pack()
+			assert.Equal(t, tc.expected, packed)
+			v = 0
+			assert.NoError(t, v.UnmarshalBinary(packed))
+			assert.Equal(t, tc.compliance, v)
+		})
+	}
+}
+
+func TestBufferSize(t *testing.T) {
+	cases := []struct {
+		name     string
+		size     BufferSize
+		expected []byte
+	}{
+		{
+			name:     "default",
+			size:     BufferSize{},
+			expected: []byte{0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:     "size and level",
+			size:     BufferSize{BufferSize: 0x00100000, Level: 0x80},
+			expected: []byte{0, 0x10, 0, 0, 0, 0, 0x80, 0},
+		},
+		{
+			name:     "status",
+			size:     BufferSize{Overflow: true, AlmostFull: true, AlmostEmpty: true, Underflow: true},
+			expected: []byte{0, 0, 0, 0, 0, 0, 0, 0x0F},
+		},
+		{
+			name:     "almost empty",
+			size:     BufferSize{Level: 0x01, AlmostEmpty: true},
+			expected: []byte{0, 0, 0, 0, 0, 0, 0x01, 0x02},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := tc.size
+			assert.Equal(t, bufferSizeBytes, b.Size())
+			packed := b.Pack()
+			assert.Equal(t, tc.expected, packed)
+			b = BufferSize{}
+			assert.NoError(t, b.UnmarshalBinary(packed))
+			assert.Equal(t, tc.size, b)
+		})
+	}
+	b := BufferSize{}
+	assert.ErrorIs(t, b.UnmarshalBinary([]byte{0, 0, 0, 0}), ErrShortBuffer)
+	assert.ErrorIs(t, b.UnmarshalBinary([]byte{0, 0, 0, 0, 0, 0, 0, 0x10}), ErrReservedBits)
+}
+
+func TestCif1Fields(t *testing.T) {
+	c := Cifs{
+		Cif0: Cif0{IndicatorField0: IndicatorField0{If1Enable: true}},
+		Cif1: Cif1{
+			IndicatorField1: IndicatorField1{PhaseOffset: true, Range: true, AuxGain: true, HealthStatus: true, BufferSize: true},
+			PhaseOffset:     1.0,
+			Range:           2.0,
+			AuxGain:         Gain{Stage1: 1.0},
+			HealthStatus:    3,
+			BufferSize:      BufferSize{BufferSize: 4, Underflow: true},
+		},
+	}
+	packed := c.Pack()
+	assert.Equal(t, []byte{
+		0, 0, 0, 0x02,
+		0x81, 0x00, 0x40, 0x12,
+		0, 0, 0, 0x80,
+		0, 0, 0, 0x80,
+		0, 0, 0, 0x80,
+		0, 0, 0, 0x03,
+		0, 0, 0, 0x04, 0, 0, 0, 0x01,
+	}, packed)
+	unpacked := Cifs{}
+	assert.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, c, unpacked)
+}
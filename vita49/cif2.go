@@ -19,6 +19,208 @@
 
 package vita49
 
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	uuidFieldBytes   = uint32(16)
+	countryCodeBytes = uint32(4)
+)
+
+// Cif2
+// Holds the CIF2 indicator bits along with the value of each field. A value
+// is only packed when its indicator bit is set.
 type Cif2 struct {
 	IndicatorField2
+	Bind                    uint32
+	CitedSID                StreamID
+	SiblingSID              StreamID
+	ParentSID               StreamID
+	ChildSID                StreamID
+	CitedMessageID          uint32
+	ControlleeID            uint32
+	ControlleeUUID          Uuid
+	ControllerID            uint32
+	ControllerUUID          Uuid
+	InformationSource       uint32
+	TraceID                 uint32
+	CountryCode             CountryCode
+	Operator                uint32
+	PlatformClass           uint32
+	PlatformInstance        uint32
+	PlatformDisplay         uint32
+	EmsDeviceClass          uint32
+	EmsDeviceType           uint32
+	EmsDeviceInstance       uint32
+	ModulationClass         uint32
+	ModulationType          uint32
+	FunctionID              uint32
+	ModeID                  uint32
+	EventID                 uint32
+	FunctionPriorityID      uint32
+	CommunicationPriorityID uint32
+	RfFootprint             uint32
+	RfFootprintRange        uint32
+}
+
+// fields lists the CIF2 fields in packing order (bit 31 down to bit 0)
+func (c *Cif2) fields() []cifField {
+	f := &c.IndicatorField2
+	return []cifField{
+		{f.Bind, (*word32)(&c.Bind)},
+		{f.CitedSID, &c.CitedSID},
+		{f.SiblingSID, &c.SiblingSID},
+		{f.ParentSID, &c.ParentSID},
+		{f.ChildSID, &c.ChildSID},
+		{f.CitedMessageID, (*word32)(&c.CitedMessageID)},
+		{f.ControlleeID, (*word32)(&c.ControlleeID)},
+		{f.ControlleeUUID, &c.ControlleeUUID},
+		{f.ControllerID, (*word32)(&c.ControllerID)},
+		{f.ControllerUUID, &c.ControllerUUID},
+		{f.InformationSource, (*word32)(&c.InformationSource)},
+		{f.TraceID, (*word32)(&c.TraceID)},
+		{f.CountryCode, &c.CountryCode},
+		{f.Operator, (*word32)(&c.Operator)},
+		{f.PlatformClass, (*word32)(&c.PlatformClass)},
+		{f.PlatformInstance, (*word32)(&c.PlatformInstance)},
+		{f.PlatformDisplay, (*word32)(&c.PlatformDisplay)},
+		{f.EmsDeviceClass, (*word32)(&c.EmsDeviceClass)},
+		{f.EmsDeviceType, (*word32)(&c.EmsDeviceType)},
+		{f.EmsDeviceInstance, (*word32)(&c.EmsDeviceInstance)},
+		{f.ModulationClass, (*word32)(&c.ModulationClass)},
+		{f.ModulationType, (*word32)(&c.ModulationType)},
+		{f.FunctionID, (*word32)(&c.FunctionID)},
+		{f.ModeID, (*word32)(&c.ModeID)},
+		{f.EventID, (*word32)(&c.EventID)},
+		{f.FunctionPriorityID, (*word32)(&c.FunctionPriorityID)},
+		{f.CommunicationPriorityID, (*word32)(&c.CommunicationPriorityID)},
+		{f.RfFootprint, (*word32)(&c.RfFootprint)},
+		{f.RfFootprintRange, (*word32)(&c.RfFootprintRange)},
+	}
+}
+
+// Uuid
+// A 128-bit universally unique identifier, packed as four words in network
+// byte order. The Controllee and Controller UUID fields use this format.
+type Uuid [16]byte
+
+// ParseUuid parses the canonical text form of a UUID, such as
+// "123e4567-e89b-12d3-a456-426614174000". Upper and lower case hex digits
+// are accepted.
+func ParseUuid(s string) (Uuid, error) {
+	var u Uuid
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, &ParseError{Type: "Uuid", Text: s}
+	}
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return Uuid{}, &ParseError{Type: "Uuid", Text: s}
+	}
+	return u, nil
+}
+
+// String returns the canonical lower case text form of the UUID
+func (u Uuid) String() string {
+	s := hex.EncodeToString(u[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+func (u *Uuid) Size() uint32 {
+	return uuidFieldBytes
+}
+
+func (u *Uuid) Pack() []byte {
+	buf := make([]byte, u.Size())
+	u.PackInto(buf)
+	return buf
+}
+
+func (u *Uuid) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Uuid", buf, u.Size()); err != nil {
+		return 0, err
+	}
+	copy(buf, u[:])
+	return int(u.Size()), nil
+}
+
+func (u *Uuid) Unpack(buf []byte) {
+	copy(u[:], buf)
+}
+
+func (u *Uuid) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Uuid", buf, u.Size()); err != nil {
+		return err
+	}
+	u.Unpack(buf)
+	return nil
+}
+
+// CountryCode
+// An ISO 3166-1 alpha-2 country code, packed as two ASCII characters in the
+// lower 16 bits of the word. The upper 16 bits are reserved. The zero value
+// means no country.
+type CountryCode [2]byte
+
+// ParseCountryCode parses a two-letter ISO 3166-1 alpha-2 code such as "US".
+// Lower case letters are accepted and stored in upper case.
+func ParseCountryCode(s string) (CountryCode, error) {
+	var c CountryCode
+	if len(s) != 2 {
+		return c, &ParseError{Type: "CountryCode", Text: s}
+	}
+	upper := strings.ToUpper(s)
+	for i := range c {
+		if upper[i] < 'A' || upper[i] > 'Z' {
+			return CountryCode{}, &ParseError{Type: "CountryCode", Text: s}
+		}
+		c[i] = upper[i]
+	}
+	return c, nil
+}
+
+// String returns the two-letter code, or "" for the zero value
+func (c CountryCode) String() string {
+	if c == (CountryCode{}) {
+		return ""
+	}
+	return string(c[:])
+}
+
+func (c *CountryCode) Size() uint32 {
+	return countryCodeBytes
+}
+
+func (c *CountryCode) Pack() []byte {
+	buf := make([]byte, c.Size())
+	c.PackInto(buf)
+	return buf
+}
+
+func (c *CountryCode) PackInto(buf []byte) (int, error) {
+	if err := checkSize("CountryCode", buf, c.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint16(buf[0:], 0) // reserved bits
+	buf[2] = c[0]
+	buf[3] = c[1]
+	return int(c.Size()), nil
+}
+
+func (c *CountryCode) Unpack(buf []byte) {
+	c[0] = buf[2]
+	c[1] = buf[3]
+}
+
+func (c *CountryCode) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("CountryCode", buf, c.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("CountryCode", buf, 0, 0xFFFF0000); err != nil {
+		return err
+	}
+	c.Unpack(buf)
+	return nil
 }
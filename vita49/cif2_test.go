@@ -29,3 +29,93 @@ func TestCif2Size(t *testing.T) {
 	c := Cif2{}
 	assert.Equal(t, uint32(4), c.Size())
 }
+
+func TestUuid(t *testing.T) {
+	u, err := ParseUuid("123E4567-e89b-12d3-a456-426614174000")
+	assert.NoError(t, err)
+	expected := []byte{0x12, 0x3E, 0x45, 0x67, 0xE8, 0x9B, 0x12, 0xD3, 0xA4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	assert.Equal(t, Uuid(expected), u)
+	assert.Equal(t, "123e4567-e89b-12d3-a456-426614174000", u.String())
+	assert.Equal(t, uuidFieldBytes, u.Size())
+	// Pack
+	packed := u.Pack()
+	assert.Equal(t, expected, packed)
+	// Unpack
+	unpacked := Uuid{}
+	assert.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, u, unpacked)
+	assert.ErrorIs(t, unpacked.UnmarshalBinary(packed[:15]), ErrShortBuffer)
+}
+
+func TestParseUuidErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"123e4567e89b12d3a456426614174000",
+		"123e4567-e89b-12d3-a456-42661417400",
+		"123e4567_e89b-12d3-a456-426614174000",
+		"123e4567-e89b-12d3-a456-42661417400g",
+	} {
+		_, err := ParseUuid(s)
+		assert.ErrorIs(t, err, ErrParse, s)
+	}
+}
+
+func TestCountryCode(t *testing.T) {
+	cases := []struct {
+		text     string
+		code     CountryCode
+		expected []byte
+	}{
+		{text: "US", code: CountryCode{'U', 'S'}, expected: []byte{0, 0, 'U', 'S'}},
+		{text: "de", code: CountryCode{'D', 'E'}, expected: []byte{0, 0, 'D', 'E'}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			c, err := ParseCountryCode(tc.text)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.code, c)
+			assert.Equal(t, countryCodeBytes, c.Size())
+			// Pack
+			packed := c.Pack()
+			assert.Equal(t, tc.expected, packed)
+			// Unpack
+			unpacked := CountryCode{}
+			assert.NoError(t, unpacked.UnmarshalBinary(packed))
+			assert.Equal(t, tc.code, unpacked)
+		})
+	}
+	assert.Equal(t, "", CountryCode{}.String())
+	assert.Equal(t, "GB", CountryCode{'G', 'B'}.String())
+
+	c := CountryCode{}
+	assert.ErrorIs(t, c.UnmarshalBinary([]byte{0, 1, 'U', 'S'}), ErrReservedBits)
+	for _, s := range []string{"", "U", "USA", "U1"} {
+		_, err := ParseCountryCode(s)
+		assert.ErrorIs(t, err, ErrParse, s)
+	}
+}
+
+func TestCif2Fields(t *testing.T) {
+	c := Cifs{
+		Cif0: Cif0{IndicatorField0: IndicatorField0{If2Enable: true}},
+		Cif2: Cif2{
+			IndicatorField2:  IndicatorField2{ParentSID: true, ControllerUUID: true, CountryCode: true, RfFootprintRange: true},
+			ParentSID:        0x11223344,
+			ControllerUUID:   Uuid{15: 0x01},
+			CountryCode:      CountryCode{'F', 'R'},
+			RfFootprintRange: 7,
+		},
+	}
+	packed := c.Pack()
+	assert.Equal(t, []byte{
+		0, 0, 0, 0x04,
+		0x10, 0x48, 0, 0x08,
+		0x11, 0x22, 0x33, 0x44,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x01,
+		0, 0, 'F', 'R',
+		0, 0, 0, 0x07,
+	}, packed)
+	unpacked := Cifs{}
+	assert.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, c, unpacked)
+}
@@ -21,8 +21,75 @@ package vita49
 
 import (
 	"encoding/binary"
+	"math"
 )
 
+// Cif3
+// Holds the CIF3 indicator bits along with the value of each field. A value
+// is only packed when its indicator bit is set. Age and ShelfLife take the
+// format of the packet timestamp, so their Tsi and Tsf must match the header
+// of the packet carrying them; packets set them from the header when
+// unpacking. A Cifs unpacked on its own keeps the Tsi and Tsf already set.
+type Cif3 struct {
+	IndicatorField3
+	TimestampDetails     TimestampDetails
+	TimestampSkew        Femtoseconds
+	RiseTime             Femtoseconds
+	FallTime             Femtoseconds
+	OffsetTime           Femtoseconds
+	PulseWidth           Femtoseconds
+	Period               Femtoseconds
+	Duration             Femtoseconds
+	Dwell                Femtoseconds
+	Jitter               Femtoseconds
+	Age                  Timestamp
+	ShelfLife            Timestamp
+	AirTemperature       Temperature
+	SeaGroundTemperature Temperature
+	Humidity             Humidity
+	BarometricPressure   BarometricPressure
+	SeaSwellState        SeaSwellState
+	TroposphericState    uint32 // raw word, contents not defined by VITA 49.2
+	NetworkID            uint32 // raw word, contents not defined by VITA 49.2
+}
+
+// fields lists the CIF3 fields in packing order (bit 31 down to bit 0)
+func (c *Cif3) fields() []cifField {
+	f := &c.IndicatorField3
+	return []cifField{
+		{f.TimestampDetails, &c.TimestampDetails},
+		{f.TimestampSkew, &c.TimestampSkew},
+		{f.RiseTime, &c.RiseTime},
+		{f.FallTime, &c.FallTime},
+		{f.OffsetTime, &c.OffsetTime},
+		{f.PulseWidth, &c.PulseWidth},
+		{f.Period, &c.Period},
+		{f.Duration, &c.Duration},
+		{f.Dwell, &c.Dwell},
+		{f.Jitter, &c.Jitter},
+		{f.Age, &c.Age},
+		{f.ShelfLife, &c.ShelfLife},
+		{f.AirTemperature, &c.AirTemperature},
+		{f.SeaGroundTemperature, &c.SeaGroundTemperature},
+		{f.Humidity, &c.Humidity},
+		{f.BarometricPressure, &c.BarometricPressure},
+		{f.SeaSwellState, &c.SeaSwellState},
+		{f.TroposphericState, (*word32)(&c.TroposphericState)},
+		{f.NetworkID, (*word32)(&c.NetworkID)},
+	}
+}
+
+// setTimestampFormat gives the enabled Age and ShelfLife fields the Tsi and
+// Tsf of the packet timestamp t
+func (c *Cif3) setTimestampFormat(t Timestamp) {
+	if c.IndicatorField3.Age {
+		c.Age.Tsi, c.Age.Tsf = t.Tsi, t.Tsf
+	}
+	if c.IndicatorField3.ShelfLife {
+		c.ShelfLife.Tsi, c.ShelfLife.Tsf = t.Tsi, t.Tsf
+	}
+}
+
 type TimestampDetails struct {
 	UserDefined           uint8
 	Global                bool
@@ -35,12 +102,21 @@ type TimestampDetails struct {
 	TimestampEpoch        uint32
 }
 
-func (t TimestampDetails) Size() uint32 {
+func (t *TimestampDetails) Size() uint32 {
 	return 8
 }
 
 func (t *TimestampDetails) Pack() []byte {
 	buf := make([]byte, t.Size())
+	t.PackInto(buf)
+	return buf
+}
+
+func (t *TimestampDetails) PackInto(buf []byte) (int, error) {
+	if err := checkSize("TimestampDetails", buf, t.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:t.Size()]
 	word1 := uint32(0)
 	word1 |= uint32(t.UserDefined) << 24
 	if t.Global {
@@ -56,7 +132,7 @@ func (t *TimestampDetails) Pack() []byte {
 	}
 	binary.BigEndian.PutUint32(buf, word1)
 	binary.BigEndian.PutUint32(buf[4:], t.TimestampEpoch)
-	return buf
+	return len(buf), nil
 }
 
 func (t *TimestampDetails) Unpack(buf []byte) {
@@ -72,24 +148,44 @@ func (t *TimestampDetails) Unpack(buf []byte) {
 	t.TimestampEpoch = binary.BigEndian.Uint32(buf[4:])
 }
 
+func (t *TimestampDetails) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("TimestampDetails", buf, t.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("TimestampDetails", buf, 0, 0x00F80000); err != nil {
+		return err
+	}
+	t.Unpack(buf)
+	return nil
+}
+
 type SeaSwellState struct {
 	UserDefined uint8
 	SwellState  uint8
 	SeaState    uint8
 }
 
-func (s SeaSwellState) Size() uint32 {
+func (s *SeaSwellState) Size() uint32 {
 	return 4
 }
 
 func (s *SeaSwellState) Pack() []byte {
 	buf := make([]byte, s.Size())
+	s.PackInto(buf)
+	return buf
+}
+
+func (s *SeaSwellState) PackInto(buf []byte) (int, error) {
+	if err := checkSize("SeaSwellState", buf, s.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:s.Size()]
 	word := uint32(0)
 	word |= uint32(s.UserDefined) << 10
 	word |= uint32(s.SwellState) << 5
 	word |= uint32(s.SeaState)
 	binary.BigEndian.PutUint32(buf, word)
-	return buf
+	return len(buf), nil
 }
 
 func (s *SeaSwellState) Unpack(buf []byte) {
@@ -98,3 +194,90 @@ func (s *SeaSwellState) Unpack(buf []byte) {
 	s.SwellState = uint8(word>>5) & 31
 	s.SeaState = uint8(word) & 31
 }
+
+func (s *SeaSwellState) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("SeaSwellState", buf, s.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("SeaSwellState", buf, 0, 0xFFFF0000); err != nil {
+		return err
+	}
+	s.Unpack(buf)
+	return nil
+}
+
+// Humidity
+// The relative humidity in percent, packed in the lower 16 bits of the word
+// as an unsigned fixed-point number with the radix point to the right of
+// bit 7. The upper 16 bits are reserved. Negative values pack as zero.
+type Humidity float64
+
+func (h *Humidity) Size() uint32 {
+	return 4
+}
+
+func (h *Humidity) Pack() []byte {
+	buf := make([]byte, h.Size())
+	h.PackInto(buf)
+	return buf
+}
+
+func (h *Humidity) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Humidity", buf, h.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint16(buf[0:], 0) // reserved bits
+	binary.BigEndian.PutUint16(buf[2:], uint16(math.Round(math.Max(float64(*h), 0)*128)))
+	return int(h.Size()), nil
+}
+
+func (h *Humidity) Unpack(buf []byte) {
+	*h = Humidity(float64(binary.BigEndian.Uint16(buf[2:])) / 128)
+}
+
+func (h *Humidity) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Humidity", buf, h.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("Humidity", buf, 0, 0xFFFF0000); err != nil {
+		return err
+	}
+	h.Unpack(buf)
+	return nil
+}
+
+// BarometricPressure
+// The barometric pressure in millibars, packed as a 32-bit unsigned
+// fixed-point number with the radix point to the right of bit 12. Negative
+// values pack as zero.
+type BarometricPressure float64
+
+func (b *BarometricPressure) Size() uint32 {
+	return 4
+}
+
+func (b *BarometricPressure) Pack() []byte {
+	buf := make([]byte, b.Size())
+	b.PackInto(buf)
+	return buf
+}
+
+func (b *BarometricPressure) PackInto(buf []byte) (int, error) {
+	if err := checkSize("BarometricPressure", buf, b.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint32(buf, uint32(math.Round(math.Max(float64(*b), 0)*4096)))
+	return int(b.Size()), nil
+}
+
+func (b *BarometricPressure) Unpack(buf []byte) {
+	*b = BarometricPressure(float64(binary.BigEndian.Uint32(buf)) / 4096)
+}
+
+func (b *BarometricPressure) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("BarometricPressure", buf, b.Size()); err != nil {
+		return err
+	}
+	b.Unpack(buf)
+	return nil
+}
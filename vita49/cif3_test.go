@@ -21,6 +21,7 @@ package vita49
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -234,3 +235,154 @@ func TestSeaSwellState(t *testing.T) {
 		})
 	}
 }
+
+func TestFemtoseconds(t *testing.T) {
+	f := FemtosecondsFromDuration(time.Millisecond)
+	assert.Equal(t, Femtoseconds(1e12), f)
+	assert.Equal(t, time.Millisecond, f.Duration())
+	packed := f.Pack()
+	assert.Equal(t, []byte{0, 0, 0, 0xE8, 0xD4, 0xA5, 0x10, 0}, packed)
+	f = 0
+	assert.NoError(t, f.UnmarshalBinary(packed))
+	assert.Equal(t, Femtoseconds(1e12), f)
+
+	// TimestampAdjustment is the same type
+	var a TimestampAdjustment = f
+	assert.Equal(t, f, a)
+}
+
+func TestHumidity(t *testing.T) {
+	cases := []struct {
+		name     string
+		humidity Humidity
+		expected []byte
+		unpacked Humidity
+	}{
+		{name: "1 percent", humidity: 1.0, expected: []byte{0, 0, 0, 0x80}, unpacked: 1.0},
+		{name: "100 percent", humidity: 100.0, expected: []byte{0, 0, 0x32, 0}, unpacked: 100.0},
+		{name: "resolution", humidity: 0.0078125, expected: []byte{0, 0, 0, 0x01}, unpacked: 0.0078125},
+		{name: "negative", humidity: -5.0, expected: []byte{0, 0, 0, 0}, unpacked: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := tc.humidity
+			assert.Equal(t, uint32(4), h.Size())
+			packed := h.Pack()
+			assert.Equal(t, tc.expected, packed)
+			assert.NoError(t, h.UnmarshalBinary(packed))
+			assert.Equal(t, tc.unpacked, h)
+		})
+	}
+	h := Humidity(0)
+	assert.ErrorIs(t, h.UnmarshalBinary([]byte{0, 0x01, 0, 0}), ErrReservedBits)
+}
+
+func TestBarometricPressure(t *testing.T) {
+	cases := []struct {
+		name     string
+		pressure BarometricPressure
+		expected []byte
+	}{
+		{name: "1 millibar", pressure: 1.0, expected: []byte{0, 0, 0x10, 0}},
+		{name: "standard atmosphere", pressure: 1013.25, expected: []byte{0, 0x3F, 0x54, 0}},
+		{name: "resolution", pressure: 1.0 / 4096, expected: []byte{0, 0, 0, 0x01}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := tc.pressure
+			assert.Equal(t, uint32(4), b.Size())
+			packed := b.Pack()
+			assert.Equal(t, tc.expected, packed)
+			b = 0
+			assert.NoError(t, b.UnmarshalBinary(packed))
+			assert.Equal(t, tc.pressure, b)
+		})
+	}
+	b := BarometricPressure(0)
+	assert.ErrorIs(t, b.UnmarshalBinary([]byte{0, 0}), ErrShortBuffer)
+}
+
+func TestCif3Fields(t *testing.T) {
+	c := Cifs{
+		Cif0: Cif0{IndicatorField0: IndicatorField0{If3Enable: true}},
+		Cif3: Cif3{
+			IndicatorField3:    IndicatorField3{PulseWidth: true, Jitter: true, AirTemperature: true, Humidity: true, BarometricPressure: true},
+			PulseWidth:         FemtosecondsFromDuration(time.Microsecond),
+			Jitter:             -1,
+			AirTemperature:     21.5,
+			Humidity:           45.5,
+			BarometricPressure: 1000,
+		},
+	}
+	packed := c.Pack()
+	assert.Equal(t, []byte{
+		0, 0, 0, 0x08,
+		0x01, 0x10, 0, 0xB0,
+		0, 0, 0, 0, 0x3B, 0x9A, 0xCA, 0,
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+		0, 0, 0x05, 0x60,
+		0, 0, 0x16, 0xC0,
+		0, 0x3E, 0x80, 0,
+	}, packed)
+	unpacked := Cifs{}
+	assert.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, c, unpacked)
+	assert.Equal(t, time.Microsecond, unpacked.Cif3.PulseWidth.Duration())
+}
+
+func TestCif3AgeShelfLife(t *testing.T) {
+	// Age and ShelfLife take the format of the packet timestamp
+	cases := []struct {
+		name string
+		tsi  Tsi
+		tsf  Tsf
+		size int
+	}{
+		{"No timestamp", NoneTsi, NoneTsf, 0},
+		{"Integer only", Utc, NoneTsf, 4},
+		{"Fractional only", NoneTsi, FreeRunning, 8},
+		{"Integer and fractional", Gps, Picoseconds, 12},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			timestamp := func(integer uint32, fractional uint64) Timestamp {
+				ts := Timestamp{Tsi: tc.tsi, Tsf: tc.tsf}
+				if tc.tsi != NoneTsi {
+					ts.Integer = integer
+				}
+				if tc.tsf != NoneTsf {
+					ts.Fractional = fractional
+				}
+				return ts
+			}
+			p := ContextPacket{
+				Header: ContextHeader{Header: Header{Tsi: tc.tsi, Tsf: tc.tsf}},
+				Cifs: Cifs{
+					Cif0: Cif0{IndicatorField0: IndicatorField0{If3Enable: true}},
+					Cif3: Cif3{
+						IndicatorField3: IndicatorField3{Age: true, ShelfLife: true},
+						Age:             timestamp(5, 6),
+						ShelfLife:       timestamp(7, 8),
+					},
+				},
+			}
+			packed := p.Pack()
+			assert.Len(t, packed, 16+3*tc.size)
+			fields := packed[16+tc.size:]
+			assert.Equal(t, p.Cif3.Age.Pack(), fields[:tc.size])
+			assert.Equal(t, p.Cif3.ShelfLife.Pack(), fields[tc.size:])
+
+			unpacked := ContextPacket{}
+			assert.NoError(t, unpacked.UnmarshalBinary(packed))
+			assert.Equal(t, p, unpacked)
+			unpacked = ContextPacket{}
+			unpacked.Unpack(packed)
+			assert.Equal(t, p, unpacked)
+
+			// A format that differs from the header is not packed
+			p.Cif3.ShelfLife.Tsf = SampleCount
+			_, err := p.PackInto(make([]byte, p.Size()))
+			assert.ErrorIs(t, err, ErrTimestamp)
+		})
+	}
+}
@@ -23,21 +23,245 @@ import (
 	"encoding/binary"
 )
 
+// Attribute
+// Identifies a CIF7 attribute by the position of its bit in the CIF7
+// indicator word.
+type Attribute uint8
+
+const (
+	CurrentValueAttribute Attribute = 31 - iota
+	AverageValueAttribute
+	MedianValueAttribute
+	StandardDeviationAttribute
+	MaxValueAttribute
+	MinValueAttribute
+	PrecisionAttribute
+	AccuracyAttribute
+	FirstDerivativeAttribute
+	SecondDerivativeAttribute
+	ThirdDerivativeAttribute
+	ProbabilityAttribute
+	BeliefAttribute
+)
+
+// Cif7
+// Holds the CIF7 indicator bits. Each bit enables an attribute, and when
+// CIF7 is enabled every enabled CIF0 to CIF3 field is packed once per
+// enabled attribute. The attribute values are held by Cifs.
 type Cif7 struct {
 	IndicatorField7
-	CurrentValue      uint32
-	AverageValue      uint32
-	MedianValue       uint32
-	StandardDeviation uint32
-	MaxValue          uint32
-	MinValue          uint32
-	Precision         uint32
-	Accuracy          uint32
-	Velocity          uint32
-	Acceleration      uint32
-	ThirdDerivative   uint32
-	Probability       Probability
-	Belief            Belief
+}
+
+func (c *Cif7) bitmap() uint32 {
+	var buf [4]byte
+	c.IndicatorField7.PackInto(buf[:])
+	return binary.BigEndian.Uint32(buf[:])
+}
+
+func (c *Cif7) enabled(a Attribute) bool {
+	return indicatorFieldBool(c.bitmap(), uint32(a))
+}
+
+func (c *Cif7) setAttribute(a Attribute) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], c.bitmap()|uint32(1)<<a)
+	c.IndicatorField7.Unpack(buf[:])
+}
+
+// attributes lists the enabled attributes in packing order (bit 31 down to
+// bit 19)
+func (c *Cif7) attributes() []Attribute {
+	bitmap := c.bitmap()
+	var attrs []Attribute
+	for a := CurrentValueAttribute; a >= BeliefAttribute; a-- {
+		if indicatorFieldBool(bitmap, uint32(a)) {
+			attrs = append(attrs, a)
+		}
+	}
+	return attrs
+}
+
+// Attribute returns the Cifs holding attribute a of each enabled field, or
+// nil when the attribute is not enabled. Its fields may be set, and are
+// packed in place of the fields of the same name in c. The current value of
+// each field is held by c itself, which is returned when CIF7 is disabled.
+// Probability and belief are held in Probabilities and Beliefs, so Attribute
+// returns nil for them.
+func (c *Cifs) Attribute(a Attribute) *Cifs {
+	switch {
+	case !c.Cif0.If7Enable:
+		if a == CurrentValueAttribute {
+			return c
+		}
+		return nil
+	case !c.Cif7.enabled(a), a <= ProbabilityAttribute:
+		return nil
+	case a == CurrentValueAttribute:
+		return c
+	}
+	return c.attributeValues(a, true)
+}
+
+// EnableAttribute enables attribute a and returns what Attribute returns.
+// When this enables CIF7 the current value is enabled too, so the field
+// values already held keep being packed.
+func (c *Cifs) EnableAttribute(a Attribute) *Cifs {
+	if !c.Cif0.If7Enable {
+		c.Cif0.If7Enable = true
+		c.Cif7.IndicatorField7 = IndicatorField7{CurrentValue: true}
+	}
+	c.Cif7.setAttribute(a)
+	switch a {
+	case ProbabilityAttribute:
+		if c.Probabilities == nil {
+			c.Probabilities = map[FieldID]Probability{}
+		}
+	case BeliefAttribute:
+		if c.Beliefs == nil {
+			c.Beliefs = map[FieldID]Belief{}
+		}
+	}
+	return c.Attribute(a)
+}
+
+// attributeValues returns the Cifs holding attribute a, with the indicator
+// bits and timestamp formats of c so that its fields line up with those of
+// c. With alloc the Cifs returned is the one held in Attributes, created
+// when absent, so that it may be written; otherwise it is a copy and the
+// values held are left untouched.
+func (c *Cifs) attributeValues(a Attribute, alloc bool) *Cifs {
+	v := c.Attributes[a]
+	switch {
+	case alloc && v == nil:
+		v = &Cifs{}
+		if c.Attributes == nil {
+			c.Attributes = map[Attribute]*Cifs{}
+		}
+		c.Attributes[a] = v
+	case !alloc && v == nil:
+		v = &Cifs{}
+	case !alloc:
+		copied := *v
+		v = &copied
+	}
+	v.Cif0.IndicatorField0 = c.Cif0.IndicatorField0
+	v.Cif0.If7Enable = false
+	v.Cif1.IndicatorField1 = c.Cif1.IndicatorField1
+	v.Cif2.IndicatorField2 = c.Cif2.IndicatorField2
+	v.Cif3.IndicatorField3 = c.Cif3.IndicatorField3
+	v.Cif3.Age.Tsi, v.Cif3.Age.Tsf = c.Cif3.Age.Tsi, c.Cif3.Age.Tsf
+	v.Cif3.ShelfLife.Tsi, v.Cif3.ShelfLife.Tsf = c.Cif3.ShelfLife.Tsi, c.Cif3.ShelfLife.Tsf
+	return v
+}
+
+// packedFields lists the enabled fields in packing order. With CIF7 enabled
+// each field is repeated once per enabled attribute, in attribute order,
+// before the next field. Probability and belief take a single word whatever
+// the size of the field. When unpacking, alloc replaces the attribute values
+// and the returned function, called once the fields are unpacked, stores the
+// probability and belief of each field. Without alloc the function is nil.
+func (c *Cifs) packedFields(alloc bool) ([]cifField, func()) {
+	fields := c.fields()
+	if alloc {
+		c.Attributes, c.Probabilities, c.Beliefs = nil, nil, nil
+	}
+	if !c.Cif0.If7Enable {
+		if alloc {
+			return fields, func() {}
+		}
+		return fields, nil
+	}
+	attrs := c.Cif7.attributes()
+	var values [CurrentValueAttribute + 1][]cifField
+	var ids []FieldID
+	for _, a := range attrs {
+		switch {
+		case a <= ProbabilityAttribute:
+			if ids == nil {
+				ids = c.fieldIDs()
+			}
+		case a != CurrentValueAttribute:
+			values[a] = c.attributeValues(a, alloc).fields()
+		}
+	}
+	packed := make([]cifField, 0, len(attrs)*len(fields))
+	var stores []func()
+	n := 0
+	for i, f := range fields {
+		if !f.enabled {
+			continue
+		}
+		var id FieldID
+		if ids != nil {
+			id = ids[n]
+		}
+		n++
+		for _, a := range attrs {
+			switch a {
+			case CurrentValueAttribute:
+				packed = append(packed, f)
+			case ProbabilityAttribute:
+				p := c.Probabilities[id]
+				packed = append(packed, cifField{true, &p})
+				if alloc {
+					stores = append(stores, func() {
+						if c.Probabilities == nil {
+							c.Probabilities = map[FieldID]Probability{}
+						}
+						c.Probabilities[id] = p
+					})
+				}
+			case BeliefAttribute:
+				b := c.Beliefs[id]
+				packed = append(packed, cifField{true, &b})
+				if alloc {
+					stores = append(stores, func() {
+						if c.Beliefs == nil {
+							c.Beliefs = map[FieldID]Belief{}
+						}
+						c.Beliefs[id] = b
+					})
+				}
+			default:
+				packed = append(packed, values[a][i])
+			}
+		}
+	}
+	if !alloc {
+		return packed, nil
+	}
+	return packed, func() {
+		for _, store := range stores {
+			store()
+		}
+	}
+}
+
+// packedFieldsSize returns the size of the fields packedFields lists without
+// building the list
+func (c *Cifs) packedFieldsSize() uint32 {
+	fields := c.fields()
+	if !c.Cif0.If7Enable {
+		return cifFieldsSize(fields)
+	}
+	var size uint32
+	bitmap := c.Cif7.bitmap()
+	for a := CurrentValueAttribute; a >= BeliefAttribute; a-- {
+		switch {
+		case !indicatorFieldBool(bitmap, uint32(a)):
+		case a == CurrentValueAttribute:
+			size += cifFieldsSize(fields)
+		case a <= ProbabilityAttribute:
+			for _, f := range fields {
+				if f.enabled {
+					size += 4
+				}
+			}
+		default:
+			size += cifFieldsSize(c.attributeValues(a, false).fields())
+		}
+	}
+	return size
 }
 
 // Represents the 2nd order probability that the standard probability
@@ -46,18 +270,27 @@ type Belief struct {
 	BeliefPercent uint32
 }
 
-func (b Belief) Size() uint32 {
+func (b *Belief) Size() uint32 {
 	return 4
 }
 
 func (b *Belief) Pack() []byte {
+	buf := make([]byte, b.Size())
+	b.PackInto(buf)
+	return buf
+}
+
+func (b *Belief) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Belief", buf, b.Size()); err != nil {
+		return 0, err
+	}
 	// 1 word
-	retval := make([]byte, b.Size())
+	retval := buf[:b.Size()]
 
 	word1 := uint32(0)
 	word1 |= uint32(b.BeliefPercent & 0xFF) // Bits 0-7
 	binary.BigEndian.PutUint32(retval[0:], word1)
-	return retval
+	return len(retval), nil
 }
 
 func (b *Belief) Unpack(buf []byte) {
@@ -65,6 +298,17 @@ func (b *Belief) Unpack(buf []byte) {
 	b.BeliefPercent = uint32(word1 & 0xFF)
 }
 
+func (b *Belief) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Belief", buf, b.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("Belief", buf, 0, 0xFFFFFF00); err != nil {
+		return err
+	}
+	b.Unpack(buf)
+	return nil
+}
+
 // Represents the probability that the the selected field in the same Packet
 // Structure Level and array index (if appropriate) is accurate or true.
 type Probability struct {
@@ -72,19 +316,28 @@ type Probability struct {
 	ProbabilityPercent  uint32
 }
 
-func (p Probability) Size() uint32 {
+func (p *Probability) Size() uint32 {
 	return 4
 }
 
 func (p *Probability) Pack() []byte {
+	buf := make([]byte, p.Size())
+	p.PackInto(buf)
+	return buf
+}
+
+func (p *Probability) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Probability", buf, p.Size()); err != nil {
+		return 0, err
+	}
 	// 1 word
-	retval := make([]byte, p.Size())
+	retval := buf[:p.Size()]
 
 	word1 := uint32(0)
 	word1 |= uint32(p.ProbabilityFunction&0xFF) << 8 // Bits 8-15
 	word1 |= uint32(p.ProbabilityPercent & 0xFF)     // Bits 0-7
 	binary.BigEndian.PutUint32(retval[0:], word1)
-	return retval
+	return len(retval), nil
 }
 
 func (p *Probability) Unpack(buf []byte) {
@@ -92,3 +345,14 @@ func (p *Probability) Unpack(buf []byte) {
 	p.ProbabilityFunction = uint32(word1>>8) & 0xFF
 	p.ProbabilityPercent = uint32(word1 & 0xFF)
 }
+
+func (p *Probability) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Probability", buf, p.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("Probability", buf, 0, 0xFFFF0000); err != nil {
+		return err
+	}
+	p.Unpack(buf)
+	return nil
+}
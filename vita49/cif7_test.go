@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBeliefBytes(t *testing.T) {
@@ -149,3 +150,98 @@ func TestProbability(t *testing.T) {
 		})
 	}
 }
+
+func TestCif7Attributes(t *testing.T) {
+	c := Cif7{IndicatorField7: IndicatorField7{Belief: true, CurrentValue: true, SecondDerivative: true}}
+	assert.Equal(t, []Attribute{CurrentValueAttribute, SecondDerivativeAttribute, BeliefAttribute}, c.attributes())
+	c.setAttribute(MaxValueAttribute)
+	assert.True(t, c.MaxValue)
+	assert.Equal(t, []Attribute{CurrentValueAttribute, MaxValueAttribute, SecondDerivativeAttribute, BeliefAttribute}, c.attributes())
+}
+
+func TestCifsAttributes(t *testing.T) {
+	c := Cifs{}
+	c.Cif0.IndicatorField0.Bandwidth = true
+	c.Cif0.IndicatorField0.Temperature = true
+	c.Cif0.Bandwidth = 20e6
+	c.Cif0.Temperature = 25.5
+	assert.Same(t, &c, c.Attribute(CurrentValueAttribute))
+	assert.Nil(t, c.Attribute(MaxValueAttribute))
+
+	maxValue := c.EnableAttribute(MaxValueAttribute)
+	require.NotNil(t, maxValue)
+	maxValue.Cif0.Bandwidth = 25e6
+	maxValue.Cif0.Temperature = 40
+	minValue := c.EnableAttribute(MinValueAttribute)
+	minValue.Cif0.Bandwidth = 15e6
+	minValue.Cif0.Temperature = -10
+	assert.Nil(t, c.EnableAttribute(ProbabilityAttribute))
+	c.Probabilities[FieldID{Cif: 0, Bit: 29}] = Probability{ProbabilityFunction: 1, ProbabilityPercent: 200}
+	c.Probabilities[FieldID{Cif: 0, Bit: 18}] = Probability{ProbabilityPercent: 128}
+	assert.True(t, c.Cif0.If7Enable)
+	assert.True(t, c.Cif7.CurrentValue)
+	assert.Nil(t, c.Attribute(AverageValueAttribute))
+
+	// Each field is followed by its maximum, minimum and probability
+	expected := []byte{
+		0x20, 0x04, 0, 0x80,
+		0x8C, 0x10, 0, 0,
+		0, 0, 0x13, 0x12, 0xD0, 0, 0, 0,
+		0, 0, 0x17, 0xD7, 0x84, 0, 0, 0,
+		0, 0, 0x0E, 0x4E, 0x1C, 0, 0, 0,
+		0, 0, 0x01, 0xC8,
+		0, 0, 0x06, 0x60,
+		0, 0, 0x0A, 0x00,
+		0, 0, 0xFD, 0x80,
+		0, 0, 0, 0x80,
+	}
+	packed := c.Pack()
+	assert.Equal(t, expected, packed)
+	assert.Equal(t, c.Size(), uint32(len(packed)))
+
+	unpacked := Cifs{}
+	unpacked.Unpack(packed)
+	assert.Equal(t, c, unpacked)
+	assert.Equal(t, Temperature(40), unpacked.Attribute(MaxValueAttribute).Cif0.Temperature)
+	assert.Equal(t, Frequency(15e6), unpacked.Attribute(MinValueAttribute).Cif0.Bandwidth)
+
+	unmarshaled := Cifs{}
+	require.NoError(t, unmarshaled.UnmarshalBinary(packed))
+	assert.Equal(t, c, unmarshaled)
+	assert.ErrorIs(t, unmarshaled.UnmarshalBinary(packed[:len(packed)-4]), ErrShortBuffer)
+
+	// Without the current value only the other attributes are packed
+	c.Cif7.CurrentValue = false
+	assert.Equal(t, uint32(len(expected)-12), c.Size())
+	assert.Nil(t, c.Attribute(CurrentValueAttribute))
+
+	// Disabling CIF7 drops the attributes when unpacked
+	c.Cif0.If7Enable = false
+	unpacked.Unpack(c.Pack())
+	assert.Nil(t, unpacked.Attributes)
+	assert.Nil(t, unpacked.Probabilities)
+	assert.Equal(t, Temperature(25.5), unpacked.Cif0.Temperature)
+}
+
+func TestCifsAttributesSize(t *testing.T) {
+	c := Cifs{}
+	c.Cif0.IndicatorField0.Bandwidth = true
+	c.Cif0.IndicatorField0.GpsAscii = true
+	c.Cif0.GpsAscii = GpsAscii{NumberOfWords: 1, AsciiSentences: []byte("$GP ")}
+	plain := testing.AllocsPerRun(10, func() { c.Size() })
+
+	c.EnableAttribute(ProbabilityAttribute)
+	c.EnableAttribute(BeliefAttribute)
+	maxValue := c.EnableAttribute(MaxValueAttribute)
+	maxValue.Cif0.GpsAscii = GpsAscii{NumberOfWords: 2, AsciiSentences: []byte("$GPGGA  ")}
+	assert.Equal(t, c.Size(), uint32(len(c.Pack())))
+
+	// Size walks the fields without building the packed layout, and does
+	// not create attribute values
+	c.Cif7.setAttribute(AverageValueAttribute)
+	assert.Equal(t, c.Size(), uint32(len(c.Pack())))
+	assert.NotContains(t, c.Attributes, AverageValueAttribute)
+	c.Cif7.IndicatorField7.AverageValue = false
+	c.Cif7.IndicatorField7.MaxValue = false
+	assert.Equal(t, plain, testing.AllocsPerRun(10, func() { c.Size() }))
+}
@@ -38,11 +38,20 @@ func (c *ClassID) Size() uint32 {
 
 func (c *ClassID) Pack() []byte {
 	buf := make([]byte, c.Size())
+	c.PackInto(buf)
+	return buf
+}
+
+func (c *ClassID) PackInto(buf []byte) (int, error) {
+	if err := checkSize("ClassID", buf, c.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:c.Size()]
 	word1 := (uint32(c.PadBitCount&0x1F) << 27) | (c.Oui & 0x00FFFFFF)
 	binary.BigEndian.PutUint32(buf[0:], word1)
 	binary.BigEndian.PutUint16(buf[4:], c.InformationCode)
 	binary.BigEndian.PutUint16(buf[6:], c.PacketCode)
-	return buf
+	return len(buf), nil
 }
 
 func (c *ClassID) Unpack(buf []byte) {
@@ -51,3 +60,14 @@ func (c *ClassID) Unpack(buf []byte) {
 	c.InformationCode = binary.BigEndian.Uint16(buf[4:])
 	c.PacketCode = binary.BigEndian.Uint16(buf[6:])
 }
+
+func (c *ClassID) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("ClassID", buf, c.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("ClassID", buf, 0, 0x07000000); err != nil {
+		return err
+	}
+	c.Unpack(buf)
+	return nil
+}
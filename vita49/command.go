@@ -68,6 +68,15 @@ func (c *CAM) Size() uint32 {
 
 func (c *CAM) Pack() []byte {
 	buf := make([]byte, c.Size())
+	c.PackInto(buf)
+	return buf
+}
+
+func (c *CAM) PackInto(buf []byte) (int, error) {
+	if err := checkSize("CAM", buf, c.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:c.Size()]
 	var bitmap uint32
 	bitmap |= indicatorFieldUint(c.ControlleeEnable, 31)
 	var ceFormat bool
@@ -88,7 +97,7 @@ func (c *CAM) Pack() []byte {
 	bitmap |= indicatorFieldUint(c.NackOnly, 22)
 	bitmap |= uint32(c.TimingControl) << 12
 	binary.BigEndian.PutUint32(buf[0:], bitmap)
-	return buf
+	return len(buf), nil
 }
 
 func (c *CAM) Unpack(buf []byte) {
@@ -113,6 +122,17 @@ func (c *CAM) Unpack(buf []byte) {
 	c.TimingControl = TimestampControlMode(uint8(bitmap>>12) & 7)
 }
 
+func (c *CAM) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("CAM", buf, c.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("CAM", buf, 0, 0x00200FFF); err != nil {
+		return err
+	}
+	c.Unpack(buf)
+	return nil
+}
+
 type ControlCAM struct {
 	CAM
 	ReqV  bool
@@ -123,7 +143,16 @@ type ControlCAM struct {
 }
 
 func (c *ControlCAM) Pack() []byte {
-	buf := c.CAM.Pack()
+	buf := make([]byte, c.Size())
+	c.PackInto(buf)
+	return buf
+}
+
+func (c *ControlCAM) PackInto(buf []byte) (int, error) {
+	n, err := c.CAM.PackInto(buf)
+	if err != nil {
+		return 0, err
+	}
 	var bitmap uint32
 	bitmap |= indicatorFieldUint(c.ReqV, 20)
 	bitmap |= indicatorFieldUint(c.ReqX, 19)
@@ -131,7 +160,7 @@ func (c *ControlCAM) Pack() []byte {
 	bitmap |= indicatorFieldUint(c.ReqW, 17)
 	bitmap |= indicatorFieldUint(c.ReqEr, 16)
 	buf[1] += uint8(bitmap >> 16)
-	return buf
+	return n, nil
 }
 
 func (c *ControlCAM) Unpack(buf []byte) {
@@ -144,6 +173,17 @@ func (c *ControlCAM) Unpack(buf []byte) {
 	c.ReqEr = indicatorFieldBool(bitmap, 16)
 }
 
+func (c *ControlCAM) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("ControlCAM", buf, c.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("ControlCAM", buf, 0, 0x0020CFFF); err != nil {
+		return err
+	}
+	c.Unpack(buf)
+	return nil
+}
+
 type AcknowledgeCAM struct {
 	CAM
 	AckV                bool
@@ -156,7 +196,16 @@ type AcknowledgeCAM struct {
 }
 
 func (a *AcknowledgeCAM) Pack() []byte {
-	buf := a.CAM.Pack()
+	buf := make([]byte, a.Size())
+	a.PackInto(buf)
+	return buf
+}
+
+func (a *AcknowledgeCAM) PackInto(buf []byte) (int, error) {
+	n, err := a.CAM.PackInto(buf)
+	if err != nil {
+		return 0, err
+	}
 	var bitmap uint32
 	bitmap |= indicatorFieldUint(a.AckV, 20)
 	bitmap |= indicatorFieldUint(a.AckX, 19)
@@ -168,7 +217,7 @@ func (a *AcknowledgeCAM) Pack() []byte {
 	buf[1] += uint8(bitmap >> 16)
 	// Shift to third byte, then grab only bits 15 and 14
 	buf[2] += uint8(bitmap>>8) & 0xC0
-	return buf
+	return n, nil
 }
 
 func (a *AcknowledgeCAM) Unpack(buf []byte) {
@@ -183,6 +232,17 @@ func (a *AcknowledgeCAM) Unpack(buf []byte) {
 	a.ScheduledOrExecuted = indicatorFieldBool(bitmap, 14)
 }
 
+func (a *AcknowledgeCAM) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("AcknowledgeCAM", buf, a.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("AcknowledgeCAM", buf, 0, 0x00200FFF); err != nil {
+		return err
+	}
+	a.Unpack(buf)
+	return nil
+}
+
 type WIF0 struct {
 	IndicatorField0
 	Wif7Enable bool
@@ -192,13 +252,19 @@ type WIF0 struct {
 }
 
 func (w *WIF0) Pack() []byte {
+	buf := make([]byte, w.Size())
+	w.PackInto(buf)
+	return buf
+}
+
+func (w *WIF0) PackInto(buf []byte) (int, error) {
 	w.IndicatorField0 = IndicatorField0{
 		If7Enable: w.Wif7Enable,
 		If3Enable: w.Wif3Enable,
 		If2Enable: w.Wif2Enable,
 		If1Enable: w.Wif1Enable,
 	}
-	return w.IndicatorField0.Pack()
+	return w.IndicatorField0.PackInto(buf)
 }
 
 func (w *WIF0) Unpack(buf []byte) {
@@ -209,6 +275,17 @@ func (w *WIF0) Unpack(buf []byte) {
 	w.Wif1Enable = w.IndicatorField0.If1Enable
 }
 
+func (w *WIF0) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("WIF0", buf, w.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("WIF0", buf, 0, 0x00000071); err != nil {
+		return err
+	}
+	w.Unpack(buf)
+	return nil
+}
+
 type EIF0 struct {
 	IndicatorField0
 	Eif7Enable bool
@@ -218,13 +295,19 @@ type EIF0 struct {
 }
 
 func (e *EIF0) Pack() []byte {
+	buf := make([]byte, e.Size())
+	e.PackInto(buf)
+	return buf
+}
+
+func (e *EIF0) PackInto(buf []byte) (int, error) {
 	e.IndicatorField0 = IndicatorField0{
 		If7Enable: e.Eif7Enable,
 		If3Enable: e.Eif3Enable,
 		If2Enable: e.Eif2Enable,
 		If1Enable: e.Eif1Enable,
 	}
-	return e.IndicatorField0.Pack()
+	return e.IndicatorField0.PackInto(buf)
 }
 
 func (e *EIF0) Unpack(buf []byte) {
@@ -235,6 +318,17 @@ func (e *EIF0) Unpack(buf []byte) {
 	e.Eif1Enable = e.IndicatorField0.If1Enable
 }
 
+func (e *EIF0) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("EIF0", buf, e.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("EIF0", buf, 0, 0x00000071); err != nil {
+		return err
+	}
+	e.Unpack(buf)
+	return nil
+}
+
 type WEIF1 struct {
 	IndicatorField1
 }
@@ -273,6 +367,15 @@ func (w *WarningErrorFields) Size() uint32 {
 
 func (w *WarningErrorFields) Pack() []byte {
 	buf := make([]byte, w.Size())
+	w.PackInto(buf)
+	return buf
+}
+
+func (w *WarningErrorFields) PackInto(buf []byte) (int, error) {
+	if err := checkSize("WarningErrorFields", buf, w.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:w.Size()]
 	var bitmap uint32
 	bitmap |= indicatorFieldUint(w.FieldNotExecuted, 31)
 	bitmap |= indicatorFieldUint(w.DeviceFailure, 30)
@@ -288,7 +391,7 @@ func (w *WarningErrorFields) Pack() []byte {
 	bitmap |= indicatorFieldUint(w.CositeInterference, 20)
 	bitmap |= indicatorFieldUint(w.RegionalInterference, 19)
 	binary.BigEndian.PutUint32(buf, bitmap)
-	return buf
+	return len(buf), nil
 }
 
 func (w *WarningErrorFields) Unpack(buf []byte) {
@@ -307,3 +410,14 @@ func (w *WarningErrorFields) Unpack(buf []byte) {
 	w.CositeInterference = indicatorFieldBool(bitmap, 20)
 	w.RegionalInterference = indicatorFieldBool(bitmap, 19)
 }
+
+func (w *WarningErrorFields) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("WarningErrorFields", buf, w.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("WarningErrorFields", buf, 0, 0x0007F000); err != nil {
+		return err
+	}
+	w.Unpack(buf)
+	return nil
+}
@@ -0,0 +1,785 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"encoding/binary"
+)
+
+const (
+	camBytes        = uint32(4)
+	messageIDBytes  = uint32(4)
+	identifierBytes = uint32(4)
+	uuidBytes       = uint32(16)
+	// CIF0 bits 7, 3, 2 and 1 enable the other indicator words
+	cif0EnableBits = uint32(0x8E)
+)
+
+// FieldID identifies a CIF field by the number of its indicator field
+// (0, 1, 2 or 3) and its bit position within that indicator field.
+type FieldID struct {
+	Cif uint8
+	Bit uint8
+}
+
+// commandCAM is satisfied by ControlCAM and AcknowledgeCAM
+type commandCAM interface {
+	CheckedField
+	base() *CAM
+}
+
+func (c *CAM) base() *CAM {
+	return c
+}
+
+// CommandPrologue
+// The fields common to every command packet. The Controllee and Controller
+// identifiers are present when enabled in the CAM, as a single word or as a
+// UUID according to the CAM identifier format.
+type CommandPrologue struct {
+	Header              CommandHeader
+	StreamID            StreamID
+	ClassID             *ClassID
+	IntegerTimestamp    uint32
+	FractionalTimestamp uint64
+	MessageID           uint32
+	ControlleeID        uint32
+	ControlleeUUID      Uuid
+	ControllerID        uint32
+	ControllerUUID      Uuid
+}
+
+func identifierSize(enable bool, format IdentifierFormat) uint32 {
+	if !enable {
+		return 0
+	}
+	if format == UUID {
+		return uuidBytes
+	}
+	return identifierBytes
+}
+
+func (c *CommandPrologue) size(cam *CAM) uint32 {
+	size := headerBytes + streamIDBytes
+	if c.ClassID != nil {
+		size += classIdBytes
+	}
+	if c.Header.Tsi != NoneTsi {
+		size += integerTimestampBytes
+	}
+	if c.Header.Tsf != NoneTsf {
+		size += fractionalTimestampBytes
+	}
+	size += camBytes + messageIDBytes
+	size += identifierSize(cam.ControlleeEnable, cam.ControlleeFormat)
+	size += identifierSize(cam.ControllerEnable, cam.ControllerFormat)
+	return size
+}
+
+// Timestamp returns the prologue timestamp described by the header
+func (c *CommandPrologue) Timestamp() Timestamp {
+	return Timestamp{
+		Tsi:        c.Header.Tsi,
+		Tsf:        c.Header.Tsf,
+		Integer:    c.IntegerTimestamp,
+		Fractional: c.FractionalTimestamp,
+	}
+}
+
+// SetTimestamp sets the header Tsi and Tsf and the timestamp words from t
+func (c *CommandPrologue) SetTimestamp(t Timestamp) {
+	c.Header.Tsi = t.Tsi
+	c.Header.Tsf = t.Tsf
+	c.IntegerTimestamp = t.Integer
+	c.FractionalTimestamp = t.Fractional
+}
+
+// pack writes the prologue, CAM and identifiers into buf and returns the
+// number of bytes written. The PacketType is set to packetType and
+// ClassIdEnable is derived from the packet contents; the caller is
+// responsible for the PacketSize.
+func (c *CommandPrologue) pack(buf []byte, packetType PacketType, cam commandCAM) uint32 {
+	c.Header.PacketType = packetType
+	c.Header.ClassIdEnable = c.ClassID != nil
+	c.Header.PackInto(buf[0:])
+	offset := headerBytes
+	c.StreamID.PackInto(buf[offset:])
+	offset += streamIDBytes
+	if c.ClassID != nil {
+		c.ClassID.PackInto(buf[offset:])
+		offset += classIdBytes
+	}
+	if c.Header.Tsi != NoneTsi {
+		binary.BigEndian.PutUint32(buf[offset:], c.IntegerTimestamp)
+		offset += integerTimestampBytes
+	}
+	if c.Header.Tsf != NoneTsf {
+		binary.BigEndian.PutUint64(buf[offset:], c.FractionalTimestamp)
+		offset += fractionalTimestampBytes
+	}
+	cam.PackInto(buf[offset:])
+	offset += camBytes
+	binary.BigEndian.PutUint32(buf[offset:], c.MessageID)
+	offset += messageIDBytes
+	if base := cam.base(); base.ControlleeEnable {
+		if base.ControlleeFormat == UUID {
+			copy(buf[offset:], c.ControlleeUUID[:])
+		} else {
+			binary.BigEndian.PutUint32(buf[offset:], c.ControlleeID)
+		}
+		offset += identifierSize(base.ControlleeEnable, base.ControlleeFormat)
+	}
+	if base := cam.base(); base.ControllerEnable {
+		if base.ControllerFormat == UUID {
+			copy(buf[offset:], c.ControllerUUID[:])
+		} else {
+			binary.BigEndian.PutUint32(buf[offset:], c.ControllerID)
+		}
+		offset += identifierSize(base.ControllerEnable, base.ControllerFormat)
+	}
+	return offset
+}
+
+// unpack reads the prologue, CAM and identifiers from buf and returns the
+// number of bytes read.
+func (c *CommandPrologue) unpack(buf []byte, cam commandCAM) uint32 {
+	c.Header.Unpack(buf)
+	offset := headerBytes
+	c.StreamID.Unpack(buf[offset:])
+	offset += streamIDBytes
+	if c.Header.ClassIdEnable {
+		c.ClassID = &ClassID{}
+		c.ClassID.Unpack(buf[offset:])
+		offset += classIdBytes
+	} else {
+		c.ClassID = nil
+	}
+	if c.Header.Tsi != NoneTsi {
+		c.IntegerTimestamp = binary.BigEndian.Uint32(buf[offset:])
+		offset += integerTimestampBytes
+	} else {
+		c.IntegerTimestamp = 0
+	}
+	if c.Header.Tsf != NoneTsf {
+		c.FractionalTimestamp = binary.BigEndian.Uint64(buf[offset:])
+		offset += fractionalTimestampBytes
+	} else {
+		c.FractionalTimestamp = 0
+	}
+	cam.Unpack(buf[offset:])
+	offset += camBytes
+	c.MessageID = binary.BigEndian.Uint32(buf[offset:])
+	offset += messageIDBytes
+	c.ControlleeID = 0
+	c.ControlleeUUID = Uuid{}
+	if base := cam.base(); base.ControlleeEnable {
+		if base.ControlleeFormat == UUID {
+			copy(c.ControlleeUUID[:], buf[offset:])
+		} else {
+			c.ControlleeID = binary.BigEndian.Uint32(buf[offset:])
+		}
+		offset += identifierSize(base.ControlleeEnable, base.ControlleeFormat)
+	}
+	c.ControllerID = 0
+	c.ControllerUUID = Uuid{}
+	if base := cam.base(); base.ControllerEnable {
+		if base.ControllerFormat == UUID {
+			copy(c.ControllerUUID[:], buf[offset:])
+		} else {
+			c.ControllerID = binary.BigEndian.Uint32(buf[offset:])
+		}
+		offset += identifierSize(base.ControllerEnable, base.ControllerFormat)
+	}
+	return offset
+}
+
+// unmarshal is the checked form of unpack. It checks the buffer holds the
+// PacketSize declared in the header and returns the buffer trimmed to it
+// along with the number of bytes read. The header must hold packetType.
+func (c *CommandPrologue) unmarshal(typ string, packetType PacketType, buf []byte, cam commandCAM) ([]byte, uint32, error) {
+	var header CommandHeader
+	if err := header.UnmarshalBinary(buf); err != nil {
+		return nil, 0, err
+	}
+	if header.PacketType != packetType {
+		return nil, 0, &PacketTypeError{Type: typ, PacketType: header.PacketType}
+	}
+	buf, err := checkPacketSize(typ, buf, header.PacketSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset := headerBytes + streamIDBytes
+	if header.ClassIdEnable {
+		offset += classIdBytes
+	}
+	if header.Tsi != NoneTsi {
+		offset += integerTimestampBytes
+	}
+	if header.Tsf != NoneTsf {
+		offset += fractionalTimestampBytes
+	}
+	if size := offset + camBytes + messageIDBytes; size > uint32(len(buf)) {
+		return nil, 0, &SizeMismatchError{Type: typ, Declared: uint32(len(buf)), Actual: size}
+	}
+	if header.ClassIdEnable {
+		var classID ClassID
+		if err := classID.UnmarshalBinary(buf[headerBytes+streamIDBytes:]); err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := cam.UnmarshalBinary(buf[offset:]); err != nil {
+		return nil, 0, err
+	}
+	base := cam.base()
+	size := offset + camBytes + messageIDBytes
+	size += identifierSize(base.ControlleeEnable, base.ControlleeFormat)
+	size += identifierSize(base.ControllerEnable, base.ControllerFormat)
+	if size > uint32(len(buf)) {
+		return nil, 0, &SizeMismatchError{Type: typ, Declared: uint32(len(buf)), Actual: size}
+	}
+	return buf, c.unpack(buf, cam), nil
+}
+
+// ControlPacket
+// A Control packet carrying the CIF-selected fields the controllee is asked
+// to act upon.
+type ControlPacket struct {
+	CommandPrologue
+	CAM ControlCAM
+	Cifs
+}
+
+func (p *ControlPacket) Size() uint32 {
+	return p.CommandPrologue.size(&p.CAM.CAM) + p.Cifs.Size()
+}
+
+func (p *ControlPacket) Pack() []byte {
+	return mustPack(p)
+}
+
+// PackInto packs the packet into buf and returns the number of bytes
+// written. The header and CAM are derived as for Pack.
+func (p *ControlPacket) PackInto(buf []byte) (int, error) {
+	p.Header.Acknowledge = false
+	size := p.Size()
+	if err := checkSize("ControlPacket", buf, size); err != nil {
+		return 0, err
+	}
+	packetSize, err := packetWords("ControlPacket", size)
+	if err != nil {
+		return 0, err
+	}
+	if err := p.Cifs.checkTimestampFormat(p.Timestamp()); err != nil {
+		return 0, err
+	}
+	p.Header.PacketSize = packetSize
+	offset := p.CommandPrologue.pack(buf, Command, &p.CAM)
+	if _, err := p.Cifs.PackInto(buf[offset:]); err != nil {
+		return 0, err
+	}
+	return int(size), nil
+}
+
+// AppendPack appends the packed packet to dst, growing it only when its
+// capacity is exhausted.
+func (p *ControlPacket) AppendPack(dst []byte) ([]byte, error) {
+	return appendPack(dst, p)
+}
+
+// UnmarshalBinary is the checked form of Unpack. The packet must be a
+// Command packet without the Acknowledge bit, and the prologue and CIF fields
+// must fill the declared PacketSize exactly.
+func (p *ControlPacket) UnmarshalBinary(buf []byte) error {
+	buf, offset, err := p.CommandPrologue.unmarshal("ControlPacket", Command, buf, &p.CAM)
+	if err != nil {
+		return err
+	}
+	if p.Header.Acknowledge {
+		return &PacketTypeError{Type: "ControlPacket", PacketType: p.Header.PacketType}
+	}
+	format := p.Timestamp()
+	if err := p.Cifs.unmarshal(buf[offset:], &format); err != nil {
+		return err
+	}
+	if size := offset + p.Cifs.Size(); size != uint32(len(buf)) {
+		return &SizeMismatchError{Type: "ControlPacket", Declared: uint32(len(buf)), Actual: size}
+	}
+	return nil
+}
+
+func (p *ControlPacket) Unpack(buf []byte) {
+	offset := p.CommandPrologue.unpack(buf, &p.CAM)
+	format := p.Timestamp()
+	p.Cifs.unpack(buf[offset:], &format)
+}
+
+// warningErrorBitmaps returns the WIF/EIF words flagging each field in fields.
+// CIF7 attributes are not represented, so WIF7/EIF7 are never enabled.
+func warningErrorBitmaps(fields map[FieldID]WarningErrorFields) [4]uint32 {
+	var bitmaps [4]uint32
+	for id := range fields {
+		if id.Cif < 4 && id.Bit < 32 {
+			bitmaps[id.Cif] |= uint32(1) << id.Bit
+		}
+	}
+	bitmaps[0] &^= cif0EnableBits
+	for i := 1; i < 4; i++ {
+		if bitmaps[i] != 0 {
+			bitmaps[0] |= uint32(1) << i
+		}
+	}
+	return bitmaps
+}
+
+// warningErrorSize returns the number of bytes occupied by the indicator
+// words and the per-field words flagged by bitmaps.
+func warningErrorSize(bitmaps [4]uint32) uint32 {
+	var size uint32
+	for i, bitmap := range bitmaps {
+		if i == 0 {
+			size += 4
+			bitmap &^= cif0EnableBits
+		} else if bitmap != 0 {
+			size += 4
+		}
+		for ; bitmap != 0; bitmap &= bitmap - 1 {
+			size += 4
+		}
+	}
+	return size
+}
+
+func packWarningErrorIndicators(buf []byte, bitmaps [4]uint32) uint32 {
+	var offset uint32
+	for i, bitmap := range bitmaps {
+		if i == 0 || indicatorFieldBool(bitmaps[0], uint32(i)) {
+			binary.BigEndian.PutUint32(buf[offset:], bitmap)
+			offset += 4
+		}
+	}
+	return offset
+}
+
+func packWarningErrorFields(buf []byte, bitmaps [4]uint32, fields map[FieldID]WarningErrorFields) uint32 {
+	var offset uint32
+	for i, bitmap := range bitmaps {
+		if i == 0 {
+			bitmap &^= cif0EnableBits
+		}
+		for bit := 31; bit >= 0; bit-- {
+			if indicatorFieldBool(bitmap, uint32(bit)) {
+				field := fields[FieldID{Cif: uint8(i), Bit: uint8(bit)}]
+				field.PackInto(buf[offset:])
+				offset += field.Size()
+			}
+		}
+	}
+	return offset
+}
+
+// unpackWarningErrorIndicators reads WIF0/EIF0 and the indicator words it
+// enables. A WIF7/EIF7 word is skipped without repeating the fields for its
+// attributes, so unmarshalWarningErrorIndicators rejects it.
+func unpackWarningErrorIndicators(buf []byte) ([4]uint32, uint32) {
+	var bitmaps [4]uint32
+	bitmaps[0] = binary.BigEndian.Uint32(buf[0:])
+	offset := uint32(4)
+	for i := 1; i < 4; i++ {
+		if indicatorFieldBool(bitmaps[0], uint32(i)) {
+			bitmaps[i] = binary.BigEndian.Uint32(buf[offset:])
+			offset += 4
+		}
+	}
+	if indicatorFieldBool(bitmaps[0], 7) {
+		offset += 4
+	}
+	return bitmaps, offset
+}
+
+func unpackWarningErrorFields(buf []byte, bitmaps [4]uint32) (map[FieldID]WarningErrorFields, uint32) {
+	fields := make(map[FieldID]WarningErrorFields)
+	var offset uint32
+	for i, bitmap := range bitmaps {
+		if i == 0 {
+			bitmap &^= cif0EnableBits
+		}
+		for bit := 31; bit >= 0; bit-- {
+			if indicatorFieldBool(bitmap, uint32(bit)) {
+				field := WarningErrorFields{}
+				field.Unpack(buf[offset:])
+				fields[FieldID{Cif: uint8(i), Bit: uint8(bit)}] = field
+				offset += field.Size()
+			}
+		}
+	}
+	return fields, offset
+}
+
+func warningErrorAckSize(warnings, errors map[FieldID]WarningErrorFields) uint32 {
+	var size uint32
+	if len(warnings) > 0 {
+		size += warningErrorSize(warningErrorBitmaps(warnings))
+	}
+	if len(errors) > 0 {
+		size += warningErrorSize(warningErrorBitmaps(errors))
+	}
+	return size
+}
+
+// packWarningErrorAck writes the WIF words, EIF words, warning fields and
+// error fields of a Validation or Execution Acknowledge packet.
+func packWarningErrorAck(buf []byte, warnings, errors map[FieldID]WarningErrorFields) {
+	var offset uint32
+	warningBitmaps := warningErrorBitmaps(warnings)
+	errorBitmaps := warningErrorBitmaps(errors)
+	if len(warnings) > 0 {
+		offset += packWarningErrorIndicators(buf[offset:], warningBitmaps)
+	}
+	if len(errors) > 0 {
+		offset += packWarningErrorIndicators(buf[offset:], errorBitmaps)
+	}
+	if len(warnings) > 0 {
+		offset += packWarningErrorFields(buf[offset:], warningBitmaps, warnings)
+	}
+	if len(errors) > 0 {
+		packWarningErrorFields(buf[offset:], errorBitmaps, errors)
+	}
+}
+
+func unpackWarningErrorAck(buf []byte, cam *AcknowledgeCAM) (map[FieldID]WarningErrorFields, map[FieldID]WarningErrorFields) {
+	var offset, n uint32
+	var warningBitmaps, errorBitmaps [4]uint32
+	if cam.AckW {
+		warningBitmaps, n = unpackWarningErrorIndicators(buf[offset:])
+		offset += n
+	}
+	if cam.AckEr {
+		errorBitmaps, n = unpackWarningErrorIndicators(buf[offset:])
+		offset += n
+	}
+	warnings, n := unpackWarningErrorFields(buf[offset:], warningBitmaps)
+	offset += n
+	errors, _ := unpackWarningErrorFields(buf[offset:], errorBitmaps)
+	return warnings, errors
+}
+
+// unmarshalWarningErrorIndicators is the checked form of
+// unpackWarningErrorIndicators. A WIF7/EIF7 word is rejected.
+func unmarshalWarningErrorIndicators(typ string, buf []byte) ([4]uint32, uint32, error) {
+	var indicators WIF0
+	if err := indicators.UnmarshalBinary(buf); err != nil {
+		return [4]uint32{}, 0, err
+	}
+	bitmap := binary.BigEndian.Uint32(buf)
+	if indicatorFieldBool(bitmap, 7) {
+		return [4]uint32{}, 0, &UnsupportedError{Type: typ, Feature: "WIF7/EIF7 attributes"}
+	}
+	count := uint32(1)
+	for _, bit := range []uint32{1, 2, 3} {
+		if indicatorFieldBool(bitmap, bit) {
+			count++
+		}
+	}
+	if err := checkWords(typ, buf, 0, count); err != nil {
+		return [4]uint32{}, 0, err
+	}
+	bitmaps, n := unpackWarningErrorIndicators(buf)
+	return bitmaps, n, nil
+}
+
+// unmarshalWarningErrorFields is the checked form of unpackWarningErrorFields.
+func unmarshalWarningErrorFields(buf []byte, bitmaps [4]uint32) (map[FieldID]WarningErrorFields, uint32, error) {
+	fields := make(map[FieldID]WarningErrorFields)
+	var offset uint32
+	for i, bitmap := range bitmaps {
+		if i == 0 {
+			bitmap &^= cif0EnableBits
+		}
+		for bit := 31; bit >= 0; bit-- {
+			if indicatorFieldBool(bitmap, uint32(bit)) {
+				field := WarningErrorFields{}
+				if err := field.UnmarshalBinary(buf[offset:]); err != nil {
+					return nil, 0, err
+				}
+				fields[FieldID{Cif: uint8(i), Bit: uint8(bit)}] = field
+				offset += field.Size()
+			}
+		}
+	}
+	return fields, offset, nil
+}
+
+// unmarshalWarningErrorAck is the checked form of unpackWarningErrorAck and
+// also returns the number of bytes read.
+func unmarshalWarningErrorAck(typ string, buf []byte, cam *AcknowledgeCAM) (map[FieldID]WarningErrorFields, map[FieldID]WarningErrorFields, uint32, error) {
+	var offset, n uint32
+	var err error
+	var warningBitmaps, errorBitmaps [4]uint32
+	if cam.AckW {
+		if warningBitmaps, n, err = unmarshalWarningErrorIndicators(typ, buf[offset:]); err != nil {
+			return nil, nil, 0, err
+		}
+		offset += n
+	}
+	if cam.AckEr {
+		if errorBitmaps, n, err = unmarshalWarningErrorIndicators(typ, buf[offset:]); err != nil {
+			return nil, nil, 0, err
+		}
+		offset += n
+	}
+	warnings, n, err := unmarshalWarningErrorFields(buf[offset:], warningBitmaps)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	offset += n
+	errors, n, err := unmarshalWarningErrorFields(buf[offset:], errorBitmaps)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	offset += n
+	return warnings, errors, offset, nil
+}
+
+// ValidationAckPacket
+// Reports the result of validating a Control packet. Warnings and Errors hold
+// the WarningErrorFields of each flagged field; the WIF/EIF words are derived
+// from them when packing.
+type ValidationAckPacket struct {
+	CommandPrologue
+	CAM      AcknowledgeCAM
+	Warnings map[FieldID]WarningErrorFields
+	Errors   map[FieldID]WarningErrorFields
+}
+
+func (p *ValidationAckPacket) Size() uint32 {
+	return p.CommandPrologue.size(&p.CAM.CAM) + warningErrorAckSize(p.Warnings, p.Errors)
+}
+
+func (p *ValidationAckPacket) Pack() []byte {
+	return mustPack(p)
+}
+
+// PackInto packs the packet into buf and returns the number of bytes
+// written. The header and CAM are derived as for Pack.
+func (p *ValidationAckPacket) PackInto(buf []byte) (int, error) {
+	p.Header.Acknowledge = true
+	p.CAM.AckV = true
+	p.CAM.AckX = false
+	p.CAM.AckS = false
+	p.CAM.AckW = len(p.Warnings) > 0
+	p.CAM.AckEr = len(p.Errors) > 0
+	size := p.Size()
+	if err := checkSize("ValidationAckPacket", buf, size); err != nil {
+		return 0, err
+	}
+	packetSize, err := packetWords("ValidationAckPacket", size)
+	if err != nil {
+		return 0, err
+	}
+	p.Header.PacketSize = packetSize
+	offset := p.CommandPrologue.pack(buf, Command, &p.CAM)
+	packWarningErrorAck(buf[offset:], p.Warnings, p.Errors)
+	return int(size), nil
+}
+
+// AppendPack appends the packed packet to dst, growing it only when its
+// capacity is exhausted.
+func (p *ValidationAckPacket) AppendPack(dst []byte) ([]byte, error) {
+	return appendPack(dst, p)
+}
+
+// UnmarshalBinary is the checked form of Unpack. The packet must be a
+// Command packet with the Acknowledge bit and AckV set, and the prologue,
+// indicator words and warning/error fields must fill the declared PacketSize
+// exactly.
+func (p *ValidationAckPacket) UnmarshalBinary(buf []byte) error {
+	buf, offset, err := p.CommandPrologue.unmarshal("ValidationAckPacket", Command, buf, &p.CAM)
+	if err != nil {
+		return err
+	}
+	if !p.Header.Acknowledge || !p.CAM.AckV {
+		return &PacketTypeError{Type: "ValidationAckPacket", PacketType: p.Header.PacketType}
+	}
+	warnings, errors, n, err := unmarshalWarningErrorAck("ValidationAckPacket", buf[offset:], &p.CAM)
+	if err != nil {
+		return err
+	}
+	if size := offset + n; size != uint32(len(buf)) {
+		return &SizeMismatchError{Type: "ValidationAckPacket", Declared: uint32(len(buf)), Actual: size}
+	}
+	p.Warnings, p.Errors = warnings, errors
+	return nil
+}
+
+func (p *ValidationAckPacket) Unpack(buf []byte) {
+	offset := p.CommandPrologue.unpack(buf, &p.CAM)
+	p.Warnings, p.Errors = unpackWarningErrorAck(buf[offset:], &p.CAM)
+}
+
+// ExecutionAckPacket
+// Reports the result of executing a Control packet. Warnings and Errors hold
+// the WarningErrorFields of each flagged field; the WIF/EIF words are derived
+// from them when packing.
+type ExecutionAckPacket struct {
+	CommandPrologue
+	CAM      AcknowledgeCAM
+	Warnings map[FieldID]WarningErrorFields
+	Errors   map[FieldID]WarningErrorFields
+}
+
+func (p *ExecutionAckPacket) Size() uint32 {
+	return p.CommandPrologue.size(&p.CAM.CAM) + warningErrorAckSize(p.Warnings, p.Errors)
+}
+
+func (p *ExecutionAckPacket) Pack() []byte {
+	return mustPack(p)
+}
+
+// PackInto packs the packet into buf and returns the number of bytes
+// written. The header and CAM are derived as for Pack.
+func (p *ExecutionAckPacket) PackInto(buf []byte) (int, error) {
+	p.Header.Acknowledge = true
+	p.CAM.AckV = false
+	p.CAM.AckX = true
+	p.CAM.AckS = false
+	p.CAM.AckW = len(p.Warnings) > 0
+	p.CAM.AckEr = len(p.Errors) > 0
+	size := p.Size()
+	if err := checkSize("ExecutionAckPacket", buf, size); err != nil {
+		return 0, err
+	}
+	packetSize, err := packetWords("ExecutionAckPacket", size)
+	if err != nil {
+		return 0, err
+	}
+	p.Header.PacketSize = packetSize
+	offset := p.CommandPrologue.pack(buf, Command, &p.CAM)
+	packWarningErrorAck(buf[offset:], p.Warnings, p.Errors)
+	return int(size), nil
+}
+
+// AppendPack appends the packed packet to dst, growing it only when its
+// capacity is exhausted.
+func (p *ExecutionAckPacket) AppendPack(dst []byte) ([]byte, error) {
+	return appendPack(dst, p)
+}
+
+// UnmarshalBinary is the checked form of Unpack. The packet must be a
+// Command packet with the Acknowledge bit and AckX set, and the prologue,
+// indicator words and warning/error fields must fill the declared PacketSize
+// exactly.
+func (p *ExecutionAckPacket) UnmarshalBinary(buf []byte) error {
+	buf, offset, err := p.CommandPrologue.unmarshal("ExecutionAckPacket", Command, buf, &p.CAM)
+	if err != nil {
+		return err
+	}
+	if !p.Header.Acknowledge || !p.CAM.AckX {
+		return &PacketTypeError{Type: "ExecutionAckPacket", PacketType: p.Header.PacketType}
+	}
+	warnings, errors, n, err := unmarshalWarningErrorAck("ExecutionAckPacket", buf[offset:], &p.CAM)
+	if err != nil {
+		return err
+	}
+	if size := offset + n; size != uint32(len(buf)) {
+		return &SizeMismatchError{Type: "ExecutionAckPacket", Declared: uint32(len(buf)), Actual: size}
+	}
+	p.Warnings, p.Errors = warnings, errors
+	return nil
+}
+
+func (p *ExecutionAckPacket) Unpack(buf []byte) {
+	offset := p.CommandPrologue.unpack(buf, &p.CAM)
+	p.Warnings, p.Errors = unpackWarningErrorAck(buf[offset:], &p.CAM)
+}
+
+// QueryAckPacket
+// Reports the current state of the controllee through CIF-selected fields.
+type QueryAckPacket struct {
+	CommandPrologue
+	CAM AcknowledgeCAM
+	Cifs
+}
+
+func (p *QueryAckPacket) Size() uint32 {
+	return p.CommandPrologue.size(&p.CAM.CAM) + p.Cifs.Size()
+}
+
+func (p *QueryAckPacket) Pack() []byte {
+	return mustPack(p)
+}
+
+// PackInto packs the packet into buf and returns the number of bytes
+// written. The header and CAM are derived as for Pack.
+func (p *QueryAckPacket) PackInto(buf []byte) (int, error) {
+	p.Header.Acknowledge = true
+	p.CAM.AckV = false
+	p.CAM.AckX = false
+	p.CAM.AckS = true
+	size := p.Size()
+	if err := checkSize("QueryAckPacket", buf, size); err != nil {
+		return 0, err
+	}
+	packetSize, err := packetWords("QueryAckPacket", size)
+	if err != nil {
+		return 0, err
+	}
+	if err := p.Cifs.checkTimestampFormat(p.Timestamp()); err != nil {
+		return 0, err
+	}
+	p.Header.PacketSize = packetSize
+	offset := p.CommandPrologue.pack(buf, Command, &p.CAM)
+	if _, err := p.Cifs.PackInto(buf[offset:]); err != nil {
+		return 0, err
+	}
+	return int(size), nil
+}
+
+// AppendPack appends the packed packet to dst, growing it only when its
+// capacity is exhausted.
+func (p *QueryAckPacket) AppendPack(dst []byte) ([]byte, error) {
+	return appendPack(dst, p)
+}
+
+// UnmarshalBinary is the checked form of Unpack. The packet must be a
+// Command packet with the Acknowledge bit and AckS set, and the prologue and
+// CIF fields must fill the declared PacketSize exactly.
+func (p *QueryAckPacket) UnmarshalBinary(buf []byte) error {
+	buf, offset, err := p.CommandPrologue.unmarshal("QueryAckPacket", Command, buf, &p.CAM)
+	if err != nil {
+		return err
+	}
+	if !p.Header.Acknowledge || !p.CAM.AckS {
+		return &PacketTypeError{Type: "QueryAckPacket", PacketType: p.Header.PacketType}
+	}
+	format := p.Timestamp()
+	if err := p.Cifs.unmarshal(buf[offset:], &format); err != nil {
+		return err
+	}
+	if size := offset + p.Cifs.Size(); size != uint32(len(buf)) {
+		return &SizeMismatchError{Type: "QueryAckPacket", Declared: uint32(len(buf)), Actual: size}
+	}
+	return nil
+}
+
+func (p *QueryAckPacket) Unpack(buf []byte) {
+	offset := p.CommandPrologue.unpack(buf, &p.CAM)
+	format := p.Timestamp()
+	p.Cifs.unpack(buf[offset:], &format)
+}
@@ -0,0 +1,325 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControlPacketSize(t *testing.T) {
+	p := ControlPacket{}
+	assert.Equal(t, uint32(20), p.Size())
+}
+
+func TestControlPacketDefault(t *testing.T) {
+	p := ControlPacket{}
+	// Pack
+	packed := p.Pack()
+	expected := []byte{0x60, 0, 0, 5, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	assert.Equal(t, expected, packed)
+	// Unpack
+	p.Unpack(packed)
+	assert.Equal(t, Command, p.Header.PacketType)
+	assert.Equal(t, false, p.Header.Acknowledge)
+	assert.EqualValues(t, 5, p.Header.PacketSize)
+}
+
+func TestControlPacket(t *testing.T) {
+	cases := []struct {
+		name     string
+		packet   ControlPacket
+		expected []byte
+	}{
+		{
+			name: "Identifiers",
+			packet: ControlPacket{
+				CommandPrologue: CommandPrologue{
+					StreamID:       1,
+					MessageID:      0x10,
+					ControlleeID:   0x20,
+					ControllerUUID: [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+				},
+				CAM: ControlCAM{
+					CAM: CAM{
+						ControlleeEnable: true,
+						ControllerEnable: true,
+						ControllerFormat: UUID,
+						ActionMode:       Execute,
+					},
+					ReqV: true,
+				},
+				Cifs: Cifs{
+					Cif0: Cif0{
+						IndicatorField0: IndicatorField0{Gain: true},
+						Gain:            Gain{Stage1: 1.0},
+					},
+				},
+			},
+			expected: []byte{
+				0x60, 0, 0, 11,
+				0, 0, 0, 1,
+				0xB1, 0x10, 0, 0,
+				0, 0, 0, 0x10,
+				0, 0, 0, 0x20,
+				1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+				0, 0x80, 0, 0,
+				0, 0, 0, 0x80,
+			},
+		},
+		{
+			name: "Controllee UUID",
+			packet: ControlPacket{
+				CommandPrologue: CommandPrologue{
+					Header:              CommandHeader{Header: Header{Tsi: Gps, Tsf: SampleCount}},
+					ClassID:             &ClassID{Oui: 0x123456},
+					IntegerTimestamp:    2,
+					FractionalTimestamp: 3,
+					MessageID:           4,
+					ControlleeUUID:      [16]byte{0xFF, 15: 0xEE},
+				},
+				CAM: ControlCAM{
+					CAM: CAM{ControlleeEnable: true, ControlleeFormat: UUID},
+				},
+			},
+			expected: []byte{
+				0x68, 0x90, 0, 14,
+				0, 0, 0, 0,
+				0, 0x12, 0x34, 0x56, 0, 0, 0, 0,
+				0, 0, 0, 2,
+				0, 0, 0, 0, 0, 0, 0, 3,
+				0xC0, 0, 0, 0,
+				0, 0, 0, 4,
+				0xFF, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xEE,
+				0, 0, 0, 0,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := tc.packet
+			// Pack
+			packed := p.Pack()
+			assert.Equal(t, tc.expected, packed)
+			// Unpack
+			unpacked := ControlPacket{}
+			unpacked.Unpack(packed)
+			assert.Equal(t, p, unpacked)
+		})
+	}
+}
+
+func TestValidationAckPacket(t *testing.T) {
+	cases := []struct {
+		name     string
+		packet   ValidationAckPacket
+		expected []byte
+	}{
+		{
+			name:   "No warnings or errors",
+			packet: ValidationAckPacket{},
+			expected: []byte{
+				0x64, 0, 0, 4,
+				0, 0, 0, 0,
+				0, 0x10, 0, 0,
+				0, 0, 0, 0,
+			},
+		},
+		{
+			name: "Warnings and errors",
+			packet: ValidationAckPacket{
+				Warnings: map[FieldID]WarningErrorFields{
+					{Cif: 0, Bit: 23}: {ParamOutOfRange: true},
+				},
+				Errors: map[FieldID]WarningErrorFields{
+					{Cif: 1, Bit: 30}: {FieldNotExecuted: true},
+				},
+			},
+			expected: []byte{
+				0x64, 0, 0, 9,
+				0, 0, 0, 0,
+				0, 0x13, 0, 0,
+				0, 0, 0, 0,
+				0, 0x80, 0, 0,
+				0, 0, 0, 0x02,
+				0x40, 0, 0, 0,
+				0x10, 0, 0, 0,
+				0x80, 0, 0, 0,
+			},
+		},
+		{
+			name: "Warning field order",
+			packet: ValidationAckPacket{
+				Warnings: map[FieldID]WarningErrorFields{
+					{Cif: 3, Bit: 31}: {Distortion: true},
+					{Cif: 0, Bit: 18}: {DeviceFailure: true},
+					{Cif: 0, Bit: 29}: {FieldValueInvalid: true},
+				},
+			},
+			expected: []byte{
+				0x64, 0, 0, 9,
+				0, 0, 0, 0,
+				0, 0x12, 0, 0,
+				0, 0, 0, 0,
+				0x20, 0x04, 0, 0x08,
+				0x80, 0, 0, 0,
+				0x04, 0, 0, 0,
+				0x40, 0, 0, 0,
+				0, 0x80, 0, 0,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := tc.packet
+			// Pack
+			packed := p.Pack()
+			assert.Equal(t, tc.expected, packed)
+			// Unpack
+			unpacked := ValidationAckPacket{}
+			unpacked.Unpack(packed)
+			assert.Equal(t, p.CommandPrologue, unpacked.CommandPrologue)
+			assert.Equal(t, p.CAM, unpacked.CAM)
+			assert.Equal(t, len(p.Warnings), len(unpacked.Warnings))
+			for id, field := range p.Warnings {
+				assert.Equal(t, field, unpacked.Warnings[id])
+			}
+			assert.Equal(t, len(p.Errors), len(unpacked.Errors))
+			for id, field := range p.Errors {
+				assert.Equal(t, field, unpacked.Errors[id])
+			}
+		})
+	}
+}
+
+func TestExecutionAckPacket(t *testing.T) {
+	p := ExecutionAckPacket{
+		CommandPrologue: CommandPrologue{MessageID: 7},
+		Errors: map[FieldID]WarningErrorFields{
+			{Cif: 0, Bit: 21}: {DeviceFailure: true},
+		},
+	}
+	// Pack
+	packed := p.Pack()
+	expected := []byte{
+		0x64, 0, 0, 6,
+		0, 0, 0, 0,
+		0, 0x09, 0, 0,
+		0, 0, 0, 7,
+		0, 0x20, 0, 0,
+		0x40, 0, 0, 0,
+	}
+	assert.Equal(t, expected, packed)
+	// Unpack
+	unpacked := ExecutionAckPacket{}
+	unpacked.Unpack(packed)
+	assert.Equal(t, p.CommandPrologue, unpacked.CommandPrologue)
+	assert.Equal(t, p.CAM, unpacked.CAM)
+	assert.Empty(t, unpacked.Warnings)
+	assert.Equal(t, p.Errors, unpacked.Errors)
+}
+
+func TestQueryAckPacket(t *testing.T) {
+	p := QueryAckPacket{
+		CommandPrologue: CommandPrologue{MessageID: 7},
+		Cifs: Cifs{
+			Cif0: Cif0{
+				IndicatorField0: IndicatorField0{Gain: true},
+				Gain:            Gain{Stage1: 1.0},
+			},
+		},
+	}
+	// Pack
+	packed := p.Pack()
+	expected := []byte{
+		0x64, 0, 0, 6,
+		0, 0, 0, 0,
+		0, 0x04, 0, 0,
+		0, 0, 0, 7,
+		0, 0x80, 0, 0,
+		0, 0, 0, 0x80,
+	}
+	assert.Equal(t, expected, packed)
+	// Unpack
+	unpacked := QueryAckPacket{}
+	unpacked.Unpack(packed)
+	assert.Equal(t, p, unpacked)
+}
+
+func TestCommandPacketUnmarshalBinary(t *testing.T) {
+	control := (&ControlPacket{
+		CommandPrologue: CommandPrologue{MessageID: 1},
+		Cifs: Cifs{
+			Cif0: Cif0{
+				IndicatorField0: IndicatorField0{Gain: true},
+				Gain:            Gain{Stage1: 1},
+			},
+		},
+	}).Pack()
+	validation := (&ValidationAckPacket{
+		CommandPrologue: CommandPrologue{MessageID: 1},
+		Warnings: map[FieldID]WarningErrorFields{
+			{Cif: 0, Bit: 23}: {ParamOutOfRange: true},
+		},
+	}).Pack()
+	execution := (&ExecutionAckPacket{CommandPrologue: CommandPrologue{MessageID: 1}}).Pack()
+	query := (&QueryAckPacket{CommandPrologue: CommandPrologue{MessageID: 1}}).Pack()
+	corrupt := func(valid []byte, offset int, value byte) []byte {
+		buf := append([]byte{}, valid...)
+		buf[offset] = value
+		return buf
+	}
+
+	cases := []struct {
+		name     string
+		packet   interface{ UnmarshalBinary([]byte) error }
+		buf      []byte
+		expected error
+	}{
+		{"Control", &ControlPacket{}, control, nil},
+		{"Validation", &ValidationAckPacket{}, validation, nil},
+		{"Execution", &ExecutionAckPacket{}, execution, nil},
+		{"Query", &QueryAckPacket{}, query, nil},
+		{"Control from ack", &ControlPacket{}, query, ErrPacketType},
+		{"Validation from execution", &ValidationAckPacket{}, execution, ErrPacketType},
+		{"Execution from query", &ExecutionAckPacket{}, query, ErrPacketType},
+		{"Query from control", &QueryAckPacket{}, control, ErrPacketType},
+		{"Control from context", &ControlPacket{}, corrupt(control, 0, 0x40), ErrPacketType},
+		{"Control short", &ControlPacket{}, control[:len(control)-4], ErrShortBuffer},
+		{"Control reserved CAM bits", &ControlPacket{}, corrupt(control, 11, 0x01), ErrReservedBits},
+		{"Control oversized", &ControlPacket{}, append(corrupt(control, 3, byte(len(control)/4+1)), 0, 0, 0, 0), ErrSizeMismatch},
+		{"Validation reserved WIF0 bits", &ValidationAckPacket{}, corrupt(validation, 19, 0x01), ErrReservedBits},
+		{"Validation WIF7 attributes", &ValidationAckPacket{}, corrupt(validation, 19, 0x80), ErrUnsupported},
+		{"Validation reserved field bits", &ValidationAckPacket{}, corrupt(validation, 22, 0x10), ErrReservedBits},
+		{"Validation missing field", &ValidationAckPacket{}, corrupt(validation[:len(validation)-4], 3, byte(len(validation)/4-1)), ErrShortBuffer},
+		{"Query short prologue", &QueryAckPacket{}, corrupt(query, 3, 2), ErrSizeMismatch},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.packet.UnmarshalBinary(tc.buf)
+			if tc.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,549 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"encoding/binary"
+)
+
+// cifField pairs a CIF field value with its indicator bit
+type cifField struct {
+	enabled bool
+	field   CheckedField
+}
+
+func cifFieldsSize(fields []cifField) uint32 {
+	var size uint32
+	for _, f := range fields {
+		if f.enabled {
+			size += f.field.Size()
+		}
+	}
+	return size
+}
+
+func packCifFields(buf []byte, fields []cifField) error {
+	var offset uint32
+	for _, f := range fields {
+		if f.enabled {
+			if _, err := f.field.PackInto(buf[offset:]); err != nil {
+				return err
+			}
+			offset += f.field.Size()
+		}
+	}
+	return nil
+}
+
+func unpackCifFields(buf []byte, fields []cifField) uint32 {
+	var offset uint32
+	for _, f := range fields {
+		if f.enabled {
+			f.field.Unpack(buf[offset:])
+			// Variable length fields report their size once unpacked
+			offset += f.field.Size()
+		}
+	}
+	return offset
+}
+
+// unmarshalCifFields is the checked form of unpackCifFields: each field is
+// handed only the bytes that remain in buf.
+func unmarshalCifFields(buf []byte, fields []cifField) (uint32, error) {
+	var offset uint32
+	for _, f := range fields {
+		if f.enabled {
+			if err := f.field.UnmarshalBinary(buf[offset:]); err != nil {
+				return offset, err
+			}
+			offset += f.field.Size()
+		}
+	}
+	return offset, nil
+}
+
+// word32 carries a single word field as its raw value
+type word32 uint32
+
+func (w *word32) Size() uint32 {
+	return 4
+}
+
+func (w *word32) Pack() []byte {
+	buf := make([]byte, w.Size())
+	w.PackInto(buf)
+	return buf
+}
+
+func (w *word32) PackInto(buf []byte) (int, error) {
+	if err := checkSize("word32", buf, w.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:w.Size()]
+	binary.BigEndian.PutUint32(buf, uint32(*w))
+	return len(buf), nil
+}
+
+func (w *word32) Unpack(buf []byte) {
+	*w = word32(binary.BigEndian.Uint32(buf))
+}
+
+func (w *word32) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("word32", buf, w.Size()); err != nil {
+		return err
+	}
+	w.Unpack(buf)
+	return nil
+}
+
+// word64 carries a two word field as its raw value
+type word64 uint64
+
+func (w *word64) Size() uint32 {
+	return 8
+}
+
+func (w *word64) Pack() []byte {
+	buf := make([]byte, w.Size())
+	w.PackInto(buf)
+	return buf
+}
+
+func (w *word64) PackInto(buf []byte) (int, error) {
+	if err := checkSize("word64", buf, w.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:w.Size()]
+	binary.BigEndian.PutUint64(buf, uint64(*w))
+	return len(buf), nil
+}
+
+func (w *word64) Unpack(buf []byte) {
+	*w = word64(binary.BigEndian.Uint64(buf))
+}
+
+func (w *word64) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("word64", buf, w.Size()); err != nil {
+		return err
+	}
+	w.Unpack(buf)
+	return nil
+}
+
+// Cifs
+// Holds the CIF words and the fields they enable. The CIF1, CIF2, CIF3 and
+// CIF7 words are present when enabled in CIF0, and each CIF field is present
+// when its indicator bit is set. Fields are packed in the order mandated by
+// VITA 49.2: CIF0 bit 31 down to bit 0, then CIF1, CIF2 and CIF3. When CIF7
+// is enabled each field is followed by its other attributes, and its own
+// value is only packed when the current value attribute is enabled.
+type Cifs struct {
+	Cif0 Cif0
+	Cif1 Cif1
+	Cif2 Cif2
+	Cif3 Cif3
+	Cif7 Cif7
+	// Attributes holds the value of each field for the CIF7 attributes
+	// other than the current value, probability and belief
+	Attributes map[Attribute]*Cifs
+	// Probabilities and Beliefs hold the CIF7 probability and belief of
+	// each field
+	Probabilities map[FieldID]Probability
+	Beliefs       map[FieldID]Belief
+}
+
+func (c *Cifs) indicatorFieldsSize() uint32 {
+	size := c.Cif0.Size()
+	if c.Cif0.If1Enable {
+		size += c.Cif1.Size()
+	}
+	if c.Cif0.If2Enable {
+		size += c.Cif2.Size()
+	}
+	if c.Cif0.If3Enable {
+		size += c.Cif3.Size()
+	}
+	if c.Cif0.If7Enable {
+		size += c.Cif7.Size()
+	}
+	return size
+}
+
+func (c *Cifs) fields() []cifField {
+	fields := c.Cif0.fields()
+	if c.Cif0.If1Enable {
+		fields = append(fields, c.Cif1.fields()...)
+	}
+	if c.Cif0.If2Enable {
+		fields = append(fields, c.Cif2.fields()...)
+	}
+	if c.Cif0.If3Enable {
+		fields = append(fields, c.Cif3.fields()...)
+	}
+	return fields
+}
+
+func (c *Cifs) Size() uint32 {
+	return c.indicatorFieldsSize() + c.packedFieldsSize()
+}
+
+func (c *Cifs) Pack() []byte {
+	fields, _ := c.packedFields(false)
+	buf := make([]byte, c.indicatorFieldsSize()+cifFieldsSize(fields))
+	if err := c.packInto(buf, fields); err != nil {
+		panic(err)
+	}
+	return buf
+}
+
+func (c *Cifs) PackInto(buf []byte) (int, error) {
+	fields, _ := c.packedFields(false)
+	size := c.indicatorFieldsSize() + cifFieldsSize(fields)
+	if err := checkSize("Cifs", buf, size); err != nil {
+		return 0, err
+	}
+	if err := c.packInto(buf, fields); err != nil {
+		return 0, err
+	}
+	return int(size), nil
+}
+
+// packInto packs the indicator words followed by fields, the layout
+// packedFields returns, into buf, which is large enough to hold them
+func (c *Cifs) packInto(buf []byte, fields []cifField) error {
+	c.Cif0.PackInto(buf[0:])
+	offset := c.Cif0.Size()
+	if c.Cif0.If1Enable {
+		c.Cif1.PackInto(buf[offset:])
+		offset += c.Cif1.Size()
+	}
+	if c.Cif0.If2Enable {
+		c.Cif2.PackInto(buf[offset:])
+		offset += c.Cif2.Size()
+	}
+	if c.Cif0.If3Enable {
+		c.Cif3.PackInto(buf[offset:])
+		offset += c.Cif3.Size()
+	}
+	if c.Cif0.If7Enable {
+		c.Cif7.PackInto(buf[offset:])
+		offset += c.Cif7.Size()
+	}
+	return packCifFields(buf[offset:], fields)
+}
+
+// Unpack walks the CIF fields according to the enable bits in each
+// indicator word.
+func (c *Cifs) Unpack(buf []byte) {
+	c.unpack(buf, nil)
+}
+
+// unpack is Unpack for the Cifs of a packet whose prologue timestamp is
+// format, which sets the Tsi and Tsf of Age and ShelfLife when not nil
+func (c *Cifs) unpack(buf []byte, format *Timestamp) {
+	c.Cif0.Unpack(buf[0:])
+	offset := c.Cif0.Size()
+	if c.Cif0.If1Enable {
+		c.Cif1.Unpack(buf[offset:])
+		offset += c.Cif1.Size()
+	} else {
+		c.Cif1.IndicatorField1 = IndicatorField1{}
+	}
+	if c.Cif0.If2Enable {
+		c.Cif2.Unpack(buf[offset:])
+		offset += c.Cif2.Size()
+	} else {
+		c.Cif2.IndicatorField2 = IndicatorField2{}
+	}
+	if c.Cif0.If3Enable {
+		c.Cif3.Unpack(buf[offset:])
+		offset += c.Cif3.Size()
+	} else {
+		c.Cif3.IndicatorField3 = IndicatorField3{}
+	}
+	if c.Cif0.If7Enable {
+		c.Cif7.Unpack(buf[offset:])
+		offset += c.Cif7.Size()
+	} else {
+		c.Cif7.IndicatorField7 = IndicatorField7{}
+	}
+	if format != nil {
+		c.Cif3.setTimestampFormat(*format)
+	}
+	fields, store := c.packedFields(true)
+	unpackCifFields(buf[offset:], fields)
+	store()
+}
+
+// UnmarshalBinary is the checked form of Unpack. Each indicator word is
+// checked for reserved bits and every enabled field must fit in buf.
+func (c *Cifs) UnmarshalBinary(buf []byte) error {
+	return c.unmarshal(buf, nil)
+}
+
+// unmarshal is the checked form of unpack
+func (c *Cifs) unmarshal(buf []byte, format *Timestamp) error {
+	if err := c.Cif0.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+	offset := c.Cif0.Size()
+	if c.Cif0.If1Enable {
+		if err := c.Cif1.UnmarshalBinary(buf[offset:]); err != nil {
+			return err
+		}
+		offset += c.Cif1.Size()
+	} else {
+		c.Cif1.IndicatorField1 = IndicatorField1{}
+	}
+	if c.Cif0.If2Enable {
+		if err := c.Cif2.UnmarshalBinary(buf[offset:]); err != nil {
+			return err
+		}
+		offset += c.Cif2.Size()
+	} else {
+		c.Cif2.IndicatorField2 = IndicatorField2{}
+	}
+	if c.Cif0.If3Enable {
+		if err := c.Cif3.UnmarshalBinary(buf[offset:]); err != nil {
+			return err
+		}
+		offset += c.Cif3.Size()
+	} else {
+		c.Cif3.IndicatorField3 = IndicatorField3{}
+	}
+	if c.Cif0.If7Enable {
+		if err := c.Cif7.UnmarshalBinary(buf[offset:]); err != nil {
+			return err
+		}
+		offset += c.Cif7.Size()
+	} else {
+		c.Cif7.IndicatorField7 = IndicatorField7{}
+	}
+	if format != nil {
+		c.Cif3.setTimestampFormat(*format)
+	}
+	fields, store := c.packedFields(true)
+	if _, err := unmarshalCifFields(buf[offset:], fields); err != nil {
+		return err
+	}
+	store()
+	return nil
+}
+
+// checkTimestampFormat returns a TimestampError when an enabled Age or
+// ShelfLife does not take the format of the packet timestamp t
+func (c *Cifs) checkTimestampFormat(t Timestamp) error {
+	if !c.Cif0.If3Enable {
+		return nil
+	}
+	fields := []struct {
+		enabled bool
+		value   *Timestamp
+	}{
+		{c.Cif3.IndicatorField3.Age, &c.Cif3.Age},
+		{c.Cif3.IndicatorField3.ShelfLife, &c.Cif3.ShelfLife},
+	}
+	for _, f := range fields {
+		if f.enabled && (f.value.Tsi != t.Tsi || f.value.Tsf != t.Tsf) {
+			return &TimestampError{Tsi: f.value.Tsi, Tsf: f.value.Tsf, Reason: "Age and ShelfLife must take the packet timestamp format"}
+		}
+	}
+	return nil
+}
+
+// ContextPacket
+// A complete Context packet: header, prologue and the CIF-selected fields.
+type ContextPacket struct {
+	Header              ContextHeader
+	StreamID            StreamID
+	ClassID             *ClassID
+	IntegerTimestamp    uint32
+	FractionalTimestamp uint64
+	Cifs
+}
+
+func (p *ContextPacket) prologueSize() uint32 {
+	size := headerBytes + streamIDBytes
+	if p.ClassID != nil {
+		size += classIdBytes
+	}
+	if p.Header.Tsi != NoneTsi {
+		size += integerTimestampBytes
+	}
+	if p.Header.Tsf != NoneTsf {
+		size += fractionalTimestampBytes
+	}
+	return size
+}
+
+func (p *ContextPacket) Size() uint32 {
+	return p.prologueSize() + p.Cifs.Size()
+}
+
+// Timestamp returns the prologue timestamp described by the header
+func (p *ContextPacket) Timestamp() Timestamp {
+	return Timestamp{
+		Tsi:        p.Header.Tsi,
+		Tsf:        p.Header.Tsf,
+		Integer:    p.IntegerTimestamp,
+		Fractional: p.FractionalTimestamp,
+	}
+}
+
+// SetTimestamp sets the header Tsi and Tsf and the timestamp words from t
+func (p *ContextPacket) SetTimestamp(t Timestamp) {
+	p.Header.Tsi = t.Tsi
+	p.Header.Tsf = t.Tsf
+	p.IntegerTimestamp = t.Integer
+	p.FractionalTimestamp = t.Fractional
+}
+
+// Pack sets the PacketType, ClassIdEnable and PacketSize from the packet
+// contents before packing, so the header always describes the packed bytes.
+func (p *ContextPacket) Pack() []byte {
+	return mustPack(p)
+}
+
+// PackInto packs the packet into buf and returns the number of bytes
+// written. The header is derived as for Pack.
+func (p *ContextPacket) PackInto(buf []byte) (int, error) {
+	size := p.Size()
+	if err := checkSize("ContextPacket", buf, size); err != nil {
+		return 0, err
+	}
+	packetSize, err := packetWords("ContextPacket", size)
+	if err != nil {
+		return 0, err
+	}
+	if err := p.Cifs.checkTimestampFormat(p.Timestamp()); err != nil {
+		return 0, err
+	}
+	p.Header.PacketType = Context
+	p.Header.ClassIdEnable = p.ClassID != nil
+	p.Header.PacketSize = packetSize
+	p.Header.PackInto(buf[0:])
+	offset := headerBytes
+	p.StreamID.PackInto(buf[offset:])
+	offset += streamIDBytes
+	if p.ClassID != nil {
+		p.ClassID.PackInto(buf[offset:])
+		offset += classIdBytes
+	}
+	if p.Header.Tsi != NoneTsi {
+		binary.BigEndian.PutUint32(buf[offset:], p.IntegerTimestamp)
+		offset += integerTimestampBytes
+	}
+	if p.Header.Tsf != NoneTsf {
+		binary.BigEndian.PutUint64(buf[offset:], p.FractionalTimestamp)
+		offset += fractionalTimestampBytes
+	}
+	if _, err := p.Cifs.PackInto(buf[offset:]); err != nil {
+		return 0, err
+	}
+	return int(size), nil
+}
+
+// AppendPack appends the packed packet to dst, growing it only when its
+// capacity is exhausted.
+func (p *ContextPacket) AppendPack(dst []byte) ([]byte, error) {
+	return appendPack(dst, p)
+}
+
+// UnmarshalBinary is the checked form of Unpack. The buffer must hold the
+// PacketSize declared in the header, and the prologue and CIF fields must
+// fill it exactly. p is left unchanged when an error is returned.
+func (p *ContextPacket) UnmarshalBinary(buf []byte) error {
+	var header ContextHeader
+	if err := header.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+	if header.PacketType != Context {
+		return &PacketTypeError{Type: "ContextPacket", PacketType: header.PacketType}
+	}
+	buf, err := checkPacketSize("ContextPacket", buf, header.PacketSize)
+	if err != nil {
+		return err
+	}
+	size := headerBytes + streamIDBytes
+	if header.ClassIdEnable {
+		size += classIdBytes
+	}
+	if header.Tsi != NoneTsi {
+		size += integerTimestampBytes
+	}
+	if header.Tsf != NoneTsf {
+		size += fractionalTimestampBytes
+	}
+	if size > uint32(len(buf)) {
+		return &SizeMismatchError{Type: "ContextPacket", Declared: uint32(len(buf)), Actual: size}
+	}
+	if header.ClassIdEnable {
+		var classID ClassID
+		if err := classID.UnmarshalBinary(buf[headerBytes+streamIDBytes:]); err != nil {
+			return err
+		}
+	}
+	var unpacked ContextPacket
+	offset := unpacked.unpackPrologue(buf)
+	format := unpacked.Timestamp()
+	if err := unpacked.Cifs.unmarshal(buf[offset:], &format); err != nil {
+		return err
+	}
+	if size := offset + unpacked.Cifs.Size(); size != uint32(len(buf)) {
+		return &SizeMismatchError{Type: "ContextPacket", Declared: uint32(len(buf)), Actual: size}
+	}
+	*p = unpacked
+	return nil
+}
+
+// Unpack walks the prologue according to the header bits, then the CIF
+// fields according to the enable bits in each indicator word.
+func (p *ContextPacket) Unpack(buf []byte) {
+	offset := p.unpackPrologue(buf)
+	format := p.Timestamp()
+	p.Cifs.unpack(buf[offset:], &format)
+}
+
+func (p *ContextPacket) unpackPrologue(buf []byte) uint32 {
+	p.Header.Unpack(buf)
+	offset := headerBytes
+	p.StreamID.Unpack(buf[offset:])
+	offset += streamIDBytes
+	if p.Header.ClassIdEnable {
+		p.ClassID = &ClassID{}
+		p.ClassID.Unpack(buf[offset:])
+		offset += classIdBytes
+	} else {
+		p.ClassID = nil
+	}
+	if p.Header.Tsi != NoneTsi {
+		p.IntegerTimestamp = binary.BigEndian.Uint32(buf[offset:])
+		offset += integerTimestampBytes
+	} else {
+		p.IntegerTimestamp = 0
+	}
+	if p.Header.Tsf != NoneTsf {
+		p.FractionalTimestamp = binary.BigEndian.Uint64(buf[offset:])
+		offset += fractionalTimestampBytes
+	} else {
+		p.FractionalTimestamp = 0
+	}
+	return offset
+}
@@ -0,0 +1,280 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextPacketSize(t *testing.T) {
+	p := ContextPacket{}
+	assert.Equal(t, uint32(12), p.Size())
+}
+
+func TestContextPacketDefault(t *testing.T) {
+	p := ContextPacket{}
+	// Pack
+	packed := p.Pack()
+	expected := []byte{0x40, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 0}
+	assert.Equal(t, expected, packed)
+	// Unpack
+	p.Unpack(packed)
+	assert.Equal(t, Context, p.Header.PacketType)
+	assert.EqualValues(t, 3, p.Header.PacketSize)
+	assert.Nil(t, p.ClassID)
+	assert.Equal(t, Cif0{}, p.Cif0)
+}
+
+func TestContextPacket(t *testing.T) {
+	cases := []struct {
+		name     string
+		packet   ContextPacket
+		expected []byte
+	}{
+		{
+			name: "Prologue",
+			packet: ContextPacket{
+				Header:              ContextHeader{Header: Header{Tsi: Utc, Tsf: Picoseconds}, Tsm: Coarse},
+				StreamID:            0x12345678,
+				ClassID:             &ClassID{Oui: 0xABCDEF, InformationCode: 1, PacketCode: 2},
+				IntegerTimestamp:    3,
+				FractionalTimestamp: 4,
+			},
+			expected: []byte{
+				0x49, 0x60, 0, 8,
+				0x12, 0x34, 0x56, 0x78,
+				0, 0xAB, 0xCD, 0xEF, 0, 1, 0, 2,
+				0, 0, 0, 3,
+				0, 0, 0, 0, 0, 0, 0, 4,
+				0, 0, 0, 0,
+			},
+		},
+		{
+			name: "CIF0 field order",
+			packet: ContextPacket{
+				Cifs: Cifs{
+					Cif0: Cif0{
+						IndicatorField0: IndicatorField0{Bandwidth: true, Gain: true, DeviceID: true},
+						Bandwidth:       20e6,
+						Gain:            Gain{Stage1: 1.0},
+						DeviceID:        DeviceIdentifier{ManufacturerOui: 0x123456, DeviceCode: 0xABCD},
+					},
+				},
+			},
+			expected: []byte{
+				0x40, 0, 0, 8,
+				0, 0, 0, 0,
+				0x20, 0x82, 0, 0,
+				0, 0, 0x13, 0x12, 0xD0, 0, 0, 0,
+				0, 0, 0, 0x80,
+				0, 0x12, 0x34, 0x56, 0, 0, 0xAB, 0xCD,
+			},
+		},
+		{
+			name: "CIF1",
+			packet: ContextPacket{
+				Cifs: Cifs{
+					Cif0: Cif0{IndicatorField0: IndicatorField0{If1Enable: true}},
+					Cif1: Cif1{
+						IndicatorField1: IndicatorField1{Polarization: true, AuxFrequency: true},
+						Polarization:    Polarization{TiltAngle: 1.0},
+						AuxFrequency:    1e6,
+					},
+				},
+			},
+			expected: []byte{
+				0x40, 0, 0, 7,
+				0, 0, 0, 0,
+				0, 0, 0, 0x02,
+				0x40, 0, 0x80, 0,
+				0x20, 0, 0, 0,
+				0, 0, 0, 0xF4, 0x24, 0, 0, 0,
+			},
+		},
+		{
+			name: "CIF0 fields before CIF1 fields",
+			packet: ContextPacket{
+				Cifs: Cifs{
+					Cif0: Cif0{
+						IndicatorField0: IndicatorField0{ReferenceLevel: true, If1Enable: true, If2Enable: true, If3Enable: true},
+						ReferenceLevel:  -10.5,
+					},
+					Cif1: Cif1{
+						IndicatorField1: IndicatorField1{BufferSize: true},
+						BufferSize:      BufferSize{BufferSize: 0x10},
+					},
+					Cif2: Cif2{
+						IndicatorField2: IndicatorField2{ControlleeUUID: true},
+						ControlleeUUID:  [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+					},
+					Cif3: Cif3{
+						IndicatorField3: IndicatorField3{SeaSwellState: true},
+						SeaSwellState:   SeaSwellState{SeaState: 3},
+					},
+				},
+			},
+			expected: []byte{
+				0x40, 0, 0, 14,
+				0, 0, 0, 0,
+				0x01, 0, 0, 0x0E,
+				0, 0, 0, 0x02,
+				0x01, 0, 0, 0,
+				0, 0, 0, 0x08,
+				0, 0, 0xFA, 0xC0,
+				0, 0, 0, 0x10, 0, 0, 0, 0,
+				1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+				0, 0, 0, 3,
+			},
+		},
+		{
+			name: "CIF7 word",
+			packet: ContextPacket{
+				Cifs: Cifs{
+					Cif0: Cif0{IndicatorField0: IndicatorField0{If7Enable: true}},
+					Cif7: Cif7{IndicatorField7: IndicatorField7{CurrentValue: true}},
+				},
+			},
+			expected: []byte{
+				0x40, 0, 0, 4,
+				0, 0, 0, 0,
+				0, 0, 0, 0x80,
+				0x80, 0, 0, 0,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := tc.packet
+			// Pack
+			packed := p.Pack()
+			assert.Equal(t, tc.expected, packed)
+			assert.Equal(t, p.Size(), uint32(len(packed)))
+			// Unpack
+			unpacked := ContextPacket{}
+			unpacked.Unpack(packed)
+			assert.Equal(t, p, unpacked)
+		})
+	}
+}
+
+func TestContextPacketVariableFields(t *testing.T) {
+	p := ContextPacket{
+		Cifs: Cifs{
+			Cif0: Cif0{
+				IndicatorField0: IndicatorField0{
+					GpsAscii:                true,
+					ContextAssociationLists: true,
+					If1Enable:               true,
+				},
+				GpsAscii: GpsAscii{
+					ManufacturerOui: 0x123456,
+					NumberOfWords:   2,
+					AsciiSentences:  []byte("$GPGGA,1"),
+				},
+				ContextAssociationLists: ContextAssociationLists{
+					SourceListSize: 1,
+					SystemListSize: 2,
+					SourceList:     []uint32{0x01020304},
+					SystemList:     []uint32{0x05060708, 0x090A0B0C},
+					VectorList:     []uint32{},
+					AsyncList:      []uint32{},
+					AsyncTagList:   []uint32{},
+				},
+			},
+			Cif1: Cif1{
+				IndicatorField1: IndicatorField1{IndexList: true, VersionInformation: true},
+				IndexList: IndexList{
+					TotalSize:  4,
+					EntrySize:  4,
+					NumEntries: 2,
+					Entries:    []uint32{7, 8},
+				},
+				VersionInformation: VersionInformation{Year: 24, Day: 100, Revision: 1},
+			},
+		},
+	}
+	packed := p.Pack()
+	assert.Equal(t, p.Size(), uint32(len(packed)))
+	assert.EqualValues(t, len(packed)/4, p.Header.PacketSize)
+
+	unpacked := ContextPacket{}
+	unpacked.Unpack(packed)
+	assert.Equal(t, p.Cif0.GpsAscii, unpacked.Cif0.GpsAscii)
+	assert.Equal(t, p.Cif0.ContextAssociationLists, unpacked.Cif0.ContextAssociationLists)
+	assert.Equal(t, p.Cif1.IndexList, unpacked.Cif1.IndexList)
+	assert.Equal(t, p.Cif1.VersionInformation, unpacked.Cif1.VersionInformation)
+}
+
+func TestContextPacketUnmarshalBinary(t *testing.T) {
+	p := ContextPacket{
+		Cifs: Cifs{
+			Cif0: Cif0{
+				IndicatorField0: IndicatorField0{Gain: true, GpsAscii: true},
+				Gain:            Gain{Stage1: 1},
+				GpsAscii: GpsAscii{
+					NumberOfWords:  1,
+					AsciiSentences: []byte("$GPG"),
+				},
+			},
+		},
+	}
+	valid := p.Pack()
+	unpacked := ContextPacket{}
+	assert.NoError(t, unpacked.UnmarshalBinary(valid))
+	assert.Equal(t, p.Cif0.Gain, unpacked.Cif0.Gain)
+	assert.Equal(t, p.Cif0.GpsAscii, unpacked.Cif0.GpsAscii)
+
+	// Trailing bytes beyond the declared PacketSize are ignored
+	assert.NoError(t, unpacked.UnmarshalBinary(append(append([]byte{}, valid...), 0, 0, 0, 0)))
+
+	corrupt := func(offset int, value byte) []byte {
+		buf := append([]byte{}, valid...)
+		buf[offset] = value
+		return buf
+	}
+	cases := []struct {
+		name     string
+		buf      []byte
+		expected error
+	}{
+		{"Empty", nil, ErrShortBuffer},
+		{"Short of PacketSize", valid[:len(valid)-4], ErrShortBuffer},
+		{"Wrong packet type", corrupt(0, 0x60), ErrPacketType},
+		{"Reserved header bit", corrupt(0, 0x44), ErrReservedBits},
+		{"Reserved CIF0 bit", corrupt(11, 0x01), ErrReservedBits},
+		{"PacketSize shorter than fields", corrupt(3, byte(len(valid)/4-1)), ErrShortBuffer},
+		{"PacketSize longer than fields", append(corrupt(3, byte(len(valid)/4+1)), 0, 0, 0, 0), ErrSizeMismatch},
+		{"PacketSize shorter than prologue", corrupt(3, 1), ErrSizeMismatch},
+		{"GPS ASCII word count", corrupt(23, 2), ErrShortBuffer},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			unpacked := ContextPacket{}
+			require.NoError(t, unpacked.UnmarshalBinary(valid))
+			before := unpacked
+			assert.ErrorIs(t, unpacked.UnmarshalBinary(tc.buf), tc.expected)
+			// A failed unmarshal leaves the packet as it was
+			assert.Equal(t, before, unpacked)
+		})
+	}
+}
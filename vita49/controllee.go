@@ -0,0 +1,302 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import "encoding/binary"
+
+// cif0ChangeIndicator is CIF0 bit 31, which is not a field
+const cif0ChangeIndicator = uint32(1) << 31
+
+// FieldHandler
+// Applies a single CIF field of Control packets on behalf of a Controllee.
+// Validate checks the requested value without applying it, Execute applies
+// it, and Query sets the current value of the field in the Cifs of a
+// Query-State acknowledgement. Problems are reported through the returned
+// warnings and errors; the zero WarningErrorFields reports none.
+type FieldHandler interface {
+	Validate(packet *ControlPacket) (warnings, errors WarningErrorFields)
+	Execute(packet *ControlPacket) (warnings, errors WarningErrorFields)
+	Query(cifs *Cifs)
+}
+
+// FieldHandlerFuncs
+// Adapts ordinary functions to a FieldHandler. A nil ValidateFunc or
+// ExecuteFunc reports no warnings or errors, and a nil QueryFunc reports the
+// zero value of the field.
+type FieldHandlerFuncs struct {
+	ValidateFunc func(packet *ControlPacket) (warnings, errors WarningErrorFields)
+	ExecuteFunc  func(packet *ControlPacket) (warnings, errors WarningErrorFields)
+	QueryFunc    func(cifs *Cifs)
+}
+
+func (f FieldHandlerFuncs) Validate(packet *ControlPacket) (warnings, errors WarningErrorFields) {
+	if f.ValidateFunc == nil {
+		return WarningErrorFields{}, WarningErrorFields{}
+	}
+	return f.ValidateFunc(packet)
+}
+
+func (f FieldHandlerFuncs) Execute(packet *ControlPacket) (warnings, errors WarningErrorFields) {
+	if f.ExecuteFunc == nil {
+		return WarningErrorFields{}, WarningErrorFields{}
+	}
+	return f.ExecuteFunc(packet)
+}
+
+func (f FieldHandlerFuncs) Query(cifs *Cifs) {
+	if f.QueryFunc != nil {
+		f.QueryFunc(cifs)
+	}
+}
+
+// Controllee
+// Handles Control packets by dispatching each enabled CIF field to the
+// FieldHandler registered for it, and builds the acknowledgements requested
+// in the ControlCAM. Handlers are registered with Handle before the
+// Controllee is used; HandleControl may then be called concurrently, so
+// handlers must be safe for concurrent use.
+type Controllee struct {
+	handlers map[FieldID]FieldHandler
+}
+
+func NewControllee() *Controllee {
+	return &Controllee{handlers: make(map[FieldID]FieldHandler)}
+}
+
+// Handle registers h for the field identified by id, replacing any handler
+// already registered for it.
+func (c *Controllee) Handle(id FieldID, h FieldHandler) {
+	c.handlers[id] = h
+}
+
+// ackFields collects the warnings and errors reported for each field
+type ackFields struct {
+	warnings map[FieldID]WarningErrorFields
+	errors   map[FieldID]WarningErrorFields
+}
+
+func newAckFields() ackFields {
+	return ackFields{
+		warnings: make(map[FieldID]WarningErrorFields),
+		errors:   make(map[FieldID]WarningErrorFields),
+	}
+}
+
+func (a ackFields) add(id FieldID, warnings, errors WarningErrorFields) {
+	if warnings != (WarningErrorFields{}) {
+		a.warnings[id] = warnings
+	}
+	if errors != (WarningErrorFields{}) {
+		a.errors[id] = errors
+	}
+}
+
+// filter drops the warnings and errors the controller did not request
+func (a ackFields) filter(cam *ControlCAM) ackFields {
+	if !cam.ReqW {
+		a.warnings = make(map[FieldID]WarningErrorFields)
+	}
+	if !cam.ReqEr {
+		a.errors = make(map[FieldID]WarningErrorFields)
+	}
+	return a
+}
+
+func (a ackFields) empty() bool {
+	return len(a.warnings) == 0 && len(a.errors) == 0
+}
+
+// HandleControl validates and executes the fields of p according to its
+// ControlCAM and returns the Validation, Execution and Query-State
+// acknowledgements it requests, in that order.
+//
+// Fields are validated when a Validation acknowledgement is requested or the
+// ActionMode is DryRun or Execute; a field without a registered handler is
+// reported as an ErroneousField error. Fields are executed only in Execute
+// mode. A field with validation errors is executed only when PermitErrors is
+// set, and one with validation warnings only when PermitWarnings is set.
+// Unless PermitPartial is set, no field is executed when any field cannot be.
+// Fields left unexecuted are reported as FieldNotExecuted errors in the
+// Execution acknowledgement.
+//
+// Warnings and errors are included in the acknowledgements when ReqW and
+// ReqEr are set, and with NackOnly set the Validation and Execution
+// acknowledgements are only returned when they report something. The
+// Query-State acknowledgement holds the current value of each requested
+// field that has a handler.
+func (c *Controllee) HandleControl(p *ControlPacket) []Packet {
+	ids := p.Cifs.fieldIDs()
+	validation := newAckFields()
+	if p.CAM.ReqV || p.CAM.ActionMode != NoAction {
+		for _, id := range ids {
+			h, ok := c.handlers[id]
+			if !ok {
+				validation.add(id, WarningErrorFields{}, WarningErrorFields{ErroneousField: true})
+				continue
+			}
+			warnings, errors := h.Validate(p)
+			validation.add(id, warnings, errors)
+		}
+	}
+
+	execution := newAckFields()
+	var executed int
+	if p.CAM.ActionMode == Execute {
+		runnable := make(map[FieldID]bool, len(ids))
+		for _, id := range ids {
+			_, hasHandler := c.handlers[id]
+			_, hasWarnings := validation.warnings[id]
+			_, hasErrors := validation.errors[id]
+			if hasHandler && (!hasWarnings || p.CAM.PermitWarnings) && (!hasErrors || p.CAM.PermitErrors) {
+				runnable[id] = true
+			}
+		}
+		if len(runnable) < len(ids) && !p.CAM.PermitPartial {
+			clear(runnable)
+		}
+		for _, id := range ids {
+			if !runnable[id] {
+				errors := validation.errors[id]
+				errors.FieldNotExecuted = true
+				execution.add(id, validation.warnings[id], errors)
+				continue
+			}
+			warnings, errors := c.handlers[id].Execute(p)
+			execution.add(id, warnings, errors)
+			executed++
+		}
+	}
+
+	var acks []Packet
+	if p.CAM.ReqV {
+		fields := validation.filter(&p.CAM)
+		if !p.CAM.NackOnly || !fields.empty() {
+			acks = append(acks, &ValidationAckPacket{
+				CommandPrologue: ackPrologue(p),
+				CAM:             AcknowledgeCAM{CAM: p.CAM.CAM},
+				Warnings:        fields.warnings,
+				Errors:          fields.errors,
+			})
+		}
+	}
+	if p.CAM.ReqX {
+		fields := execution.filter(&p.CAM)
+		if !p.CAM.NackOnly || !fields.empty() {
+			acks = append(acks, &ExecutionAckPacket{
+				CommandPrologue: ackPrologue(p),
+				CAM: AcknowledgeCAM{
+					CAM:                 p.CAM.CAM,
+					PartialAction:       executed > 0 && executed < len(ids),
+					ScheduledOrExecuted: executed > 0,
+				},
+				Warnings: fields.warnings,
+				Errors:   fields.errors,
+			})
+		}
+	}
+	if p.CAM.ReqS {
+		ack := &QueryAckPacket{
+			CommandPrologue: ackPrologue(p),
+			CAM:             AcknowledgeCAM{CAM: p.CAM.CAM},
+		}
+		for _, id := range ids {
+			if h, ok := c.handlers[id]; ok {
+				ack.Cifs.setIndicator(id)
+				h.Query(&ack.Cifs)
+			}
+		}
+		acks = append(acks, ack)
+	}
+	return acks
+}
+
+// ackPrologue copies the prologue of p for an acknowledgement, so that the
+// acknowledgement carries the same stream, message and identifiers.
+func ackPrologue(p *ControlPacket) CommandPrologue {
+	prologue := p.CommandPrologue
+	prologue.Header.Acknowledge = true
+	if p.ClassID != nil {
+		classID := *p.ClassID
+		prologue.ClassID = &classID
+	}
+	return prologue
+}
+
+// indicatorBitmaps returns the CIF0 to CIF3 indicator words as bitmaps. The
+// words of indicator fields not enabled in CIF0 are zero.
+func (c *Cifs) indicatorBitmaps() [4]uint32 {
+	var bitmaps [4]uint32
+	var buf [4]byte
+	c.Cif0.IndicatorField0.PackInto(buf[:])
+	bitmaps[0] = binary.BigEndian.Uint32(buf[:])
+	if c.Cif0.If1Enable {
+		c.Cif1.IndicatorField1.PackInto(buf[:])
+		bitmaps[1] = binary.BigEndian.Uint32(buf[:])
+	}
+	if c.Cif0.If2Enable {
+		c.Cif2.IndicatorField2.PackInto(buf[:])
+		bitmaps[2] = binary.BigEndian.Uint32(buf[:])
+	}
+	if c.Cif0.If3Enable {
+		c.Cif3.IndicatorField3.PackInto(buf[:])
+		bitmaps[3] = binary.BigEndian.Uint32(buf[:])
+	}
+	return bitmaps
+}
+
+// fieldIDs lists the enabled CIF0 to CIF3 fields in packing order
+func (c *Cifs) fieldIDs() []FieldID {
+	var ids []FieldID
+	for i, bitmap := range c.indicatorBitmaps() {
+		if i == 0 {
+			bitmap &^= cif0EnableBits | cif0ChangeIndicator
+		}
+		for bit := 31; bit >= 0; bit-- {
+			if indicatorFieldBool(bitmap, uint32(bit)) {
+				ids = append(ids, FieldID{Cif: uint8(i), Bit: uint8(bit)})
+			}
+		}
+	}
+	return ids
+}
+
+// setIndicator sets the indicator bit of the field identified by id, along
+// with the CIF0 bit enabling its indicator field.
+func (c *Cifs) setIndicator(id FieldID) {
+	var buf [4]byte
+	set := func(f CheckedField) {
+		f.PackInto(buf[:])
+		binary.BigEndian.PutUint32(buf[:], binary.BigEndian.Uint32(buf[:])|uint32(1)<<id.Bit)
+		f.Unpack(buf[:])
+	}
+	switch id.Cif {
+	case 0:
+		set(&c.Cif0.IndicatorField0)
+	case 1:
+		c.Cif0.If1Enable = true
+		set(&c.Cif1.IndicatorField1)
+	case 2:
+		c.Cif0.If2Enable = true
+		set(&c.Cif2.IndicatorField2)
+	case 3:
+		c.Cif0.If3Enable = true
+		set(&c.Cif3.IndicatorField3)
+	}
+}
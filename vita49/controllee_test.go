@@ -0,0 +1,313 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	gainID      = FieldID{Cif: 0, Bit: 23}
+	bandwidthID = FieldID{Cif: 0, Bit: 29}
+	rangeID     = FieldID{Cif: 1, Bit: 24}
+)
+
+// controlleeState is the device state driven by the test controllee
+type controlleeState struct {
+	gain      Gain
+	bandwidth Frequency
+	rng       Range
+}
+
+// newTestControllee rejects gains above 10 dB and warns about a zero
+// bandwidth. Range has no validation.
+func newTestControllee() (*Controllee, *controlleeState) {
+	state := &controlleeState{gain: Gain{Stage1: 1}, bandwidth: 100, rng: 7}
+	c := NewControllee()
+	c.Handle(gainID, FieldHandlerFuncs{
+		ValidateFunc: func(p *ControlPacket) (WarningErrorFields, WarningErrorFields) {
+			if p.Cif0.Gain.Stage1 > 10 {
+				return WarningErrorFields{}, WarningErrorFields{ParamOutOfRange: true}
+			}
+			return WarningErrorFields{}, WarningErrorFields{}
+		},
+		ExecuteFunc: func(p *ControlPacket) (WarningErrorFields, WarningErrorFields) {
+			state.gain = p.Cif0.Gain
+			return WarningErrorFields{}, WarningErrorFields{}
+		},
+		QueryFunc: func(cifs *Cifs) {
+			cifs.Cif0.Gain = state.gain
+		},
+	})
+	c.Handle(bandwidthID, FieldHandlerFuncs{
+		ValidateFunc: func(p *ControlPacket) (WarningErrorFields, WarningErrorFields) {
+			if p.Cif0.Bandwidth == 0 {
+				return WarningErrorFields{FieldValueInvalid: true}, WarningErrorFields{}
+			}
+			return WarningErrorFields{}, WarningErrorFields{}
+		},
+		ExecuteFunc: func(p *ControlPacket) (WarningErrorFields, WarningErrorFields) {
+			state.bandwidth = p.Cif0.Bandwidth
+			return WarningErrorFields{}, WarningErrorFields{}
+		},
+		QueryFunc: func(cifs *Cifs) {
+			cifs.Cif0.Bandwidth = state.bandwidth
+		},
+	})
+	c.Handle(rangeID, FieldHandlerFuncs{
+		ExecuteFunc: func(p *ControlPacket) (WarningErrorFields, WarningErrorFields) {
+			state.rng = p.Cif1.Range
+			return WarningErrorFields{}, WarningErrorFields{DeviceFailure: true}
+		},
+		QueryFunc: func(cifs *Cifs) {
+			cifs.Cif1.Range = state.rng
+		},
+	})
+	return c, state
+}
+
+func controlleeRequest(cam ControlCAM, gain float64, bandwidth Frequency) *ControlPacket {
+	return &ControlPacket{
+		CommandPrologue: CommandPrologue{StreamID: 1, MessageID: 42},
+		CAM:             cam,
+		Cifs: Cifs{Cif0: Cif0{
+			IndicatorField0: IndicatorField0{Gain: true, Bandwidth: true},
+			Gain:            Gain{Stage1: gain},
+			Bandwidth:       bandwidth,
+		}},
+	}
+}
+
+func TestCifsFieldIDs(t *testing.T) {
+	cifs := Cifs{
+		Cif0: Cif0{IndicatorField0: IndicatorField0{ChangeIndicator: true, Gain: true, Bandwidth: true, If1Enable: true, If7Enable: true}},
+		Cif1: Cif1{IndicatorField1: IndicatorField1{Range: true, BufferSize: true}},
+		Cif3: Cif3{IndicatorField3: IndicatorField3{Age: true}},
+	}
+	assert.Equal(t, []FieldID{bandwidthID, gainID, rangeID, {Cif: 1, Bit: 1}}, cifs.fieldIDs())
+
+	var set Cifs
+	set.setIndicator(rangeID)
+	set.setIndicator(gainID)
+	assert.True(t, set.Cif0.IndicatorField0.Gain)
+	assert.True(t, set.Cif0.If1Enable)
+	assert.True(t, set.Cif1.IndicatorField1.Range)
+	assert.Equal(t, []FieldID{gainID, rangeID}, set.fieldIDs())
+}
+
+func TestControlleeHandleControl(t *testing.T) {
+	none := map[FieldID]WarningErrorFields{}
+	tests := []struct {
+		name      string
+		cam       ControlCAM
+		gain      float64
+		bandwidth Frequency
+		// Expected validation and execution acknowledgements, nil when
+		// none is expected
+		validation *ValidationAckPacket
+		execution  *ExecutionAckPacket
+		// Expected state after handling
+		state controlleeState
+	}{
+		{
+			name:       "no action",
+			cam:        ControlCAM{CAM: CAM{ActionMode: NoAction}, ReqV: true, ReqW: true, ReqEr: true},
+			gain:       20,
+			bandwidth:  0,
+			validation: &ValidationAckPacket{Warnings: map[FieldID]WarningErrorFields{bandwidthID: {FieldValueInvalid: true}}, Errors: map[FieldID]WarningErrorFields{gainID: {ParamOutOfRange: true}}},
+			state:      controlleeState{gain: Gain{Stage1: 1}, bandwidth: 100, rng: 7},
+		},
+		{
+			name:       "dry run",
+			cam:        ControlCAM{CAM: CAM{ActionMode: DryRun}, ReqV: true, ReqX: true, ReqW: true, ReqEr: true},
+			gain:       5,
+			bandwidth:  200,
+			validation: &ValidationAckPacket{Warnings: none, Errors: none},
+			execution:  &ExecutionAckPacket{Warnings: none, Errors: none},
+			state:      controlleeState{gain: Gain{Stage1: 1}, bandwidth: 100, rng: 7},
+		},
+		{
+			name:      "execute",
+			cam:       ControlCAM{CAM: CAM{ActionMode: Execute}, ReqX: true, ReqW: true, ReqEr: true},
+			gain:      5,
+			bandwidth: 200,
+			execution: &ExecutionAckPacket{CAM: AcknowledgeCAM{ScheduledOrExecuted: true}, Warnings: none, Errors: none},
+			state:     controlleeState{gain: Gain{Stage1: 5}, bandwidth: 200, rng: 7},
+		},
+		{
+			name:      "errors not permitted",
+			cam:       ControlCAM{CAM: CAM{ActionMode: Execute}, ReqX: true, ReqW: true, ReqEr: true},
+			gain:      20,
+			bandwidth: 200,
+			execution: &ExecutionAckPacket{Warnings: none, Errors: map[FieldID]WarningErrorFields{
+				gainID:      {FieldNotExecuted: true, ParamOutOfRange: true},
+				bandwidthID: {FieldNotExecuted: true},
+			}},
+			state: controlleeState{gain: Gain{Stage1: 1}, bandwidth: 100, rng: 7},
+		},
+		{
+			name:      "errors permitted",
+			cam:       ControlCAM{CAM: CAM{ActionMode: Execute, PermitErrors: true}, ReqX: true, ReqW: true, ReqEr: true},
+			gain:      20,
+			bandwidth: 200,
+			execution: &ExecutionAckPacket{CAM: AcknowledgeCAM{ScheduledOrExecuted: true}, Warnings: none, Errors: none},
+			state:     controlleeState{gain: Gain{Stage1: 20}, bandwidth: 200, rng: 7},
+		},
+		{
+			name:      "partial",
+			cam:       ControlCAM{CAM: CAM{ActionMode: Execute, PermitPartial: true}, ReqX: true, ReqW: true, ReqEr: true},
+			gain:      20,
+			bandwidth: 200,
+			execution: &ExecutionAckPacket{
+				CAM:      AcknowledgeCAM{PartialAction: true, ScheduledOrExecuted: true},
+				Warnings: none,
+				Errors:   map[FieldID]WarningErrorFields{gainID: {FieldNotExecuted: true, ParamOutOfRange: true}},
+			},
+			state: controlleeState{gain: Gain{Stage1: 1}, bandwidth: 200, rng: 7},
+		},
+		{
+			name:      "warnings not permitted",
+			cam:       ControlCAM{CAM: CAM{ActionMode: Execute, PermitPartial: true}, ReqX: true, ReqW: true, ReqEr: true},
+			gain:      5,
+			bandwidth: 0,
+			execution: &ExecutionAckPacket{
+				CAM:      AcknowledgeCAM{PartialAction: true, ScheduledOrExecuted: true},
+				Warnings: map[FieldID]WarningErrorFields{bandwidthID: {FieldValueInvalid: true}},
+				Errors:   map[FieldID]WarningErrorFields{bandwidthID: {FieldNotExecuted: true}},
+			},
+			state: controlleeState{gain: Gain{Stage1: 5}, bandwidth: 100, rng: 7},
+		},
+		{
+			name:      "warnings permitted",
+			cam:       ControlCAM{CAM: CAM{ActionMode: Execute, PermitWarnings: true}, ReqX: true, ReqW: true, ReqEr: true},
+			gain:      5,
+			bandwidth: 0,
+			execution: &ExecutionAckPacket{CAM: AcknowledgeCAM{ScheduledOrExecuted: true}, Warnings: none, Errors: none},
+			state:     controlleeState{gain: Gain{Stage1: 5}, bandwidth: 0, rng: 7},
+		},
+		{
+			name:       "warnings and errors not requested",
+			cam:        ControlCAM{CAM: CAM{ActionMode: DryRun}, ReqV: true},
+			gain:       20,
+			bandwidth:  0,
+			validation: &ValidationAckPacket{Warnings: none, Errors: none},
+			state:      controlleeState{gain: Gain{Stage1: 1}, bandwidth: 100, rng: 7},
+		},
+		{
+			name:      "nack only without problems",
+			cam:       ControlCAM{CAM: CAM{ActionMode: Execute, NackOnly: true}, ReqV: true, ReqX: true, ReqW: true, ReqEr: true},
+			gain:      5,
+			bandwidth: 200,
+			state:     controlleeState{gain: Gain{Stage1: 5}, bandwidth: 200, rng: 7},
+		},
+		{
+			name:       "nack only with problems",
+			cam:        ControlCAM{CAM: CAM{ActionMode: DryRun, NackOnly: true}, ReqV: true, ReqX: true, ReqW: true, ReqEr: true},
+			gain:       20,
+			bandwidth:  200,
+			validation: &ValidationAckPacket{Warnings: none, Errors: map[FieldID]WarningErrorFields{gainID: {ParamOutOfRange: true}}},
+			state:      controlleeState{gain: Gain{Stage1: 1}, bandwidth: 100, rng: 7},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, state := newTestControllee()
+			request := controlleeRequest(test.cam, test.gain, test.bandwidth)
+			acks := c.HandleControl(request)
+
+			var expected []Packet
+			prologue := CommandPrologue{Header: CommandHeader{Acknowledge: true}, StreamID: 1, MessageID: 42}
+			if test.validation != nil {
+				test.validation.CommandPrologue = prologue
+				test.validation.CAM.CAM = test.cam.CAM
+				expected = append(expected, test.validation)
+			}
+			if test.execution != nil {
+				test.execution.CommandPrologue = prologue
+				test.execution.CAM.CAM = test.cam.CAM
+				expected = append(expected, test.execution)
+			}
+			assert.Equal(t, expected, acks)
+			assert.Equal(t, test.state, *state)
+		})
+	}
+}
+
+func TestControlleeUnhandledField(t *testing.T) {
+	c, state := newTestControllee()
+	request := controlleeRequest(ControlCAM{CAM: CAM{ActionMode: Execute, PermitPartial: true}, ReqV: true, ReqX: true, ReqEr: true}, 5, 200)
+	request.Cif0.IndicatorField0.Temperature = true
+	acks := c.HandleControl(request)
+	require.Len(t, acks, 2)
+	temperatureID := FieldID{Cif: 0, Bit: 18}
+	assert.Equal(t, map[FieldID]WarningErrorFields{temperatureID: {ErroneousField: true}}, acks[0].(*ValidationAckPacket).Errors)
+	assert.Equal(t, map[FieldID]WarningErrorFields{temperatureID: {ErroneousField: true, FieldNotExecuted: true}}, acks[1].(*ExecutionAckPacket).Errors)
+	assert.True(t, acks[1].(*ExecutionAckPacket).CAM.PartialAction)
+	assert.Equal(t, Gain{Stage1: 5}, state.gain)
+}
+
+func TestControlleeExecuteErrors(t *testing.T) {
+	c, state := newTestControllee()
+	request := &ControlPacket{
+		CAM: ControlCAM{CAM: CAM{ActionMode: Execute}, ReqX: true, ReqEr: true},
+		Cifs: Cifs{
+			Cif0: Cif0{IndicatorField0: IndicatorField0{If1Enable: true}},
+			Cif1: Cif1{IndicatorField1: IndicatorField1{Range: true}, Range: 9},
+		},
+	}
+	acks := c.HandleControl(request)
+	require.Len(t, acks, 1)
+	ack := acks[0].(*ExecutionAckPacket)
+	assert.Equal(t, map[FieldID]WarningErrorFields{rangeID: {DeviceFailure: true}}, ack.Errors)
+	assert.True(t, ack.CAM.ScheduledOrExecuted)
+	assert.Equal(t, Range(9), state.rng)
+}
+
+func TestControlleeQuery(t *testing.T) {
+	c, _ := newTestControllee()
+	classID := &ClassID{Oui: 0x123456}
+	request := &ControlPacket{
+		CommandPrologue: CommandPrologue{StreamID: 3, ClassID: classID, MessageID: 7},
+		CAM:             ControlCAM{ReqS: true},
+		Cifs: Cifs{
+			Cif0: Cif0{IndicatorField0: IndicatorField0{Gain: true, Temperature: true, If1Enable: true}},
+			Cif1: Cif1{IndicatorField1: IndicatorField1{Range: true}},
+		},
+	}
+	acks := c.HandleControl(request)
+	require.Len(t, acks, 1)
+	ack := acks[0].(*QueryAckPacket)
+	assert.Equal(t, uint32(7), ack.MessageID)
+	assert.Equal(t, StreamID(3), ack.StreamID)
+	assert.Equal(t, classID, ack.ClassID)
+	assert.NotSame(t, classID, ack.ClassID)
+	assert.Equal(t, Cifs{
+		Cif0: Cif0{IndicatorField0: IndicatorField0{Gain: true, If1Enable: true}, Gain: Gain{Stage1: 1}},
+		Cif1: Cif1{IndicatorField1: IndicatorField1{Range: true}, Range: 7},
+	}, ack.Cifs)
+
+	// The acknowledgement survives a round trip
+	packet, _, err := UnmarshalPacket(ack.Pack())
+	require.NoError(t, err)
+	assert.Equal(t, ack, packet)
+}
@@ -29,7 +29,7 @@ func (ei *EnableIndicator) Reset() {
 	ei.Value = false
 }
 
-func (ei *EnableIndicator) Pack(buf []byte, enPos uint8, inPos uint8) {
+func (ei *EnableIndicator) PackBits(buf []byte, enPos uint8, inPos uint8) {
 	var enableVal uint8
 	var indicatorVal uint8
 	if ei.Enable {
@@ -46,7 +46,7 @@ func (ei *EnableIndicator) Pack(buf []byte, enPos uint8, inPos uint8) {
 	buf[bytePos] = (buf[bytePos] & mask) | (indicatorVal << (inPos % 8))
 }
 
-func (ei *EnableIndicator) Unpack(buf []byte, enPos uint8, inPos uint8) {
+func (ei *EnableIndicator) UnpackBits(buf []byte, enPos uint8, inPos uint8) {
 	bytePos := uint8((31 - enPos) / 8)
 	ei.Enable = (buf[bytePos] & (1 << (enPos % 8))) != 0
 	bytePos = uint8((31 - inPos) / 8)
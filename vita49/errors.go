@@ -0,0 +1,336 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrShortBuffer    = errors.New("vita49: short buffer")
+	ErrSizeMismatch   = errors.New("vita49: size mismatch")
+	ErrReservedBits   = errors.New("vita49: reserved bits set")
+	ErrListCount      = errors.New("vita49: inconsistent list count")
+	ErrPacketType     = errors.New("vita49: unexpected packet type")
+	ErrTimestamp      = errors.New("vita49: unsupported timestamp")
+	ErrPayloadFormat  = errors.New("vita49: unsupported payload format")
+	ErrFrameAlignment = errors.New("vita49: missing VRL frame alignment word")
+	ErrCrc            = errors.New("vita49: VRL frame CRC mismatch")
+	ErrParse          = errors.New("vita49: invalid text form")
+	ErrRegistration   = errors.New("vita49: invalid registration")
+	ErrUnknownClass   = errors.New("vita49: unknown packet class")
+	ErrSchema         = errors.New("vita49: invalid packet schema")
+	ErrValidation     = errors.New("vita49: packet does not match schema")
+	ErrUnsupported    = errors.New("vita49: unsupported feature")
+)
+
+// ShortBufferError is returned when a buffer is too short to hold the type
+// being unpacked.
+type ShortBufferError struct {
+	Type string
+	Need uint32
+	Have uint32
+}
+
+func (e *ShortBufferError) Error() string {
+	return fmt.Sprintf("vita49: %s needs %d bytes, have %d", e.Type, e.Need, e.Have)
+}
+
+func (e *ShortBufferError) Is(target error) bool {
+	return target == ErrShortBuffer
+}
+
+// SizeMismatchError is returned when the size declared by a packet or field
+// does not match the size of its contents.
+type SizeMismatchError struct {
+	Type     string
+	Declared uint32
+	Actual   uint32
+}
+
+func (e *SizeMismatchError) Error() string {
+	return fmt.Sprintf("vita49: %s declares %d bytes, contents are %d", e.Type, e.Declared, e.Actual)
+}
+
+func (e *SizeMismatchError) Is(target error) bool {
+	return target == ErrSizeMismatch
+}
+
+// ReservedBitsError is returned when bits the standard reserves are set in
+// the word at the given byte offset.
+type ReservedBitsError struct {
+	Type   string
+	Offset uint32
+	Bits   uint32
+}
+
+func (e *ReservedBitsError) Error() string {
+	return fmt.Sprintf("vita49: %s has reserved bits 0x%08X set in word at offset %d", e.Type, e.Bits, e.Offset)
+}
+
+func (e *ReservedBitsError) Is(target error) bool {
+	return target == ErrReservedBits
+}
+
+// ListCountError is returned when the counts describing a list or array
+// disagree with each other.
+type ListCountError struct {
+	Type     string
+	List     string
+	Count    uint32
+	Expected uint32
+}
+
+func (e *ListCountError) Error() string {
+	return fmt.Sprintf("vita49: %s %s count is %d, expected %d", e.Type, e.List, e.Count, e.Expected)
+}
+
+func (e *ListCountError) Is(target error) bool {
+	return target == ErrListCount
+}
+
+// PacketTypeError is returned when a buffer holds a different type of packet
+// than the one being unpacked.
+type PacketTypeError struct {
+	Type       string
+	PacketType PacketType
+}
+
+func (e *PacketTypeError) Error() string {
+	return fmt.Sprintf("vita49: %s cannot hold packet type %d", e.Type, e.PacketType)
+}
+
+func (e *PacketTypeError) Is(target error) bool {
+	return target == ErrPacketType
+}
+
+// TimestampError is returned when a timestamp cannot be converted or holds
+// an invalid value for its Tsi and Tsf.
+type TimestampError struct {
+	Tsi    Tsi
+	Tsf    Tsf
+	Reason string
+}
+
+func (e *TimestampError) Error() string {
+	return fmt.Sprintf("vita49: timestamp with Tsi %d and Tsf %d: %s", e.Tsi, e.Tsf, e.Reason)
+}
+
+func (e *TimestampError) Is(target error) bool {
+	return target == ErrTimestamp
+}
+
+// PayloadFormatError is returned when a PayloadFormat describes a payload
+// that cannot be encoded or decoded.
+type PayloadFormatError struct {
+	Format PayloadFormat
+	Reason string
+}
+
+func (e *PayloadFormatError) Error() string {
+	return fmt.Sprintf("vita49: payload format with data item format 0x%02X: %s", e.Format.DataItemFormat, e.Reason)
+}
+
+func (e *PayloadFormatError) Is(target error) bool {
+	return target == ErrPayloadFormat
+}
+
+// FrameAlignmentError is returned when a VRL frame does not start with the
+// frame alignment word.
+type FrameAlignmentError struct {
+	Word uint32
+}
+
+func (e *FrameAlignmentError) Error() string {
+	return fmt.Sprintf("vita49: VRL frame starts with 0x%08X, not the frame alignment word", e.Word)
+}
+
+func (e *FrameAlignmentError) Is(target error) bool {
+	return target == ErrFrameAlignment
+}
+
+// CrcError is returned when the CRC received in a VRL frame trailer does not
+// match the CRC computed over the frame.
+type CrcError struct {
+	FrameCount uint16
+	Received   uint32
+	Computed   uint32
+}
+
+func (e *CrcError) Error() string {
+	return fmt.Sprintf("vita49: VRL frame %d has CRC 0x%08X, computed 0x%08X", e.FrameCount, e.Received, e.Computed)
+}
+
+func (e *CrcError) Is(target error) bool {
+	return target == ErrCrc
+}
+
+// ParseError is returned when text does not hold a valid value of the type
+// being parsed.
+type ParseError struct {
+	Type string
+	Text string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("vita49: invalid %s %q", e.Type, e.Text)
+}
+
+func (e *ParseError) Is(target error) bool {
+	return target == ErrParse
+}
+
+// RegistrationError is returned when a definition cannot be registered for a
+// ClassID.
+type RegistrationError struct {
+	ClassID ClassID
+	Reason  string
+}
+
+func (e *RegistrationError) Error() string {
+	return fmt.Sprintf("vita49: class ID %06X:%04X:%04X: %s", e.ClassID.Oui, e.ClassID.InformationCode, e.ClassID.PacketCode, e.Reason)
+}
+
+func (e *RegistrationError) Is(target error) bool {
+	return target == ErrRegistration
+}
+
+// UnknownClassError is returned when a Registry has no decoder for the class
+// of a packet. ClassID is nil when the packet has none.
+type UnknownClassError struct {
+	ClassID *ClassID
+}
+
+func (e *UnknownClassError) Error() string {
+	if e.ClassID == nil {
+		return "vita49: no decoder for packets without a class ID"
+	}
+	return fmt.Sprintf("vita49: no decoder for class ID %06X:%04X:%04X", e.ClassID.Oui, e.ClassID.InformationCode, e.ClassID.PacketCode)
+}
+
+func (e *UnknownClassError) Is(target error) bool {
+	return target == ErrUnknownClass
+}
+
+// SchemaError is returned when packet definitions cannot be loaded. Schema
+// names the definition at fault, if any.
+type SchemaError struct {
+	Schema string
+	Reason string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Schema == "" {
+		return fmt.Sprintf("vita49: packet schema: %s", e.Reason)
+	}
+	return fmt.Sprintf("vita49: packet schema %s: %s", e.Schema, e.Reason)
+}
+
+func (e *SchemaError) Is(target error) bool {
+	return target == ErrSchema
+}
+
+// ValidationError is returned when a packet does not match its schema
+type ValidationError struct {
+	Schema string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("vita49: packet does not match %s: %s", e.Schema, e.Reason)
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// UnsupportedError is returned when a packet uses a feature of the standard
+// that cannot be unpacked.
+type UnsupportedError struct {
+	Type    string
+	Feature string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("vita49: %s uses unsupported %s", e.Type, e.Feature)
+}
+
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+func checkSize(typ string, buf []byte, size uint32) error {
+	if uint32(len(buf)) < size {
+		return &ShortBufferError{Type: typ, Need: size, Have: uint32(len(buf))}
+	}
+	return nil
+}
+
+// checkReserved assumes the caller has already checked the buffer holds the
+// word at offset.
+func checkReserved(typ string, buf []byte, offset uint32, mask uint32) error {
+	if bits := binary.BigEndian.Uint32(buf[offset:]) & mask; bits != 0 {
+		return &ReservedBitsError{Type: typ, Offset: offset, Bits: bits}
+	}
+	return nil
+}
+
+// checkWords checks buf holds offset bytes followed by count words. The count
+// comes off the wire, so it is checked before being scaled to bytes.
+func checkWords(typ string, buf []byte, offset uint32, count uint32) error {
+	need := uint64(offset) + 4*uint64(count)
+	if need > uint64(len(buf)) {
+		if need > uint64(^uint32(0)) {
+			need = uint64(^uint32(0))
+		}
+		return &ShortBufferError{Type: typ, Need: uint32(need), Have: uint32(len(buf))}
+	}
+	return nil
+}
+
+// packetWords returns the PacketSize of a packet of size bytes, which must
+// fit in the 16 bits of the header.
+func packetWords(typ string, size uint32) (uint16, error) {
+	if size > MaxPacketBytes {
+		return 0, &SizeMismatchError{Type: typ, Declared: MaxPacketBytes, Actual: size}
+	}
+	return uint16(size / 4), nil
+}
+
+// checkArrayCount checks a count fits in the 12 bits an array header holds
+// it in
+func checkArrayCount(typ string, list string, count uint32) error {
+	if count > 0xFFF {
+		return &ListCountError{Type: typ, List: list, Count: count, Expected: 0xFFF}
+	}
+	return nil
+}
+
+// checkPacketSize checks buf holds the PacketSize words declared in the
+// header and returns buf trimmed to them.
+func checkPacketSize(typ string, buf []byte, packetSize uint16) ([]byte, error) {
+	size := uint32(packetSize) * 4
+	if err := checkSize(typ, buf, size); err != nil {
+		return nil, err
+	}
+	return buf[:size], nil
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorsIs(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected error
+		message  string
+	}{
+		{
+			name:     "Short buffer",
+			err:      &ShortBufferError{Type: "Header", Need: 4, Have: 2},
+			expected: ErrShortBuffer,
+			message:  "vita49: Header needs 4 bytes, have 2",
+		},
+		{
+			name:     "Size mismatch",
+			err:      &SizeMismatchError{Type: "ContextPacket", Declared: 8, Actual: 12},
+			expected: ErrSizeMismatch,
+			message:  "vita49: ContextPacket declares 8 bytes, contents are 12",
+		},
+		{
+			name:     "Reserved bits",
+			err:      &ReservedBitsError{Type: "ClassID", Offset: 0, Bits: 0x01000000},
+			expected: ErrReservedBits,
+			message:  "vita49: ClassID has reserved bits 0x01000000 set in word at offset 0",
+		},
+		{
+			name:     "List count",
+			err:      &ListCountError{Type: "IndexList", List: "TotalSize", Count: 1, Expected: 2},
+			expected: ErrListCount,
+			message:  "vita49: IndexList TotalSize count is 1, expected 2",
+		},
+		{
+			name:     "Packet type",
+			err:      &PacketTypeError{Type: "ContextPacket", PacketType: Command},
+			expected: ErrPacketType,
+			message:  "vita49: ContextPacket cannot hold packet type 6",
+		},
+		{
+			name:     "Timestamp",
+			err:      &TimestampError{Tsi: Other, Tsf: FreeRunning, Reason: "no time reference"},
+			expected: ErrTimestamp,
+			message:  "vita49: timestamp with Tsi 3 and Tsf 3: no time reference",
+		},
+		{
+			name:     "Payload format",
+			err:      &PayloadFormatError{Format: PayloadFormat{DataItemFormat: 0x08}, Reason: "reserved data item format"},
+			expected: ErrPayloadFormat,
+			message:  "vita49: payload format with data item format 0x08: reserved data item format",
+		},
+		{
+			name:     "Frame alignment",
+			err:      &FrameAlignmentError{Word: 0x12345678},
+			expected: ErrFrameAlignment,
+			message:  "vita49: VRL frame starts with 0x12345678, not the frame alignment word",
+		},
+		{
+			name:     "CRC",
+			err:      &CrcError{FrameCount: 3, Received: 2, Computed: 1},
+			expected: ErrCrc,
+			message:  "vita49: VRL frame 3 has CRC 0x00000002, computed 0x00000001",
+		},
+		{
+			name:     "Parse",
+			err:      &ParseError{Type: "Uuid", Text: "xyz"},
+			expected: ErrParse,
+			message:  `vita49: invalid Uuid "xyz"`,
+		},
+		{
+			name:     "Registration",
+			err:      &RegistrationError{ClassID: ClassID{Oui: 0x123456, InformationCode: 1, PacketCode: 2}, Reason: "bit 3 already registered"},
+			expected: ErrRegistration,
+			message:  "vita49: class ID 123456:0001:0002: bit 3 already registered",
+		},
+		{
+			name:     "Unknown class",
+			err:      &UnknownClassError{ClassID: &ClassID{Oui: 0x123456, InformationCode: 1, PacketCode: 2}},
+			expected: ErrUnknownClass,
+			message:  "vita49: no decoder for class ID 123456:0001:0002",
+		},
+		{
+			name:     "Unknown class without class ID",
+			err:      &UnknownClassError{},
+			expected: ErrUnknownClass,
+			message:  "vita49: no decoder for packets without a class ID",
+		},
+		{
+			name:     "Schema",
+			err:      &SchemaError{Schema: "Example", Reason: `unknown type "beacon"`},
+			expected: ErrSchema,
+			message:  `vita49: packet schema Example: unknown type "beacon"`,
+		},
+		{
+			name:     "Schema without name",
+			err:      &SchemaError{Reason: "empty document"},
+			expected: ErrSchema,
+			message:  "vita49: packet schema: empty document",
+		},
+		{
+			name:     "Validation",
+			err:      &ValidationError{Schema: "Example", Reason: "missing required field bandwidth"},
+			expected: ErrValidation,
+			message:  "vita49: packet does not match Example: missing required field bandwidth",
+		},
+		{
+			name:     "Unsupported",
+			err:      &UnsupportedError{Type: "ExecutionAckPacket", Feature: "WIF7/EIF7 attributes"},
+			expected: ErrUnsupported,
+			message:  "vita49: ExecutionAckPacket uses unsupported WIF7/EIF7 attributes",
+		},
+	}
+
+	sentinels := []error{ErrShortBuffer, ErrSizeMismatch, ErrReservedBits, ErrListCount, ErrPacketType, ErrTimestamp, ErrPayloadFormat, ErrFrameAlignment, ErrCrc, ErrParse, ErrRegistration, ErrUnknownClass, ErrSchema, ErrValidation, ErrUnsupported}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.message, tc.err.Error())
+			for _, sentinel := range sentinels {
+				assert.Equal(t, sentinel == tc.expected, errors.Is(tc.err, sentinel))
+			}
+		})
+	}
+}
+
+func TestCheckWords(t *testing.T) {
+	buf := make([]byte, 12)
+	assert.NoError(t, checkWords("Test", buf, 4, 2))
+	err := checkWords("Test", buf, 4, 3)
+	assert.Equal(t, &ShortBufferError{Type: "Test", Need: 16, Have: 12}, err)
+	// A count read off the wire must not wrap when scaled to bytes
+	err = checkWords("Test", buf, 8, 0xFFFFFFFF)
+	assert.Equal(t, &ShortBufferError{Type: "Test", Need: 0xFFFFFFFF, Have: 12}, err)
+}
@@ -0,0 +1,532 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const (
+	extensionCifBytes = uint32(4)
+)
+
+// ExtensionField
+// Defines a user-defined field of the extension packets of a class. Bit is
+// the position of the indicator bit enabling the field in the extension CIF
+// word, and New returns an empty value of the type the field unpacks into,
+// whose Size, PackInto and Unpack lay out the field.
+type ExtensionField struct {
+	Name string
+	Bit  uint8
+	New  func() CheckedField
+}
+
+// ExtensionRegistry
+// Holds the extension fields registered for each ClassID, ignoring its
+// PadBitCount. It is safe for concurrent use.
+type ExtensionRegistry struct {
+	mu      sync.RWMutex
+	classes map[ClassID]map[uint8]ExtensionField
+}
+
+// DefaultExtensions is the registry extension packets are decoded with when
+// they do not name another
+var DefaultExtensions = &ExtensionRegistry{}
+
+// RegisterExtension registers fields for classID with DefaultExtensions
+func RegisterExtension(classID ClassID, fields ...ExtensionField) error {
+	return DefaultExtensions.Register(classID, fields...)
+}
+
+// classKey identifies the class of classID, which does not depend on its
+// PadBitCount
+func classKey(classID ClassID) ClassID {
+	classID.PadBitCount = 0
+	return classID
+}
+
+// Register adds fields to those registered for classID. When a field has a
+// bit above 31, no New function or a bit already registered, none of fields
+// are registered.
+func (r *ExtensionRegistry) Register(classID ClassID, fields ...ExtensionField) error {
+	key := classKey(classID)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	registered := make(map[uint8]ExtensionField, len(r.classes[key])+len(fields))
+	for bit, f := range r.classes[key] {
+		registered[bit] = f
+	}
+	for _, f := range fields {
+		_, duplicate := registered[f.Bit]
+		switch {
+		case f.Bit > 31:
+			return &RegistrationError{ClassID: key, Reason: fmt.Sprintf("extension field %q has bit %d", f.Name, f.Bit)}
+		case f.New == nil:
+			return &RegistrationError{ClassID: key, Reason: fmt.Sprintf("extension field %q has no New function", f.Name)}
+		case duplicate:
+			return &RegistrationError{ClassID: key, Reason: fmt.Sprintf("extension field %q uses bit %d, which is already registered", f.Name, f.Bit)}
+		}
+		registered[f.Bit] = f
+	}
+	if r.classes == nil {
+		r.classes = map[ClassID]map[uint8]ExtensionField{}
+	}
+	// Registered maps are replaced rather than modified, so lookup may hand
+	// them out
+	r.classes[key] = registered
+	return nil
+}
+
+// Fields returns the fields registered for classID in packing order (bit 31
+// down to bit 0)
+func (r *ExtensionRegistry) Fields(classID ClassID) []ExtensionField {
+	registered := r.lookup(&classID)
+	fields := make([]ExtensionField, 0, len(registered))
+	for _, f := range registered {
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Bit > fields[j].Bit })
+	return fields
+}
+
+// lookup returns the fields registered for the class of a packet, or nil
+// when it has no ClassID or none are registered
+func (r *ExtensionRegistry) lookup(classID *ClassID) map[uint8]ExtensionField {
+	if classID == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.classes[classKey(*classID)]
+}
+
+// ExtensionFields
+// The body of an extension packet: an extension CIF word whose indicator
+// bits enable the fields that follow it, packed from bit 31 down to bit 0.
+// Fields holds the value of each field keyed by its bit, and the CIF word is
+// derived from it. The body of a packet whose ClassID has no registered
+// fields, or which enables a field that is not registered, is kept in Raw
+// instead and packed as is when Fields is nil. Raw and each field must be a
+// whole number of words.
+//
+// Fields are unpacked with those registered for the ClassID of the packet
+// with Extensions, or with DefaultExtensions when it is nil.
+type ExtensionFields struct {
+	Fields     map[uint8]CheckedField
+	Raw        []byte
+	Extensions *ExtensionRegistry
+}
+
+// lookup returns the fields registered for classID with the registry the
+// body is unpacked with
+func (e *ExtensionFields) lookup(classID *ClassID) map[uint8]ExtensionField {
+	if e.Extensions != nil {
+		return e.Extensions.lookup(classID)
+	}
+	return DefaultExtensions.lookup(classID)
+}
+
+// bits lists the bits of the fields in packing order
+func (e *ExtensionFields) bits() []uint8 {
+	bits := make([]uint8, 0, len(e.Fields))
+	for bit := range e.Fields {
+		if bit < 32 {
+			bits = append(bits, bit)
+		}
+	}
+	sort.Slice(bits, func(i, j int) bool { return bits[i] > bits[j] })
+	return bits
+}
+
+func (e *ExtensionFields) raw() bool {
+	return e.Fields == nil && e.Raw != nil
+}
+
+func (e *ExtensionFields) size() uint32 {
+	if e.raw() {
+		return uint32(len(e.Raw))
+	}
+	size := extensionCifBytes
+	for _, bit := range e.bits() {
+		size += e.Fields[bit].Size()
+	}
+	return size
+}
+
+// checkWords checks Raw, or each of Fields, is a whole number of words, so
+// that the PacketSize of the packet describes the body exactly
+func (e *ExtensionFields) checkWords() error {
+	if e.raw() {
+		if size := uint32(len(e.Raw)); size%4 != 0 {
+			return &SizeMismatchError{Type: "ExtensionFields", Declared: size &^ 3, Actual: size}
+		}
+		return nil
+	}
+	for _, bit := range e.bits() {
+		if size := e.Fields[bit].Size(); size%4 != 0 {
+			return &SizeMismatchError{Type: fmt.Sprintf("ExtensionFields bit %d", bit), Declared: size &^ 3, Actual: size}
+		}
+	}
+	return nil
+}
+
+func (e *ExtensionFields) packInto(buf []byte) error {
+	if e.raw() {
+		copy(buf, e.Raw)
+		return nil
+	}
+	bits := e.bits()
+	var word uint32
+	for _, bit := range bits {
+		word |= uint32(1) << bit
+	}
+	binary.BigEndian.PutUint32(buf, word)
+	offset := extensionCifBytes
+	for _, bit := range bits {
+		f := e.Fields[bit]
+		if _, err := f.PackInto(buf[offset:]); err != nil {
+			return err
+		}
+		offset += f.Size()
+	}
+	return nil
+}
+
+func extensionMask(registered map[uint8]ExtensionField) uint32 {
+	var mask uint32
+	for bit := range registered {
+		mask |= uint32(1) << bit
+	}
+	return mask
+}
+
+// unpack reads the body in buf into the fields registered. Slices in Raw
+// refer to buf.
+func (e *ExtensionFields) unpack(buf []byte, registered map[uint8]ExtensionField) {
+	if registered == nil || binary.BigEndian.Uint32(buf)&^extensionMask(registered) != 0 {
+		e.Fields = nil
+		e.Raw = buf
+		return
+	}
+	word := binary.BigEndian.Uint32(buf)
+	e.Fields = map[uint8]CheckedField{}
+	e.Raw = nil
+	offset := extensionCifBytes
+	for bit := 31; bit >= 0; bit-- {
+		if indicatorFieldBool(word, uint32(bit)) {
+			f := registered[uint8(bit)].New()
+			f.Unpack(buf[offset:])
+			offset += f.Size()
+			e.Fields[uint8(bit)] = f
+		}
+	}
+}
+
+// unmarshal is the checked form of unpack. A body enabling a field that is
+// not registered is an error rather than kept in Raw.
+func (e *ExtensionFields) unmarshal(buf []byte, registered map[uint8]ExtensionField) error {
+	if registered == nil {
+		e.Fields = nil
+		e.Raw = buf
+		return nil
+	}
+	if err := checkSize("ExtensionFields", buf, extensionCifBytes); err != nil {
+		return err
+	}
+	if err := checkReserved("ExtensionFields", buf, 0, ^extensionMask(registered)); err != nil {
+		return err
+	}
+	word := binary.BigEndian.Uint32(buf)
+	fields := map[uint8]CheckedField{}
+	offset := extensionCifBytes
+	for bit := 31; bit >= 0; bit-- {
+		if indicatorFieldBool(word, uint32(bit)) {
+			f := registered[uint8(bit)].New()
+			if err := f.UnmarshalBinary(buf[offset:]); err != nil {
+				return err
+			}
+			offset += f.Size()
+			fields[uint8(bit)] = f
+		}
+	}
+	e.Fields = fields
+	e.Raw = nil
+	return nil
+}
+
+// ExtensionContextPacket
+// An Extension Context packet: the prologue of a Context packet followed by
+// the extension fields registered for its ClassID.
+type ExtensionContextPacket struct {
+	Header              ContextHeader
+	StreamID            StreamID
+	ClassID             *ClassID
+	IntegerTimestamp    uint32
+	FractionalTimestamp uint64
+	ExtensionFields
+}
+
+func (p *ExtensionContextPacket) prologueSize() uint32 {
+	size := headerBytes + streamIDBytes
+	if p.ClassID != nil {
+		size += classIdBytes
+	}
+	if p.Header.Tsi != NoneTsi {
+		size += integerTimestampBytes
+	}
+	if p.Header.Tsf != NoneTsf {
+		size += fractionalTimestampBytes
+	}
+	return size
+}
+
+func (p *ExtensionContextPacket) Size() uint32 {
+	return p.prologueSize() + p.ExtensionFields.size()
+}
+
+// Timestamp returns the prologue timestamp described by the header
+func (p *ExtensionContextPacket) Timestamp() Timestamp {
+	return Timestamp{
+		Tsi:        p.Header.Tsi,
+		Tsf:        p.Header.Tsf,
+		Integer:    p.IntegerTimestamp,
+		Fractional: p.FractionalTimestamp,
+	}
+}
+
+// SetTimestamp sets the header Tsi and Tsf and the timestamp words from t
+func (p *ExtensionContextPacket) SetTimestamp(t Timestamp) {
+	p.Header.Tsi = t.Tsi
+	p.Header.Tsf = t.Tsf
+	p.IntegerTimestamp = t.Integer
+	p.FractionalTimestamp = t.Fractional
+}
+
+// Pack sets the PacketType, ClassIdEnable and PacketSize from the packet
+// contents before packing, so the header always describes the packed bytes.
+func (p *ExtensionContextPacket) Pack() []byte {
+	return mustPack(p)
+}
+
+// PackInto packs the packet into buf and returns the number of bytes
+// written. The header is derived as for Pack.
+func (p *ExtensionContextPacket) PackInto(buf []byte) (int, error) {
+	size := p.Size()
+	if err := checkSize("ExtensionContextPacket", buf, size); err != nil {
+		return 0, err
+	}
+	if err := p.ExtensionFields.checkWords(); err != nil {
+		return 0, err
+	}
+	packetSize, err := packetWords("ExtensionContextPacket", size)
+	if err != nil {
+		return 0, err
+	}
+	p.Header.PacketType = ExtensionContext
+	p.Header.ClassIdEnable = p.ClassID != nil
+	p.Header.PacketSize = packetSize
+	p.Header.PackInto(buf[0:])
+	offset := headerBytes
+	p.StreamID.PackInto(buf[offset:])
+	offset += streamIDBytes
+	if p.ClassID != nil {
+		p.ClassID.PackInto(buf[offset:])
+		offset += classIdBytes
+	}
+	if p.Header.Tsi != NoneTsi {
+		binary.BigEndian.PutUint32(buf[offset:], p.IntegerTimestamp)
+		offset += integerTimestampBytes
+	}
+	if p.Header.Tsf != NoneTsf {
+		binary.BigEndian.PutUint64(buf[offset:], p.FractionalTimestamp)
+		offset += fractionalTimestampBytes
+	}
+	if err := p.ExtensionFields.packInto(buf[offset:size]); err != nil {
+		return 0, err
+	}
+	return int(size), nil
+}
+
+// AppendPack appends the packed packet to dst, growing it only when its
+// capacity is exhausted.
+func (p *ExtensionContextPacket) AppendPack(dst []byte) ([]byte, error) {
+	return appendPack(dst, p)
+}
+
+// UnmarshalBinary is the checked form of Unpack. The buffer must hold the
+// PacketSize declared in the header, and the prologue and extension fields
+// must fill it exactly. p is left unchanged when an error is returned.
+func (p *ExtensionContextPacket) UnmarshalBinary(buf []byte) error {
+	var header ContextHeader
+	if err := header.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+	if header.PacketType != ExtensionContext {
+		return &PacketTypeError{Type: "ExtensionContextPacket", PacketType: header.PacketType}
+	}
+	buf, err := checkPacketSize("ExtensionContextPacket", buf, header.PacketSize)
+	if err != nil {
+		return err
+	}
+	size := headerBytes + streamIDBytes
+	if header.ClassIdEnable {
+		size += classIdBytes
+	}
+	if header.Tsi != NoneTsi {
+		size += integerTimestampBytes
+	}
+	if header.Tsf != NoneTsf {
+		size += fractionalTimestampBytes
+	}
+	if size > uint32(len(buf)) {
+		return &SizeMismatchError{Type: "ExtensionContextPacket", Declared: uint32(len(buf)), Actual: size}
+	}
+	if header.ClassIdEnable {
+		var classID ClassID
+		if err := classID.UnmarshalBinary(buf[headerBytes+streamIDBytes:]); err != nil {
+			return err
+		}
+	}
+	unpacked := ExtensionContextPacket{ExtensionFields: ExtensionFields{Extensions: p.Extensions}}
+	offset := unpacked.unpackPrologue(buf)
+	if err := unpacked.ExtensionFields.unmarshal(buf[offset:], unpacked.ExtensionFields.lookup(unpacked.ClassID)); err != nil {
+		return err
+	}
+	if size := offset + unpacked.ExtensionFields.size(); size != uint32(len(buf)) {
+		return &SizeMismatchError{Type: "ExtensionContextPacket", Declared: uint32(len(buf)), Actual: size}
+	}
+	*p = unpacked
+	return nil
+}
+
+// Unpack walks the prologue according to the header bits, then the
+// extension fields registered for the ClassID. The body is kept in Raw up to
+// the PacketSize declared in the header.
+func (p *ExtensionContextPacket) Unpack(buf []byte) {
+	offset := p.unpackPrologue(buf)
+	end := uint32(p.Header.PacketSize) * 4
+	p.ExtensionFields.unpack(buf[offset:end], p.ExtensionFields.lookup(p.ClassID))
+}
+
+func (p *ExtensionContextPacket) unpackPrologue(buf []byte) uint32 {
+	p.Header.Unpack(buf)
+	offset := headerBytes
+	p.StreamID.Unpack(buf[offset:])
+	offset += streamIDBytes
+	if p.Header.ClassIdEnable {
+		p.ClassID = &ClassID{}
+		p.ClassID.Unpack(buf[offset:])
+		offset += classIdBytes
+	} else {
+		p.ClassID = nil
+	}
+	if p.Header.Tsi != NoneTsi {
+		p.IntegerTimestamp = binary.BigEndian.Uint32(buf[offset:])
+		offset += integerTimestampBytes
+	} else {
+		p.IntegerTimestamp = 0
+	}
+	if p.Header.Tsf != NoneTsf {
+		p.FractionalTimestamp = binary.BigEndian.Uint64(buf[offset:])
+		offset += fractionalTimestampBytes
+	} else {
+		p.FractionalTimestamp = 0
+	}
+	return offset
+}
+
+// ExtensionCommandPacket
+// An Extension Command packet carrying the extension fields registered for
+// its ClassID, which the controllee is asked to act upon. Acknowledgements
+// of extension commands are read as RawPacket.
+type ExtensionCommandPacket struct {
+	CommandPrologue
+	CAM ControlCAM
+	ExtensionFields
+}
+
+func (p *ExtensionCommandPacket) Size() uint32 {
+	return p.CommandPrologue.size(&p.CAM.CAM) + p.ExtensionFields.size()
+}
+
+func (p *ExtensionCommandPacket) Pack() []byte {
+	return mustPack(p)
+}
+
+// PackInto packs the packet into buf and returns the number of bytes
+// written. The header and CAM are derived as for Pack.
+func (p *ExtensionCommandPacket) PackInto(buf []byte) (int, error) {
+	p.Header.Acknowledge = false
+	size := p.Size()
+	if err := checkSize("ExtensionCommandPacket", buf, size); err != nil {
+		return 0, err
+	}
+	if err := p.ExtensionFields.checkWords(); err != nil {
+		return 0, err
+	}
+	packetSize, err := packetWords("ExtensionCommandPacket", size)
+	if err != nil {
+		return 0, err
+	}
+	p.Header.PacketSize = packetSize
+	offset := p.CommandPrologue.pack(buf, ExtensionCommand, &p.CAM)
+	if err := p.ExtensionFields.packInto(buf[offset:size]); err != nil {
+		return 0, err
+	}
+	return int(size), nil
+}
+
+// AppendPack appends the packed packet to dst, growing it only when its
+// capacity is exhausted.
+func (p *ExtensionCommandPacket) AppendPack(dst []byte) ([]byte, error) {
+	return appendPack(dst, p)
+}
+
+// UnmarshalBinary is the checked form of Unpack. The packet must be an
+// Extension Command packet without the Acknowledge bit, and the prologue and
+// extension fields must fill the declared PacketSize exactly.
+func (p *ExtensionCommandPacket) UnmarshalBinary(buf []byte) error {
+	buf, offset, err := p.CommandPrologue.unmarshal("ExtensionCommandPacket", ExtensionCommand, buf, &p.CAM)
+	if err != nil {
+		return err
+	}
+	if p.Header.Acknowledge {
+		return &PacketTypeError{Type: "ExtensionCommandPacket", PacketType: p.Header.PacketType}
+	}
+	if err := p.ExtensionFields.unmarshal(buf[offset:], p.ExtensionFields.lookup(p.ClassID)); err != nil {
+		return err
+	}
+	if size := offset + p.ExtensionFields.size(); size != uint32(len(buf)) {
+		return &SizeMismatchError{Type: "ExtensionCommandPacket", Declared: uint32(len(buf)), Actual: size}
+	}
+	return nil
+}
+
+// Unpack walks the prologue, then the extension fields registered for the
+// ClassID. The body is kept in Raw up to the PacketSize declared in the
+// header.
+func (p *ExtensionCommandPacket) Unpack(buf []byte) {
+	offset := p.CommandPrologue.unpack(buf, &p.CAM)
+	end := uint32(p.Header.PacketSize) * 4
+	p.ExtensionFields.unpack(buf[offset:end], p.ExtensionFields.lookup(p.ClassID))
+}
@@ -0,0 +1,273 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testExtensionFields() []ExtensionField {
+	return []ExtensionField{
+		{Name: "Temperature", Bit: 31, New: func() CheckedField { return new(Temperature) }},
+		{Name: "Frequency", Bit: 4, New: func() CheckedField { return new(Frequency) }},
+	}
+}
+
+func TestExtensionRegistry(t *testing.T) {
+	r := ExtensionRegistry{}
+	classID := ClassID{Oui: 0x123456, InformationCode: 1, PacketCode: 2}
+	fields := testExtensionFields()
+	require.NoError(t, r.Register(classID, fields[1]))
+	require.NoError(t, r.Register(classID, fields[0]))
+
+	// Fields are returned in packing order whatever the PadBitCount
+	registered := r.Fields(ClassID{PadBitCount: 3, Oui: 0x123456, InformationCode: 1, PacketCode: 2})
+	require.Len(t, registered, 2)
+	assert.Equal(t, "Temperature", registered[0].Name)
+	assert.Equal(t, "Frequency", registered[1].Name)
+	assert.Empty(t, r.Fields(ClassID{Oui: 0x123456}))
+
+	cases := []struct {
+		name  string
+		field ExtensionField
+	}{
+		{"Bit out of range", ExtensionField{Name: "Wide", Bit: 32, New: fields[0].New}},
+		{"No New function", ExtensionField{Name: "Empty", Bit: 0}},
+		{"Bit already registered", ExtensionField{Name: "Again", Bit: 4, New: fields[0].New}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			valid := ExtensionField{Name: "Valid", Bit: 10, New: fields[0].New}
+			err := r.Register(classID, valid, tc.field)
+			assert.ErrorIs(t, err, ErrRegistration)
+			// A failed registration registers nothing
+			assert.Len(t, r.Fields(classID), 2)
+		})
+	}
+}
+
+func TestExtensionContextPacket(t *testing.T) {
+	classID := ClassID{Oui: 0xFFFFFA, PacketCode: 1}
+	require.NoError(t, RegisterExtension(classID, testExtensionFields()...))
+
+	temperature := Temperature(25.5)
+	frequency := Frequency(20e6)
+	p := ExtensionContextPacket{
+		StreamID: 1,
+		ClassID:  &classID,
+		ExtensionFields: ExtensionFields{
+			Fields: map[uint8]CheckedField{31: &temperature, 4: &frequency},
+		},
+	}
+	packed := p.Pack()
+	assert.Equal(t, []byte{
+		0x58, 0, 0, 8,
+		0, 0, 0, 1,
+		0, 0xFF, 0xFF, 0xFA, 0, 0, 0, 1,
+		0x80, 0, 0, 0x10,
+		0, 0, 0x06, 0x60,
+		0, 0, 0x13, 0x12, 0xD0, 0, 0, 0,
+	}, packed)
+	assert.Equal(t, p.Size(), uint32(len(packed)))
+
+	packet, rest, err := UnmarshalPacket(packed)
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+	require.IsType(t, &ExtensionContextPacket{}, packet)
+	assert.Equal(t, &p, packet)
+	unpacked := packet.(*ExtensionContextPacket)
+	assert.Equal(t, Temperature(25.5), *unpacked.Fields[31].(*Temperature))
+
+	unpacked = &ExtensionContextPacket{}
+	unpacked.Unpack(packed)
+	assert.Equal(t, &p, unpacked)
+
+	// A field that is not registered cannot be walked
+	packed[19] |= 0x01
+	assert.ErrorIs(t, unpacked.UnmarshalBinary(packed), ErrReservedBits)
+	unpacked.Unpack(packed)
+	assert.Nil(t, unpacked.Fields)
+	assert.Equal(t, packed[16:], unpacked.Raw)
+
+	// Without registered fields the body is kept as is
+	other := ClassID{Oui: 0xFFFFFA, PacketCode: 2}
+	raw := ExtensionContextPacket{ClassID: &other, ExtensionFields: ExtensionFields{Raw: []byte{1, 2, 3, 4, 5, 6, 7, 8}}}
+	packed = raw.Pack()
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, packed[16:])
+	unpacked = &ExtensionContextPacket{}
+	require.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, &raw, unpacked)
+}
+
+func TestExtensionContextPacketUnmarshalErrors(t *testing.T) {
+	classID := ClassID{Oui: 0xFFFFFA, PacketCode: 3}
+	require.NoError(t, RegisterExtension(classID, testExtensionFields()...))
+	temperature := Temperature(1)
+	valid := (&ExtensionContextPacket{
+		ClassID:         &classID,
+		ExtensionFields: ExtensionFields{Fields: map[uint8]CheckedField{31: &temperature}},
+	}).Pack()
+
+	cases := []struct {
+		name     string
+		buf      []byte
+		expected error
+	}{
+		{"Context packet", (&ContextPacket{}).Pack(), ErrPacketType},
+		{"Truncated", valid[:len(valid)-4], ErrShortBuffer},
+		{"Field past the packet", append(append([]byte{}, valid[:3]...), 5, 0, 0, 0, 0, 0, 0xFF, 0xFF, 0xFA, 0, 0, 0, 3, 0x80, 0, 0, 0), ErrShortBuffer},
+		{"Trailing words", append(append([]byte{0x58, 0, 0, 7}, valid[4:]...), 0, 0, 0, 0), ErrSizeMismatch},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := ExtensionContextPacket{}
+			require.NoError(t, p.UnmarshalBinary(valid))
+			before := p
+			assert.ErrorIs(t, p.UnmarshalBinary(tc.buf), tc.expected)
+			// A failed unmarshal leaves the packet as it was
+			assert.Equal(t, before, p)
+		})
+	}
+}
+
+func TestExtensionCommandPacket(t *testing.T) {
+	classID := ClassID{Oui: 0xFFFFFA, PacketCode: 4}
+	require.NoError(t, RegisterExtension(classID, testExtensionFields()...))
+	frequency := Frequency(20e6)
+	p := ExtensionCommandPacket{
+		CommandPrologue: CommandPrologue{StreamID: 1, ClassID: &classID, MessageID: 2},
+		CAM:             ControlCAM{CAM: CAM{ActionMode: Execute}},
+		ExtensionFields: ExtensionFields{Fields: map[uint8]CheckedField{4: &frequency}},
+	}
+	packed := p.Pack()
+	assert.Equal(t, []byte{
+		0x78, 0, 0, 9,
+		0, 0, 0, 1,
+		0, 0xFF, 0xFF, 0xFA, 0, 0, 0, 4,
+		0x01, 0, 0, 0,
+		0, 0, 0, 2,
+		0, 0, 0, 0x10,
+		0, 0, 0x13, 0x12, 0xD0, 0, 0, 0,
+	}, packed)
+
+	packet, _, err := UnmarshalPacket(packed)
+	require.NoError(t, err)
+	assert.Equal(t, &p, packet)
+	unpacked := ExtensionCommandPacket{}
+	unpacked.Unpack(packed)
+	assert.Equal(t, p, unpacked)
+
+	// Acknowledgements are not extension command packets
+	packed[0] |= 0x04
+	assert.ErrorIs(t, unpacked.UnmarshalBinary(packed), ErrPacketType)
+	assert.ErrorIs(t, unpacked.UnmarshalBinary((&ControlPacket{}).Pack()), ErrPacketType)
+}
+
+// oddField is a field that is not a whole number of words
+type oddField [6]byte
+
+func (f *oddField) Size() uint32 { return uint32(len(f)) }
+func (f *oddField) Pack() []byte { return f[:] }
+func (f *oddField) Unpack(buf []byte) {
+	copy(f[:], buf)
+}
+func (f *oddField) PackInto(buf []byte) (int, error) {
+	return copy(buf, f[:]), nil
+}
+func (f *oddField) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("oddField", buf, f.Size()); err != nil {
+		return err
+	}
+	f.Unpack(buf)
+	return nil
+}
+
+func TestExtensionFieldsWords(t *testing.T) {
+	cases := []struct {
+		name   string
+		fields ExtensionFields
+	}{
+		{"Raw", ExtensionFields{Raw: []byte{1, 2, 3, 4, 5, 6}}},
+		{"Field", ExtensionFields{Fields: map[uint8]CheckedField{0: &oddField{}}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, p := range []Packet{
+				&ExtensionContextPacket{ExtensionFields: tc.fields},
+				&ExtensionCommandPacket{ExtensionFields: tc.fields},
+			} {
+				buf := make([]byte, p.Size())
+				_, err := p.PackInto(buf)
+				assert.ErrorIs(t, err, ErrSizeMismatch)
+				_, err = p.AppendPack(nil)
+				assert.ErrorIs(t, err, ErrSizeMismatch)
+				assert.Panics(t, func() { p.Pack() })
+			}
+		})
+	}
+}
+
+func TestExtensionFieldsRegistry(t *testing.T) {
+	classID := ClassID{Oui: 0xFFFFFA, PacketCode: 5}
+	r := &ExtensionRegistry{}
+	require.NoError(t, r.Register(classID, testExtensionFields()...))
+	frequency := Frequency(20e6)
+	p := ExtensionContextPacket{
+		ClassID: &classID,
+		ExtensionFields: ExtensionFields{
+			Fields:     map[uint8]CheckedField{4: &frequency},
+			Extensions: r,
+		},
+	}
+	packed := p.Pack()
+
+	// The class is only registered with r, not DefaultExtensions
+	packet, _, err := UnmarshalPacket(packed)
+	require.NoError(t, err)
+	assert.Equal(t, packed[16:], packet.(*ExtensionContextPacket).Raw)
+
+	packet, err = NewGenericDecoder(r).DecodePacket(packed)
+	require.NoError(t, err)
+	assert.Equal(t, &p, packet)
+
+	unpacked := ExtensionContextPacket{ExtensionFields: ExtensionFields{Extensions: r}}
+	require.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, p, unpacked)
+
+	command := ExtensionCommandPacket{
+		CommandPrologue: CommandPrologue{ClassID: &classID},
+		ExtensionFields: ExtensionFields{Extensions: r},
+	}
+	require.NoError(t, command.UnmarshalBinary((&ExtensionCommandPacket{
+		CommandPrologue: CommandPrologue{ClassID: &classID},
+		ExtensionFields: ExtensionFields{Fields: map[uint8]CheckedField{4: &frequency}},
+	}).Pack()))
+	assert.Equal(t, &frequency, command.Fields[4])
+
+	// A packet Registry can fall back to decoding with r
+	registry := NewRegistry()
+	registry.Fallback = NewGenericDecoder(r)
+	packet, _, err = registry.UnmarshalPacket(packed)
+	require.NoError(t, err)
+	assert.Equal(t, &frequency, packet.(*ExtensionContextPacket).Fields[4])
+}
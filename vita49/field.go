@@ -0,0 +1,157 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+// Field is satisfied by every field, indicator word and packet in the
+// package. Size is the number of bytes Pack produces and Unpack consumes.
+// Unpack assumes the buffer is well formed and may panic otherwise.
+type Field interface {
+	Size() uint32
+	Pack() []byte
+	Unpack(buf []byte)
+}
+
+// CheckedField adds the error-returning forms of Pack and Unpack. PackInto
+// packs into a caller-supplied buffer without allocating, and UnmarshalBinary
+// checks the buffer before unpacking it.
+type CheckedField interface {
+	Field
+	PackInto(buf []byte) (int, error)
+	UnmarshalBinary(buf []byte) error
+}
+
+// Packet is satisfied by every packet type. AppendPack appends the packed
+// packet to dst, growing it only when its capacity is exhausted. A packet
+// larger than MaxPacketBytes cannot be packed: PackInto and AppendPack return
+// an error and Pack panics.
+type Packet interface {
+	CheckedField
+	AppendPack(dst []byte) ([]byte, error)
+}
+
+var (
+	// Prologue
+	_ CheckedField = (*Header)(nil)
+	_ CheckedField = (*DataHeader)(nil)
+	_ CheckedField = (*ContextHeader)(nil)
+	_ CheckedField = (*CommandHeader)(nil)
+	_ CheckedField = (*StreamID)(nil)
+	_ CheckedField = (*Timestamp)(nil)
+	_ CheckedField = (*ClassID)(nil)
+	_ CheckedField = (*StateEventIndicators)(nil)
+	_ CheckedField = (*Trailer)(nil)
+
+	// Indicator words
+	_ CheckedField = (*IndicatorField0)(nil)
+	_ CheckedField = (*IndicatorField1)(nil)
+	_ CheckedField = (*IndicatorField2)(nil)
+	_ CheckedField = (*IndicatorField3)(nil)
+	_ CheckedField = (*IndicatorField7)(nil)
+	_ CheckedField = (*Cif0)(nil)
+	_ CheckedField = (*Cif1)(nil)
+	_ CheckedField = (*Cif2)(nil)
+	_ CheckedField = (*Cif3)(nil)
+	_ CheckedField = (*Cif7)(nil)
+	_ CheckedField = (*Cifs)(nil)
+
+	// CIF0 fields
+	_ CheckedField = (*Frequency)(nil)
+	_ CheckedField = (*ReferenceLevel)(nil)
+	_ CheckedField = (*Gain)(nil)
+	_ CheckedField = (*Femtoseconds)(nil)
+	_ CheckedField = (*Temperature)(nil)
+	_ CheckedField = (*DeviceIdentifier)(nil)
+	_ CheckedField = (*Ephemeris)(nil)
+	_ CheckedField = (*Geolocation)(nil)
+	_ CheckedField = (*GpsAscii)(nil)
+	_ CheckedField = (*PayloadFormat)(nil)
+	_ CheckedField = (*ContextAssociationLists)(nil)
+
+	// CIF1 fields
+	_ CheckedField = (*PhaseOffset)(nil)
+	_ CheckedField = (*Polarization)(nil)
+	_ CheckedField = (*PointingVector)(nil)
+	_ CheckedField = (*PointingVectorCIF)(nil)
+	_ CheckedField = (*PointingVectorRecord)(nil)
+	_ CheckedField = (*PointingVectorStructure)(nil)
+	_ CheckedField = (*SpatialScanType)(nil)
+	_ CheckedField = (*SpatialReferenceType)(nil)
+	_ CheckedField = (*BeamWidth)(nil)
+	_ CheckedField = (*Range)(nil)
+	_ CheckedField = (*EbNoBER)(nil)
+	_ CheckedField = (*Threshold)(nil)
+	_ CheckedField = (*CompressionPoint)(nil)
+	_ CheckedField = (*InterceptPoints)(nil)
+	_ CheckedField = (*SNRNoise)(nil)
+	_ CheckedField = (*ArrayOfCifs)(nil)
+	_ CheckedField = (*SpectrumType)(nil)
+	_ CheckedField = (*WindowType)(nil)
+	_ CheckedField = (*SpectrumF1F2Indicies)(nil)
+	_ CheckedField = (*Spectrum)(nil)
+	_ CheckedField = (*SectorStepScanCIF)(nil)
+	_ CheckedField = (*SectorStepScanRecord)(nil)
+	_ CheckedField = (*SectorStepScan)(nil)
+	_ CheckedField = (*IndexList)(nil)
+	_ CheckedField = (*HealthStatus)(nil)
+	_ CheckedField = (*V49SpecCompliance)(nil)
+	_ CheckedField = (*VersionInformation)(nil)
+	_ CheckedField = (*BufferSize)(nil)
+
+	// CIF2 fields
+	_ CheckedField = (*Uuid)(nil)
+	_ CheckedField = (*CountryCode)(nil)
+
+	// CIF3 fields
+	_ CheckedField = (*TimestampDetails)(nil)
+	_ CheckedField = (*Humidity)(nil)
+	_ CheckedField = (*BarometricPressure)(nil)
+	_ CheckedField = (*SeaSwellState)(nil)
+
+	// CIF7 attributes
+	_ CheckedField = (*Belief)(nil)
+	_ CheckedField = (*Probability)(nil)
+
+	// Command fields
+	_ CheckedField = (*CAM)(nil)
+	_ CheckedField = (*ControlCAM)(nil)
+	_ CheckedField = (*AcknowledgeCAM)(nil)
+	_ CheckedField = (*WIF0)(nil)
+	_ CheckedField = (*EIF0)(nil)
+	_ CheckedField = (*WEIF1)(nil)
+	_ CheckedField = (*WEIF2)(nil)
+	_ CheckedField = (*WEIF3)(nil)
+	_ CheckedField = (*WEIF7)(nil)
+	_ CheckedField = (*WarningErrorFields)(nil)
+
+	// Packets
+	_ Packet = (*SignalDataPacket)(nil)
+	_ Packet = (*ContextPacket)(nil)
+	_ Packet = (*ControlPacket)(nil)
+	_ Packet = (*ValidationAckPacket)(nil)
+	_ Packet = (*ExecutionAckPacket)(nil)
+	_ Packet = (*QueryAckPacket)(nil)
+	_ Packet = (*ExtensionContextPacket)(nil)
+	_ Packet = (*ExtensionCommandPacket)(nil)
+	_ Packet = (*RawPacket)(nil)
+
+	// Raw CIF field values
+	_ CheckedField = (*word32)(nil)
+	_ CheckedField = (*word64)(nil)
+)
@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldRoundTrip(t *testing.T) {
+	fields := []CheckedField{
+		&Header{PacketType: Command, Tsf: SampleCount, PacketSize: 3},
+		&ClassID{Oui: 0xABCDEF, InformationCode: 4},
+		&Trailer{StateEventIndicators{SampleLoss: EnableIndicator{Enable: true, Value: true}}},
+		&Cif7{IndicatorField7: IndicatorField7{Belief: true}},
+		&Gain{Stage1: -2},
+		&InterceptPoints{SecondOrder: 3},
+		&SeaSwellState{SwellState: 4},
+		&Probability{ProbabilityPercent: 25},
+		&WEIF1{IndicatorField1{Range: true}},
+		&CAM{PermitErrors: true},
+	}
+
+	for _, field := range fields {
+		name := reflect.TypeOf(field).Elem().Name()
+		t.Run(name, func(t *testing.T) {
+			packed := field.Pack()
+			assert.Equal(t, field.Size(), uint32(len(packed)))
+
+			// Unpack into a zero value of the same type through the
+			// interface alone
+			unpacked := reflect.New(reflect.TypeOf(field).Elem()).Interface().(CheckedField)
+			assert.NoError(t, unpacked.UnmarshalBinary(packed))
+			assert.Equal(t, field, unpacked)
+
+			unpacked = reflect.New(reflect.TypeOf(field).Elem()).Interface().(CheckedField)
+			unpacked.Unpack(packed)
+			assert.Equal(t, field, unpacked)
+		})
+	}
+}
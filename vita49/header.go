@@ -24,6 +24,9 @@ import (
 )
 
 const (
+	// MaxPacketBytes is the largest packet the 16-bit PacketSize describes
+	MaxPacketBytes = uint32(1<<16-1) * 4
+
 	headerBytes = uint32(4)
 )
 
@@ -67,12 +70,22 @@ type Header struct {
 	PacketSize    uint16
 }
 
-func (h Header) Size() uint32 {
+func (h *Header) Size() uint32 {
 	return headerBytes
 }
 
 func (h *Header) Pack() []byte {
 	buf := make([]byte, h.Size())
+	h.PackInto(buf)
+	return buf
+}
+
+// PackInto packs the header into buf without allocating and returns the
+// number of bytes written.
+func (h *Header) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Header", buf, h.Size()); err != nil {
+		return 0, err
+	}
 	var classIdEnableVal uint8
 	if h.ClassIdEnable {
 		classIdEnableVal = 1
@@ -80,7 +93,7 @@ func (h *Header) Pack() []byte {
 	buf[0] = (uint8(h.PacketType) << 4) | (classIdEnableVal << 3)
 	buf[1] = (uint8(h.Tsi) << 6) | (uint8(h.Tsf) << 4) | (h.PacketCount % 16)
 	binary.BigEndian.PutUint16(buf[2:], uint16(h.PacketSize))
-	return buf
+	return int(h.Size()), nil
 }
 
 func (h *Header) Unpack(buf []byte) {
@@ -92,6 +105,14 @@ func (h *Header) Unpack(buf []byte) {
 	h.PacketSize = binary.BigEndian.Uint16(buf[2:])
 }
 
+func (h *Header) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Header", buf, h.Size()); err != nil {
+		return err
+	}
+	h.Unpack(buf)
+	return nil
+}
+
 type DataHeader struct {
 	Header
 	TrailerIncluded bool
@@ -100,7 +121,16 @@ type DataHeader struct {
 }
 
 func (h *DataHeader) Pack() []byte {
-	buf := h.Header.Pack()
+	buf := make([]byte, h.Size())
+	h.PackInto(buf)
+	return buf
+}
+
+func (h *DataHeader) PackInto(buf []byte) (int, error) {
+	n, err := h.Header.PackInto(buf)
+	if err != nil {
+		return 0, err
+	}
 	if h.TrailerIncluded {
 		buf[0] |= (uint8(1) << 2)
 	}
@@ -110,7 +140,7 @@ func (h *DataHeader) Pack() []byte {
 	if h.Spectrum {
 		buf[0] |= uint8(1)
 	}
-	return buf
+	return n, nil
 }
 
 func (h *DataHeader) Unpack(buf []byte) {
@@ -120,6 +150,14 @@ func (h *DataHeader) Unpack(buf []byte) {
 	h.Spectrum = (buf[0] & 0x01) != 0
 }
 
+func (h *DataHeader) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("DataHeader", buf, h.Size()); err != nil {
+		return err
+	}
+	h.Unpack(buf)
+	return nil
+}
+
 type Tsm uint8
 
 const (
@@ -134,12 +172,21 @@ type ContextHeader struct {
 }
 
 func (h *ContextHeader) Pack() []byte {
-	buf := h.Header.Pack()
+	buf := make([]byte, h.Size())
+	h.PackInto(buf)
+	return buf
+}
+
+func (h *ContextHeader) PackInto(buf []byte) (int, error) {
+	n, err := h.Header.PackInto(buf)
+	if err != nil {
+		return 0, err
+	}
 	if h.NotV490 {
 		buf[0] |= (uint8(1) << 1)
 	}
 	buf[0] |= uint8(h.Tsm)
-	return buf
+	return n, nil
 }
 
 func (h *ContextHeader) Unpack(buf []byte) {
@@ -148,6 +195,17 @@ func (h *ContextHeader) Unpack(buf []byte) {
 	h.Tsm = Tsm(buf[0] & 0x01)
 }
 
+func (h *ContextHeader) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("ContextHeader", buf, h.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("ContextHeader", buf, 0, 0x04000000); err != nil {
+		return err
+	}
+	h.Unpack(buf)
+	return nil
+}
+
 type CommandHeader struct {
 	Header
 	Acknowledge  bool
@@ -155,14 +213,23 @@ type CommandHeader struct {
 }
 
 func (h *CommandHeader) Pack() []byte {
-	buf := h.Header.Pack()
+	buf := make([]byte, h.Size())
+	h.PackInto(buf)
+	return buf
+}
+
+func (h *CommandHeader) PackInto(buf []byte) (int, error) {
+	n, err := h.Header.PackInto(buf)
+	if err != nil {
+		return 0, err
+	}
 	if h.Acknowledge {
 		buf[0] |= (uint8(1) << 2)
 	}
 	if h.Cancellation {
 		buf[0] |= uint8(1)
 	}
-	return buf
+	return n, nil
 }
 
 func (h *CommandHeader) Unpack(buf []byte) {
@@ -170,3 +237,14 @@ func (h *CommandHeader) Unpack(buf []byte) {
 	h.Acknowledge = (buf[0] & 0x4) != 0
 	h.Cancellation = (buf[0] & 0x1) != 0
 }
+
+func (h *CommandHeader) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("CommandHeader", buf, h.Size()); err != nil {
+		return err
+	}
+	if err := checkReserved("CommandHeader", buf, 0, 0x02000000); err != nil {
+		return err
+	}
+	h.Unpack(buf)
+	return nil
+}
@@ -268,3 +268,30 @@ func TestHeaderPacketSize(t *testing.T) {
 		})
 	}
 }
+
+func TestHeaderUnmarshalBinary(t *testing.T) {
+	cases := []struct {
+		name     string
+		header   interface{ UnmarshalBinary([]byte) error }
+		buf      []byte
+		expected error
+	}{
+		{"Header", &Header{}, []byte{0x10, 0, 0, 2}, nil},
+		{"Header short", &Header{}, []byte{0x10, 0, 0}, ErrShortBuffer},
+		{"DataHeader", &DataHeader{}, []byte{0x17, 0, 0, 2}, nil},
+		{"ContextHeader reserved", &ContextHeader{}, []byte{0x44, 0, 0, 2}, ErrReservedBits},
+		{"CommandHeader reserved", &CommandHeader{}, []byte{0x62, 0, 0, 2}, ErrReservedBits},
+		{"CommandHeader short", &CommandHeader{}, nil, ErrShortBuffer},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.header.UnmarshalBinary(tc.buf)
+			if tc.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expected)
+			}
+		})
+	}
+}
@@ -130,3 +130,80 @@ func TestRfRefFrequency(t *testing.T) {
 	packAndUnpack(t, &indField, 0, 27)
 	assert.Equal(t, false, indField.RfRefFrequency)
 }
+
+func TestIndicatorField3(t *testing.T) {
+	cases := []struct {
+		name     string
+		field    IndicatorField3
+		expected []byte
+	}{
+		{
+			name:     "Default",
+			field:    IndicatorField3{},
+			expected: []byte{0, 0, 0, 0},
+		},
+		{
+			name:     "TimestampDetails",
+			field:    IndicatorField3{TimestampDetails: true},
+			expected: []byte{0x80, 0, 0, 0},
+		},
+		{
+			name:     "RiseTime ShelfLife",
+			field:    IndicatorField3{RiseTime: true, ShelfLife: true},
+			expected: []byte{0x08, 0x01, 0, 0},
+		},
+		{
+			name:     "AirTemperature NetworkID",
+			field:    IndicatorField3{AirTemperature: true, NetworkID: true},
+			expected: []byte{0, 0, 0, 0x82},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := tc.field
+			// Pack
+			packed := f.Pack()
+			assert.Equal(t, tc.expected, packed)
+			// Unpack
+			unpacked := IndicatorField3{}
+			unpacked.Unpack(packed)
+			assert.Equal(t, tc.field, unpacked)
+		})
+	}
+}
+
+func TestIndicatorField7(t *testing.T) {
+	cases := []struct {
+		name     string
+		field    IndicatorField7
+		expected []byte
+	}{
+		{
+			name:     "Default",
+			field:    IndicatorField7{},
+			expected: []byte{0, 0, 0, 0},
+		},
+		{
+			name:     "CurrentValue",
+			field:    IndicatorField7{CurrentValue: true},
+			expected: []byte{0x80, 0, 0, 0},
+		},
+		{
+			name:     "Accuracy Belief",
+			field:    IndicatorField7{Accuracy: true, Belief: true},
+			expected: []byte{0x01, 0x08, 0, 0},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := tc.field
+			// Pack
+			packed := f.Pack()
+			assert.Equal(t, tc.expected, packed)
+			// Unpack
+			unpacked := IndicatorField7{}
+			unpacked.Unpack(packed)
+			assert.Equal(t, tc.field, unpacked)
+		})
+	}
+}
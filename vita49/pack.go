@@ -0,0 +1,53 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import "slices"
+
+// grow extends dst by size bytes for a PackInto call, reallocating only when
+// the capacity of dst is exhausted. It returns the extended slice and the
+// newly added bytes.
+func grow(dst []byte, size uint32) ([]byte, []byte) {
+	n := len(dst)
+	dst = slices.Grow(dst, int(size))[:n+int(size)]
+	return dst, dst[n:]
+}
+
+// mustPack packs a packet or field into a new buffer. It panics when it
+// cannot be packed, such as when a packet is larger than MaxPacketBytes,
+// rather than return bytes whose header misdescribes them.
+func mustPack(p CheckedField) []byte {
+	buf := make([]byte, p.Size())
+	if _, err := p.PackInto(buf); err != nil {
+		panic(err)
+	}
+	return buf
+}
+
+// appendPack appends the packed packet to dst, growing it only when its
+// capacity is exhausted. dst is returned unchanged when PackInto fails.
+func appendPack(dst []byte, p CheckedField) ([]byte, error) {
+	n := len(dst)
+	dst, buf := grow(dst, p.Size())
+	if _, err := p.PackInto(buf); err != nil {
+		return dst[:n], err
+	}
+	return dst, nil
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackInto(t *testing.T) {
+	cases := []struct {
+		name  string
+		field CheckedField
+	}{
+		{"Header", &Header{PacketType: Context, Tsi: Gps, PacketSize: 7}},
+		{"DataHeader", &DataHeader{TrailerIncluded: true, Spectrum: true}},
+		{"ContextHeader", &ContextHeader{Tsm: Coarse}},
+		{"CommandHeader", &CommandHeader{Acknowledge: true}},
+		{"ClassID", &ClassID{Oui: 0x123456, PacketCode: 3}},
+		{"StateEventIndicators", &StateEventIndicators{OverRange: EnableIndicator{Enable: true}}},
+		{"Gain", &Gain{Stage1: 1.5}},
+		{"DeviceIdentifier", &DeviceIdentifier{ManufacturerOui: 0x123456, DeviceCode: 9}},
+		{"Ephemeris", &Ephemeris{PositionX: 1, VelocityDz: 2}},
+		{"Geolocation", &Geolocation{Latitude: 45, Altitude: 10}},
+		{"GpsAscii", &GpsAscii{NumberOfWords: 2, AsciiSentences: []byte("$GP")}},
+		{"PayloadFormat", &PayloadFormat{ItemPackingFieldSize: 15, DataItemSize: 15, RepeatCount: 2}},
+		{"ContextAssociationLists", &ContextAssociationLists{
+			SourceListSize:     1,
+			AsyncListSize:      1,
+			AsyncTagListEnable: true,
+			SourceList:         []uint32{1},
+			AsyncList:          []uint32{2},
+			AsyncTagList:       []uint32{3},
+		}},
+		{"Polarization", &Polarization{TiltAngle: 1}},
+		{"SpectrumType", &SpectrumType{SpectrumType: 1}},
+		{"Spectrum", &Spectrum{NumberTransformPoints: 4}},
+		{"SectorStepScan", &SectorStepScan{
+			SubfieldCif: SectorStepScanCIF{SectorNumber: true, F1StartFrequency: true},
+			Records:     []SectorStepScanRecord{{SectorNumber: 1}},
+		}},
+		{"PointingVectorStructure", &PointingVectorStructure{
+			SubfieldCif: PointingVectorCIF{Range: true},
+			Records:     []PointingVectorRecord{{Range: 1}},
+		}},
+		{"PointingVectorRecord", &PointingVectorRecord{Range: 1}},
+		{"SectorStepScanRecord", &SectorStepScanRecord{Time4: 1}},
+		{"IndexList", &IndexList{Entries: []uint32{5}}},
+		{"VersionInformation", &VersionInformation{Year: 24}},
+		{"TimestampDetails", &TimestampDetails{Global: true, PosixTimeOffset: 18}},
+		{"Belief", &Belief{BeliefPercent: 50}},
+		{"ControlCAM", &ControlCAM{CAM: CAM{ControlleeEnable: true}, ReqV: true}},
+		{"AcknowledgeCAM", &AcknowledgeCAM{AckX: true, PartialAction: true}},
+		{"WIF0", &WIF0{Wif1Enable: true}},
+		{"WarningErrorFields", &WarningErrorFields{DeviceFailure: true}},
+		{"IndicatorField0", &IndicatorField0{Bandwidth: true}},
+		{"Cifs", &Cifs{Cif0: Cif0{IndicatorField0: IndicatorField0{Bandwidth: true}, Bandwidth: 5}}},
+		{"ContextPacket", &ContextPacket{StreamID: 1}},
+		{"ControlPacket", &ControlPacket{CommandPrologue: CommandPrologue{MessageID: 1}}},
+		{"ValidationAckPacket", &ValidationAckPacket{Errors: map[FieldID]WarningErrorFields{{Cif: 0, Bit: 29}: {DeviceFailure: true}}}},
+		{"QueryAckPacket", &QueryAckPacket{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expected := tc.field.Pack()
+			assert.Equal(t, tc.field.Size(), uint32(len(expected)))
+
+			// Every byte is overwritten when packing into a reused buffer
+			buf := make([]byte, len(expected)+1)
+			for i := range buf {
+				buf[i] = 0xFF
+			}
+			n, err := tc.field.PackInto(buf)
+			assert.NoError(t, err)
+			assert.Equal(t, len(expected), n)
+			assert.Equal(t, expected, buf[:n])
+			assert.Equal(t, byte(0xFF), buf[n])
+
+			_, err = tc.field.PackInto(buf[:len(expected)-1])
+			assert.ErrorIs(t, err, ErrShortBuffer)
+		})
+	}
+}
+
+func TestGrow(t *testing.T) {
+	dst := make([]byte, 2, 8)
+	grown, added := grow(dst, 4)
+	assert.Len(t, grown, 6)
+	assert.Len(t, added, 4)
+	// The existing backing array is reused while it has capacity
+	assert.Equal(t, &dst[:cap(dst)][0], &grown[0])
+
+	grown, added = grow(grown, 4)
+	assert.Len(t, grown, 10)
+	assert.Len(t, added, 4)
+}
+
+func TestPacketTooLarge(t *testing.T) {
+	// Each packet is just over MaxPacketBytes
+	gps := GpsAscii{NumberOfWords: MaxPacketBytes / 4}
+	cifs := Cifs{Cif0: Cif0{IndicatorField0: IndicatorField0{GpsAscii: true}, GpsAscii: gps}}
+	cases := []struct {
+		name   string
+		packet Packet
+	}{
+		{"SignalDataPacket", &SignalDataPacket{Payload: make([]byte, MaxPacketBytes-headerBytes+4)}},
+		{"ContextPacket", &ContextPacket{Cifs: cifs}},
+		{"ControlPacket", &ControlPacket{Cifs: cifs}},
+		{"QueryAckPacket", &QueryAckPacket{Cifs: cifs}},
+		{"ExtensionContextPacket", &ExtensionContextPacket{ExtensionFields: ExtensionFields{Raw: make([]byte, MaxPacketBytes)}}},
+		{"ExtensionCommandPacket", &ExtensionCommandPacket{ExtensionFields: ExtensionFields{Raw: make([]byte, MaxPacketBytes)}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Greater(t, tc.packet.Size(), MaxPacketBytes)
+			buf := make([]byte, tc.packet.Size())
+			n, err := tc.packet.PackInto(buf)
+			assert.ErrorIs(t, err, ErrSizeMismatch)
+			assert.Zero(t, n)
+
+			dst := []byte{0xAA}
+			dst, err = tc.packet.AppendPack(dst)
+			assert.ErrorIs(t, err, ErrSizeMismatch)
+			assert.Equal(t, []byte{0xAA}, dst)
+
+			assert.Panics(t, func() { tc.packet.Pack() })
+		})
+	}
+
+	// A packet of exactly MaxPacketBytes still packs
+	p := &SignalDataPacket{Payload: make([]byte, MaxPacketBytes-headerBytes)}
+	packed, err := p.AppendPack(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0xFFFF), p.Header.PacketSize)
+	assert.NoError(t, (&SignalDataPacket{}).UnmarshalBinary(packed))
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// RawPacket
+// A packet whose PacketType the package has no dedicated type for. Buf holds
+// the whole packet, header included.
+type RawPacket struct {
+	Header Header
+	Buf    []byte
+}
+
+func (p *RawPacket) Size() uint32 {
+	return uint32(len(p.Buf))
+}
+
+func (p *RawPacket) Pack() []byte {
+	buf := make([]byte, p.Size())
+	p.PackInto(buf)
+	return buf
+}
+
+// PackInto copies Buf as is; Header is not packed over it.
+func (p *RawPacket) PackInto(buf []byte) (int, error) {
+	if err := checkSize("RawPacket", buf, p.Size()); err != nil {
+		return 0, err
+	}
+	return copy(buf, p.Buf), nil
+}
+
+func (p *RawPacket) AppendPack(dst []byte) ([]byte, error) {
+	return append(dst, p.Buf...), nil
+}
+
+// Unpack unpacks the header and keeps the PacketSize it declares. Buf refers
+// to buf rather than a copy of it.
+func (p *RawPacket) Unpack(buf []byte) {
+	p.Header.Unpack(buf)
+	p.Buf = buf[:uint32(p.Header.PacketSize)*4]
+}
+
+func (p *RawPacket) UnmarshalBinary(buf []byte) error {
+	var header Header
+	if err := header.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+	if _, err := checkPacketSize("RawPacket", buf, header.PacketSize); err != nil {
+		return err
+	}
+	p.Unpack(buf)
+	return nil
+}
+
+// UnmarshalPacket unpacks the first packet in buf, such as a datagram that
+// may hold several, and returns it along with the bytes that follow it. The
+// packet type is chosen by the header PacketType, and for command packets by
+// the Acknowledge bit and the AckV, AckX and AckS bits of the CAM. Extension
+// context and command packets hold the extension fields registered with
+// DefaultExtensions, and acknowledgements of extension commands are returned
+// as RawPacket. Slices in the packet refer to buf.
+func UnmarshalPacket(buf []byte) (Packet, []byte, error) {
+	header, size, err := splitPacket(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	packet, err := unmarshalPacket(header, buf[:size], nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return packet, buf[size:], nil
+}
+
+// splitPacket checks buf starts with a header and holds the PacketSize it
+// declares, and returns the header along with the packet size in bytes
+func splitPacket(buf []byte) (Header, uint32, error) {
+	var header Header
+	if err := header.UnmarshalBinary(buf); err != nil {
+		return header, 0, err
+	}
+	size := uint32(header.PacketSize) * 4
+	if size < headerBytes {
+		return header, 0, &SizeMismatchError{Type: "Packet", Declared: size, Actual: headerBytes}
+	}
+	if err := checkSize("Packet", buf, size); err != nil {
+		return header, 0, err
+	}
+	return header, size, nil
+}
+
+// unmarshalPacket unpacks the packet buf holds exactly, with the extension
+// fields registered with extensions
+func unmarshalPacket(header Header, buf []byte, extensions *ExtensionRegistry) (Packet, error) {
+	packet, err := newPacket(header, buf, extensions)
+	if err != nil {
+		return nil, err
+	}
+	if err := packet.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+	return packet, nil
+}
+
+// newPacket returns an empty packet of the type buf holds
+func newPacket(header Header, buf []byte, extensions *ExtensionRegistry) (Packet, error) {
+	switch header.PacketType {
+	case SignalData, SignalDataStreamID, ExtensionData, ExtensionDataStreamID:
+		return &SignalDataPacket{}, nil
+	case Context:
+		return &ContextPacket{}, nil
+	case Command:
+		if buf[0]&0x04 == 0 {
+			return &ControlPacket{}, nil
+		}
+		// The CAM follows the prologue
+		offset := headerBytes + streamIDBytes
+		if header.ClassIdEnable {
+			offset += classIdBytes
+		}
+		if header.Tsi != NoneTsi {
+			offset += integerTimestampBytes
+		}
+		if header.Tsf != NoneTsf {
+			offset += fractionalTimestampBytes
+		}
+		if err := checkSize("Packet", buf, offset+camBytes); err != nil {
+			return nil, err
+		}
+		var cam AcknowledgeCAM
+		cam.Unpack(buf[offset:])
+		switch {
+		case cam.AckV:
+			return &ValidationAckPacket{}, nil
+		case cam.AckX:
+			return &ExecutionAckPacket{}, nil
+		case cam.AckS:
+			return &QueryAckPacket{}, nil
+		}
+		return nil, &PacketTypeError{Type: "AcknowledgePacket", PacketType: header.PacketType}
+	case ExtensionContext:
+		return &ExtensionContextPacket{ExtensionFields: ExtensionFields{Extensions: extensions}}, nil
+	case ExtensionCommand:
+		if buf[0]&0x04 == 0 {
+			return &ExtensionCommandPacket{ExtensionFields: ExtensionFields{Extensions: extensions}}, nil
+		}
+		return &RawPacket{}, nil
+	}
+	return nil, &PacketTypeError{Type: "Packet", PacketType: header.PacketType}
+}
+
+// PacketReader
+// Reads packets one at a time from a byte stream, using the header
+// PacketSize to find where each one ends. When Registry is set, packets are
+// decoded by their class with it rather than by UnmarshalPacket.
+type PacketReader struct {
+	Registry *Registry
+
+	r   io.Reader
+	buf []byte
+}
+
+func NewPacketReader(r io.Reader) *PacketReader {
+	return &PacketReader{r: r}
+}
+
+// ReadPacket reads the next packet and unpacks it as UnmarshalPacket does,
+// or as Registry does when it is set. Slices in the packet refer to a buffer
+// that the next call overwrites. It returns io.EOF when the stream ends
+// between packets and io.ErrUnexpectedEOF when it ends within one.
+func (r *PacketReader) ReadPacket() (Packet, error) {
+	if len(r.buf) < int(headerBytes) {
+		r.buf = make([]byte, 4096)
+	}
+	if _, err := io.ReadFull(r.r, r.buf[:headerBytes]); err != nil {
+		return nil, err
+	}
+	size := uint32(binary.BigEndian.Uint16(r.buf[2:])) * 4
+	if size < headerBytes {
+		return nil, &SizeMismatchError{Type: "Packet", Declared: size, Actual: headerBytes}
+	}
+	if uint32(len(r.buf)) < size {
+		r.buf = append(r.buf[:headerBytes], make([]byte, size-headerBytes)...)
+	}
+	if _, err := io.ReadFull(r.r, r.buf[headerBytes:size]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if r.Registry != nil {
+		packet, _, err := r.Registry.UnmarshalPacket(r.buf[:size])
+		return packet, err
+	}
+	packet, _, err := UnmarshalPacket(r.buf[:size])
+	return packet, err
+}
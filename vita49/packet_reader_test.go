@@ -0,0 +1,133 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func packetReaderTestPackets() []Packet {
+	return []Packet{
+		&SignalDataPacket{Header: DataHeader{Header: Header{PacketType: SignalDataStreamID}}, StreamID: 1, Payload: []byte{1, 2, 3, 4}},
+		&SignalDataPacket{Header: DataHeader{Header: Header{PacketType: ExtensionData}}, Payload: []byte{5, 6, 7, 8}},
+		&ContextPacket{StreamID: 2},
+		&ControlPacket{CommandPrologue: CommandPrologue{StreamID: 3, MessageID: 4}},
+		&ValidationAckPacket{CommandPrologue: CommandPrologue{MessageID: 4}, Warnings: map[FieldID]WarningErrorFields{}, Errors: map[FieldID]WarningErrorFields{}},
+		&ExecutionAckPacket{CommandPrologue: CommandPrologue{MessageID: 4}, Warnings: map[FieldID]WarningErrorFields{}, Errors: map[FieldID]WarningErrorFields{}},
+		&QueryAckPacket{CommandPrologue: CommandPrologue{Header: CommandHeader{Header: Header{ClassIdEnable: true}}, ClassID: &ClassID{Oui: 1}, MessageID: 4}},
+		&ExtensionContextPacket{StreamID: 5, ExtensionFields: ExtensionFields{Raw: []byte{1, 2, 3, 4}}},
+		&ExtensionCommandPacket{CommandPrologue: CommandPrologue{StreamID: 6, MessageID: 7}, ExtensionFields: ExtensionFields{Raw: []byte{}}},
+		&RawPacket{Header: Header{PacketType: ExtensionCommand, PacketSize: 4}, Buf: []byte{0x74, 0, 0, 4, 0, 0, 0, 6, 0, 0, 0x80, 0, 0, 0, 0, 7}},
+	}
+}
+
+func TestUnmarshalPacket(t *testing.T) {
+	packets := packetReaderTestPackets()
+	var datagram []byte
+	for _, p := range packets {
+		var err error
+		datagram, err = p.AppendPack(datagram)
+		require.NoError(t, err)
+	}
+	rest := datagram
+	for _, expected := range packets {
+		var packet Packet
+		var err error
+		packet, rest, err = UnmarshalPacket(rest)
+		assert.NoError(t, err)
+		assert.IsType(t, expected, packet)
+		assert.Equal(t, expected, packet)
+	}
+	assert.Empty(t, rest)
+}
+
+func TestUnmarshalPacketErrors(t *testing.T) {
+	control := (&ControlPacket{}).Pack()
+	ack := (&QueryAckPacket{}).Pack()
+	corrupt := func(valid []byte, offset int, value byte) []byte {
+		buf := append([]byte{}, valid...)
+		buf[offset] = value
+		return buf
+	}
+
+	cases := []struct {
+		name     string
+		buf      []byte
+		expected error
+	}{
+		{"Short header", control[:2], ErrShortBuffer},
+		{"Truncated", control[:len(control)-4], ErrShortBuffer},
+		{"Zero packet size", corrupt(control, 3, 0), ErrSizeMismatch},
+		{"Acknowledge without ack bits", corrupt(ack, 9, 0), ErrPacketType},
+		{"Acknowledge without CAM", corrupt(corrupt(ack, 3, 2), 9, 0)[:8], ErrShortBuffer},
+		{"Reserved bits", corrupt(control, 11, 1), ErrReservedBits},
+		{"Reserved packet type", corrupt(control, 0, 0x80), ErrPacketType},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := UnmarshalPacket(tc.buf)
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestPacketReader(t *testing.T) {
+	packets := packetReaderTestPackets()
+	large := &SignalDataPacket{Payload: make([]byte, 10000)}
+	packets = append(packets, large)
+	var stream []byte
+	for _, p := range packets {
+		var err error
+		stream, err = p.AppendPack(stream)
+		require.NoError(t, err)
+	}
+
+	r := NewPacketReader(bytes.NewReader(stream))
+	for _, expected := range packets {
+		packet, err := r.ReadPacket()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, packet)
+	}
+	_, err := r.ReadPacket()
+	assert.Equal(t, io.EOF, err)
+
+	// Truncated within the header and within the packet
+	r = NewPacketReader(bytes.NewReader(stream[:2]))
+	_, err = r.ReadPacket()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	r = NewPacketReader(bytes.NewReader(stream[:6]))
+	_, err = r.ReadPacket()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	// A packet that fails to unpack does not stop the stream
+	bad := (&ControlPacket{}).Pack()
+	bad[11] = 1
+	r = NewPacketReader(bytes.NewReader(append(bad, stream...)))
+	_, err = r.ReadPacket()
+	assert.ErrorIs(t, err, ErrReservedBits)
+	packet, err := r.ReadPacket()
+	assert.NoError(t, err)
+	assert.Equal(t, packets[0], packet)
+}
@@ -0,0 +1,520 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"math"
+)
+
+// Data Item Format codes for PayloadFormat.DataItemFormat
+const (
+	SignedFixedPoint                = 0x00
+	SignedVrtFloat1                 = 0x01
+	SignedVrtFloat2                 = 0x02
+	SignedVrtFloat3                 = 0x03
+	SignedVrtFloat4                 = 0x04
+	SignedVrtFloat5                 = 0x05
+	SignedVrtFloat6                 = 0x06
+	SignedFixedPointNonNormalized   = 0x07
+	IeeeHalf                        = 0x0D
+	IeeeSingle                      = 0x0E
+	IeeeDouble                      = 0x0F
+	UnsignedFixedPoint              = 0x10
+	UnsignedVrtFloat1               = 0x11
+	UnsignedVrtFloat2               = 0x12
+	UnsignedVrtFloat3               = 0x13
+	UnsignedVrtFloat4               = 0x14
+	UnsignedVrtFloat5               = 0x15
+	UnsignedVrtFloat6               = 0x16
+	UnsignedFixedPointNonNormalized = 0x17
+)
+
+// Real/Complex Type codes for PayloadFormat.RealComplexType
+const (
+	Real             = 0
+	ComplexCartesian = 1
+	ComplexPolar     = 2
+)
+
+type dataItemKind uint8
+
+const (
+	fixedItem dataItemKind = iota
+	vrtFloatItem
+	ieeeItem
+)
+
+// PayloadSample
+// The real slice element types a PayloadCodec converts data items to and from
+type PayloadSample interface {
+	~int8 | ~int16 | ~int32 | ~int64 | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~float32 | ~float64
+}
+
+// ComplexPayloadSample
+// The complex slice element types a PayloadCodec converts sample pairs to and
+// from
+type ComplexPayloadSample interface {
+	~complex64 | ~complex128
+}
+
+// PayloadCodec
+// Converts between packed signal data payloads and Go slices according to a
+// PayloadFormat. Each data item sits in the most significant bits of its Item
+// Packing Field, followed by the Event Tag and then the Channel Tag in the
+// least significant bits. Processing-efficient packing never splits a field
+// across a 32-bit word, link-efficient packing packs fields back to back, and
+// both pad the payload out to a whole word with zeros.
+//
+// Fixed-point items are passed through unscaled to integer slices and scaled
+// by DataItemFractionSize for floating-point slices. Complex formats carry
+// two items per sample, I then Q for cartesian and amplitude then phase for
+// polar, which real slices see interleaved.
+type PayloadCodec struct {
+	format    PayloadFormat
+	kind      dataItemKind
+	signed    bool
+	itemBits  uint32
+	fieldBits uint32
+	expBits   uint32
+}
+
+// NewPayloadCodec returns a codec for format, or a PayloadFormatError if the
+// format is reserved or its sizes are inconsistent. As PayloadFormat unpacks
+// an encoded size of one as zero, sizes of zero are treated as one.
+func NewPayloadCodec(format PayloadFormat) (*PayloadCodec, error) {
+	c := &PayloadCodec{
+		format:    format,
+		itemBits:  uint32(max(format.DataItemSize, 1)),
+		fieldBits: uint32(max(format.ItemPackingFieldSize, 1)),
+		signed:    format.DataItemFormat < UnsignedFixedPoint,
+	}
+	if format.RealComplexType > ComplexPolar {
+		return nil, &PayloadFormatError{Format: format, Reason: "reserved real/complex type"}
+	}
+	switch format.DataItemFormat {
+	case SignedFixedPoint, SignedFixedPointNonNormalized, UnsignedFixedPoint, UnsignedFixedPointNonNormalized:
+		c.kind = fixedItem
+	case SignedVrtFloat1, SignedVrtFloat2, SignedVrtFloat3, SignedVrtFloat4, SignedVrtFloat5, SignedVrtFloat6,
+		UnsignedVrtFloat1, UnsignedVrtFloat2, UnsignedVrtFloat3, UnsignedVrtFloat4, UnsignedVrtFloat5, UnsignedVrtFloat6:
+		c.kind = vrtFloatItem
+		c.expBits = uint32(format.DataItemFormat & 0x07)
+		if c.expBits >= c.itemBits {
+			return nil, &PayloadFormatError{Format: format, Reason: "exponent leaves no mantissa bits"}
+		}
+	case IeeeHalf, IeeeSingle, IeeeDouble:
+		c.kind = ieeeItem
+		if c.itemBits != 16<<(format.DataItemFormat-IeeeHalf) {
+			return nil, &PayloadFormatError{Format: format, Reason: "data item size does not match IEEE-754 precision"}
+		}
+	default:
+		return nil, &PayloadFormatError{Format: format, Reason: "reserved data item format"}
+	}
+	if c.itemBits+uint32(format.EventTagSize)+uint32(format.ChannelTagSize) > c.fieldBits {
+		return nil, &PayloadFormatError{Format: format, Reason: "data item and tags exceed item packing field size"}
+	}
+	return c, nil
+}
+
+// Format returns the PayloadFormat the codec was created from
+func (c *PayloadCodec) Format() PayloadFormat {
+	return c.format
+}
+
+// Complex reports whether each sample is a pair of data items
+func (c *PayloadCodec) Complex() bool {
+	return c.format.RealComplexType != Real
+}
+
+// Fields returns the number of Item Packing Fields a payload of size bytes
+// holds. When fields are narrower than a word, zero padding in the last word
+// is indistinguishable from zero-valued items.
+func (c *PayloadCodec) Fields(size int) int {
+	bits := uint64(size/4) * 32
+	switch {
+	case c.format.PackingMethod:
+		return int(bits / uint64(c.fieldBits))
+	case c.fieldBits <= 32:
+		return int(bits / 32 * uint64(32/c.fieldBits))
+	default:
+		return int(bits / c.fieldStride())
+	}
+}
+
+// PayloadSize returns the number of bytes, padded to a whole word, that hold
+// the given number of Item Packing Fields.
+func (c *PayloadCodec) PayloadSize(fields int) uint32 {
+	var words uint64
+	switch {
+	case c.format.PackingMethod:
+		words = (uint64(fields)*uint64(c.fieldBits) + 31) / 32
+	case c.fieldBits <= 32:
+		perWord := uint64(32 / c.fieldBits)
+		words = (uint64(fields) + perWord - 1) / perWord
+	default:
+		words = uint64(fields) * c.fieldStride() / 32
+	}
+	return uint32(words * 4)
+}
+
+// fieldStride is the number of bits a processing-efficient field wider than
+// a word occupies
+func (c *PayloadCodec) fieldStride() uint64 {
+	return uint64((c.fieldBits+31)/32) * 32
+}
+
+// fieldOffset returns the bit offset of the nth Item Packing Field
+func (c *PayloadCodec) fieldOffset(n int) uint64 {
+	switch {
+	case c.format.PackingMethod:
+		return uint64(n) * uint64(c.fieldBits)
+	case c.fieldBits <= 32:
+		perWord := uint64(32 / c.fieldBits)
+		return uint64(n)/perWord*32 + uint64(n)%perWord*uint64(c.fieldBits)
+	default:
+		return uint64(n) * c.fieldStride()
+	}
+}
+
+func (c *PayloadCodec) item(payload []byte, n int) uint64 {
+	return getBits(payload, c.fieldOffset(n), c.itemBits)
+}
+
+func (c *PayloadCodec) putItem(payload []byte, n int, raw uint64) {
+	putBits(payload, c.fieldOffset(n), c.itemBits, raw)
+}
+
+// DecodePayload converts the data items in payload into dst and returns the
+// number of items converted, which is the lesser of len(dst) and the number
+// of fields in payload.
+func DecodePayload[T PayloadSample](c *PayloadCodec, payload []byte, dst []T) (int, error) {
+	n := min(len(dst), c.Fields(len(payload)))
+	conv := newSampleConverter[T](c)
+	for i := 0; i < n; i++ {
+		dst[i] = conv.decode(c.item(payload, i))
+	}
+	return n, nil
+}
+
+// EncodePayload packs src into dst as data items and returns the number of
+// bytes written. Values outside the range of the data item saturate, and
+// tag and padding bits are zeroed.
+func EncodePayload[T PayloadSample](c *PayloadCodec, dst []byte, src []T) (int, error) {
+	size := c.PayloadSize(len(src))
+	if err := checkSize("Payload", dst, size); err != nil {
+		return 0, err
+	}
+	clear(dst[:size])
+	conv := newSampleConverter[T](c)
+	for i, v := range src {
+		c.putItem(dst, i, conv.encode(v))
+	}
+	return int(size), nil
+}
+
+// sampleConverter selects how values of T convert to and from data items.
+// Integer slices take fixed-point items unscaled.
+type sampleConverter[T PayloadSample] struct {
+	codec   *PayloadCodec
+	integer bool
+	signed  bool
+}
+
+func newSampleConverter[T PayloadSample](c *PayloadCodec) sampleConverter[T] {
+	half := T(1)
+	half /= 2
+	negative := T(0)
+	negative--
+	return sampleConverter[T]{codec: c, integer: half == 0, signed: negative < 0}
+}
+
+func (s sampleConverter[T]) decode(raw uint64) T {
+	c := s.codec
+	switch {
+	case !s.integer || c.kind != fixedItem:
+		return T(c.itemFloat(raw))
+	case c.signed:
+		return T(signExtend(raw, c.itemBits))
+	default:
+		return T(raw)
+	}
+}
+
+func (s sampleConverter[T]) encode(v T) uint64 {
+	c := s.codec
+	switch {
+	case !s.integer || c.kind != fixedItem:
+		return c.floatItem(float64(v))
+	case s.signed:
+		return c.fixedFromInt(int64(v))
+	default:
+		return c.fixedFromUint(uint64(v))
+	}
+}
+
+// DecodeComplexPayload converts pairs of data items in payload into dst and
+// returns the number of samples converted. The format must be complex.
+func DecodeComplexPayload[T ComplexPayloadSample](c *PayloadCodec, payload []byte, dst []T) (int, error) {
+	if !c.Complex() {
+		return 0, &PayloadFormatError{Format: c.format, Reason: "real payload decoded as complex"}
+	}
+	n := min(len(dst), c.Fields(len(payload))/2)
+	for i := 0; i < n; i++ {
+		re := c.itemFloat(c.item(payload, 2*i))
+		im := c.itemFloat(c.item(payload, 2*i+1))
+		dst[i] = T(complex(re, im))
+	}
+	return n, nil
+}
+
+// EncodeComplexPayload packs src into dst as pairs of data items and returns
+// the number of bytes written. The format must be complex.
+func EncodeComplexPayload[T ComplexPayloadSample](c *PayloadCodec, dst []byte, src []T) (int, error) {
+	if !c.Complex() {
+		return 0, &PayloadFormatError{Format: c.format, Reason: "complex samples encoded as real payload"}
+	}
+	size := c.PayloadSize(2 * len(src))
+	if err := checkSize("Payload", dst, size); err != nil {
+		return 0, err
+	}
+	clear(dst[:size])
+	for i, v := range src {
+		v := complex128(v)
+		c.putItem(dst, 2*i, c.floatItem(real(v)))
+		c.putItem(dst, 2*i+1, c.floatItem(imag(v)))
+	}
+	return int(size), nil
+}
+
+// itemFloat converts a raw data item to its floating-point value
+func (c *PayloadCodec) itemFloat(raw uint64) float64 {
+	switch c.kind {
+	case fixedItem:
+		if c.signed {
+			return math.Ldexp(float64(signExtend(raw, c.itemBits)), -int(c.format.DataItemFractionSize))
+		}
+		return math.Ldexp(float64(raw), -int(c.format.DataItemFractionSize))
+	case vrtFloatItem:
+		// The mantissa is a normalized fraction in the most significant bits
+		// and the unsigned exponent scales it up by powers of two
+		mantissaBits := c.itemBits - c.expBits
+		exponent := int(raw & (uint64(1)<<c.expBits - 1))
+		if c.signed {
+			return math.Ldexp(float64(signExtend(raw>>c.expBits, mantissaBits)), exponent-int(mantissaBits-1))
+		}
+		return math.Ldexp(float64(raw>>c.expBits), exponent-int(mantissaBits))
+	default:
+		switch c.itemBits {
+		case 16:
+			return float16Value(uint16(raw))
+		case 32:
+			return float64(math.Float32frombits(uint32(raw)))
+		default:
+			return math.Float64frombits(raw)
+		}
+	}
+}
+
+// floatItem converts a floating-point value to a raw data item
+func (c *PayloadCodec) floatItem(v float64) uint64 {
+	switch c.kind {
+	case fixedItem:
+		v = math.Round(math.Ldexp(v, int(c.format.DataItemFractionSize)))
+		return fixedRange{bits: c.itemBits, signed: c.signed}.fromFloat(v)
+	case vrtFloatItem:
+		return c.vrtFloatItem(v)
+	default:
+		switch c.itemBits {
+		case 16:
+			return uint64(float16Bits(v))
+		case 32:
+			return uint64(math.Float32bits(float32(v)))
+		default:
+			return math.Float64bits(v)
+		}
+	}
+}
+
+// vrtFloatItem picks the smallest exponent that holds v, which keeps the
+// most mantissa precision, and saturates at the largest exponent.
+func (c *PayloadCodec) vrtFloatItem(v float64) uint64 {
+	if math.IsNaN(v) || (!c.signed && v <= 0) {
+		return 0
+	}
+	mantissaBits := c.itemBits - c.expBits
+	fraction := int(mantissaBits)
+	if c.signed {
+		fraction--
+	}
+	mantissa := fixedRange{bits: mantissaBits, signed: c.signed}
+	maxExponent := int(uint32(1)<<c.expBits - 1)
+	var raw uint64
+	for exponent := 0; exponent <= maxExponent; exponent++ {
+		m := math.Round(math.Ldexp(v, fraction-exponent))
+		raw = mantissa.fromFloat(m)<<c.expBits | uint64(exponent)
+		if mantissa.holds(m) {
+			break
+		}
+	}
+	return raw & (uint64(1)<<c.itemBits - 1)
+}
+
+// fixedRange describes the integers a field of a given width holds
+type fixedRange struct {
+	bits   uint32
+	signed bool
+}
+
+func (r fixedRange) holds(v float64) bool {
+	if r.signed {
+		limit := math.Ldexp(1, int(r.bits-1))
+		return v >= -limit && v < limit
+	}
+	return v >= 0 && v < math.Ldexp(1, int(r.bits))
+}
+
+// fromFloat saturates an integral v to the range and returns its bits
+func (r fixedRange) fromFloat(v float64) uint64 {
+	mask := uint64(math.MaxUint64) >> (64 - r.bits)
+	if r.signed {
+		limit := math.Ldexp(1, int(r.bits-1))
+		switch {
+		case math.IsNaN(v):
+			return 0
+		case v >= limit:
+			return mask >> 1
+		case v < -limit:
+			return (mask >> 1) + 1
+		}
+		return uint64(int64(v)) & mask
+	}
+	switch {
+	case math.IsNaN(v) || v <= 0:
+		return 0
+	case v >= math.Ldexp(1, int(r.bits)):
+		return mask
+	}
+	return uint64(v)
+}
+
+// fixedFromInt saturates v to the data item range and returns its bits
+func (c *PayloadCodec) fixedFromInt(v int64) uint64 {
+	mask := uint64(math.MaxUint64) >> (64 - c.itemBits)
+	if !c.signed {
+		if v < 0 {
+			return 0
+		}
+		return c.fixedFromUint(uint64(v))
+	}
+	maxValue := int64(mask >> 1)
+	switch {
+	case v > maxValue:
+		v = maxValue
+	case v < -maxValue-1:
+		v = -maxValue - 1
+	}
+	return uint64(v) & mask
+}
+
+// fixedFromUint saturates v to the data item range and returns its bits
+func (c *PayloadCodec) fixedFromUint(v uint64) uint64 {
+	mask := uint64(math.MaxUint64) >> (64 - c.itemBits)
+	if c.signed {
+		mask >>= 1
+	}
+	return min(v, mask)
+}
+
+func signExtend(raw uint64, bits uint32) int64 {
+	shift := 64 - bits
+	return int64(raw<<shift) >> shift
+}
+
+// getBits reads n bits starting at bit offset off, most significant first
+func getBits(buf []byte, off uint64, n uint32) uint64 {
+	var v uint64
+	for n > 0 {
+		bit := uint32(off % 8)
+		take := min(8-bit, n)
+		v = v<<take | uint64(buf[off/8]>>(8-bit-take))&(uint64(1)<<take-1)
+		off += uint64(take)
+		n -= take
+	}
+	return v
+}
+
+// putBits ORs the low n bits of v into buf starting at bit offset off, most
+// significant first
+func putBits(buf []byte, off uint64, n uint32, v uint64) {
+	for n > 0 {
+		bit := uint32(off % 8)
+		take := min(8-bit, n)
+		n -= take
+		buf[off/8] |= byte(v>>n) & (byte(1)<<take - 1) << (8 - bit - take)
+		off += uint64(take)
+	}
+}
+
+// float16Value decodes an IEEE-754 half precision value
+func float16Value(h uint16) float64 {
+	exponent := int(h>>10) & 0x1F
+	fraction := float64(h & 0x3FF)
+	var v float64
+	switch exponent {
+	case 0:
+		v = math.Ldexp(fraction, -24)
+	case 0x1F:
+		if fraction != 0 {
+			return math.NaN()
+		}
+		v = math.Inf(1)
+	default:
+		v = math.Ldexp(1024+fraction, exponent-25)
+	}
+	if h&0x8000 != 0 {
+		v = -v
+	}
+	return v
+}
+
+// float16Bits encodes v as IEEE-754 half precision, rounding to nearest even
+func float16Bits(v float64) uint16 {
+	var sign uint16
+	if math.Signbit(v) {
+		sign = 0x8000
+		v = -v
+	}
+	switch {
+	case math.IsNaN(v):
+		return 0x7E00
+	case v >= 65520:
+		return sign | 0x7C00
+	case v < math.Ldexp(1, -14):
+		// Subnormal, where rounding up to 0x400 gives the smallest normal
+		return sign | uint16(math.RoundToEven(math.Ldexp(v, 24)))
+	}
+	fraction, exponent := math.Frexp(v)
+	mantissa := uint16(math.RoundToEven(math.Ldexp(fraction, 11)))
+	biased := uint16(exponent + 14)
+	if mantissa == 2048 {
+		mantissa = 1024
+		biased++
+	}
+	return sign | biased<<10 | (mantissa - 1024)
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+// PayloadTags
+// The Event Tag and Channel Tag that share an Item Packing Field with a data
+// item. Tags are masked to the EventTagSize and ChannelTagSize of the format.
+type PayloadTags struct {
+	Event   uint8
+	Channel uint16
+}
+
+func (c *PayloadCodec) tagBits() uint32 {
+	return uint32(c.format.EventTagSize) + uint32(c.format.ChannelTagSize)
+}
+
+// tags reads the tags of the nth Item Packing Field, which sit in its least
+// significant bits with the Channel Tag last
+func (c *PayloadCodec) tags(payload []byte, n int) PayloadTags {
+	bits := c.tagBits()
+	if bits == 0 {
+		return PayloadTags{}
+	}
+	v := getBits(payload, c.fieldOffset(n)+uint64(c.fieldBits-bits), bits)
+	return PayloadTags{
+		Event:   uint8(v >> c.format.ChannelTagSize),
+		Channel: uint16(v & (uint64(1)<<c.format.ChannelTagSize - 1)),
+	}
+}
+
+func (c *PayloadCodec) putTags(payload []byte, n int, tags PayloadTags) {
+	bits := c.tagBits()
+	if bits == 0 {
+		return
+	}
+	v := uint64(tags.Event)<<c.format.ChannelTagSize | uint64(tags.Channel)&(uint64(1)<<c.format.ChannelTagSize-1)
+	putBits(payload, c.fieldOffset(n)+uint64(c.fieldBits-bits), bits, v)
+}
+
+// DecodeTaggedPayload converts the data items in payload into dst and their
+// tags into tags, and returns the number of items converted, which is the
+// least of len(dst), len(tags) and the number of fields in payload.
+func DecodeTaggedPayload[T PayloadSample](c *PayloadCodec, payload []byte, dst []T, tags []PayloadTags) (int, error) {
+	n := min(len(dst), len(tags), c.Fields(len(payload)))
+	conv := newSampleConverter[T](c)
+	for i := 0; i < n; i++ {
+		dst[i] = conv.decode(c.item(payload, i))
+		tags[i] = c.tags(payload, i)
+	}
+	return n, nil
+}
+
+// EncodeTaggedPayload packs src and tags into dst and returns the number of
+// bytes written. There must be one set of tags per item.
+func EncodeTaggedPayload[T PayloadSample](c *PayloadCodec, dst []byte, src []T, tags []PayloadTags) (int, error) {
+	if len(tags) != len(src) {
+		return 0, &ListCountError{Type: "Payload", List: "tags", Count: uint32(len(tags)), Expected: uint32(len(src))}
+	}
+	n, err := EncodePayload(c, dst, src)
+	if err != nil {
+		return 0, err
+	}
+	for i, t := range tags {
+		c.putTags(dst, i, t)
+	}
+	return n, nil
+}
+
+// DecodeTaggedComplexPayload converts pairs of data items in payload into
+// dst and returns the number of samples converted. Each sample takes the
+// tags of its first item.
+func DecodeTaggedComplexPayload[T ComplexPayloadSample](c *PayloadCodec, payload []byte, dst []T, tags []PayloadTags) (int, error) {
+	n, err := DecodeComplexPayload(c, payload, dst[:min(len(dst), len(tags))])
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < n; i++ {
+		tags[i] = c.tags(payload, 2*i)
+	}
+	return n, nil
+}
+
+// EncodeTaggedComplexPayload packs src into dst as pairs of data items and
+// returns the number of bytes written. Both items of a sample carry its tags.
+func EncodeTaggedComplexPayload[T ComplexPayloadSample](c *PayloadCodec, dst []byte, src []T, tags []PayloadTags) (int, error) {
+	if len(tags) != len(src) {
+		return 0, &ListCountError{Type: "Payload", List: "tags", Count: uint32(len(tags)), Expected: uint32(len(src))}
+	}
+	n, err := EncodeComplexPayload(c, dst, src)
+	if err != nil {
+		return 0, err
+	}
+	for i, t := range tags {
+		c.putTags(dst, 2*i, t)
+		c.putTags(dst, 2*i+1, t)
+	}
+	return n, nil
+}
+
+// DemuxPayload appends the first count data items in payload to the slice
+// in channels keyed by their Channel Tag, and returns the number of items
+// demultiplexed, which is the lesser of count and the number of fields in
+// payload. count comes from the caller because zero padding in the last word
+// is indistinguishable from items on channel 0. Slices in channels are
+// reused, so a receiver can truncate them to zero length between packets
+// rather than allocating new ones. channels must not be nil.
+func DemuxPayload[T PayloadSample](c *PayloadCodec, payload []byte, count int, channels map[uint16][]T) (int, error) {
+	n := min(count, c.Fields(len(payload)))
+	conv := newSampleConverter[T](c)
+	for i := 0; i < n; i++ {
+		channel := c.tags(payload, i).Channel
+		channels[channel] = append(channels[channel], conv.decode(c.item(payload, i)))
+	}
+	return n, nil
+}
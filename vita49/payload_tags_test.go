@@ -0,0 +1,166 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaggedPayload(t *testing.T) {
+	cases := []struct {
+		name     string
+		format   PayloadFormat
+		samples  []int8
+		tags     []PayloadTags
+		expected []byte
+	}{
+		{
+			name:     "Event and channel tags",
+			format:   PayloadFormat{DataItemFormat: SignedFixedPoint, EventTagSize: 2, ChannelTagSize: 4, ItemPackingFieldSize: 16, DataItemSize: 8},
+			samples:  []int8{0x12, -1},
+			tags:     []PayloadTags{{Event: 3, Channel: 5}, {Event: 1, Channel: 15}},
+			expected: []byte{0x12, 0x35, 0xFF, 0x1F},
+		},
+		{
+			name:     "Channel tags link-efficient",
+			format:   PayloadFormat{PackingMethod: true, DataItemFormat: UnsignedFixedPoint, ChannelTagSize: 4, ItemPackingFieldSize: 12, DataItemSize: 8},
+			samples:  []int8{0x2B, 0x4D},
+			tags:     []PayloadTags{{Channel: 1}, {Channel: 2}},
+			expected: []byte{0x2B, 0x14, 0xD2, 0},
+		},
+		{
+			name:     "Event tags only",
+			format:   PayloadFormat{DataItemFormat: UnsignedFixedPoint, EventTagSize: 7, ItemPackingFieldSize: 16, DataItemSize: 8},
+			samples:  []int8{1, 2},
+			tags:     []PayloadTags{{Event: 0x7F}, {Event: 0x40}},
+			expected: []byte{1, 0x7F, 2, 0x40},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewPayloadCodec(tc.format)
+			assert.NoError(t, err)
+			// Encode
+			buf := make([]byte, len(tc.expected))
+			n, err := EncodeTaggedPayload(c, buf, tc.samples, tc.tags)
+			assert.NoError(t, err)
+			assert.Equal(t, len(tc.expected), n)
+			assert.Equal(t, tc.expected, buf)
+			// Decode
+			samples := make([]int8, len(tc.samples))
+			tags := make([]PayloadTags, len(tc.tags))
+			n, err = DecodeTaggedPayload(c, buf, samples, tags)
+			assert.NoError(t, err)
+			assert.Equal(t, len(tc.samples), n)
+			assert.Equal(t, tc.samples, samples)
+			assert.Equal(t, tc.tags, tags)
+			// Untagged decode ignores the tags
+			n, err = DecodePayload(c, buf, samples)
+			assert.NoError(t, err)
+			assert.Equal(t, len(tc.samples), n)
+			assert.Equal(t, tc.samples, samples)
+		})
+	}
+}
+
+func TestTaggedPayloadMasking(t *testing.T) {
+	c, err := NewPayloadCodec(PayloadFormat{DataItemFormat: SignedFixedPoint, EventTagSize: 1, ChannelTagSize: 2, ItemPackingFieldSize: 16, DataItemSize: 8})
+	assert.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = EncodeTaggedPayload(c, buf, []int16{1}, []PayloadTags{{Event: 0xFF, Channel: 0xFFFF}})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 0x07, 0, 0}, buf)
+	_, err = EncodeTaggedPayload(c, buf, []int16{1, 2}, []PayloadTags{{}})
+	assert.ErrorIs(t, err, ErrListCount)
+	_, err = EncodeTaggedPayload(c, buf[:2], []int16{1, 2, 3}, make([]PayloadTags, 3))
+	assert.ErrorIs(t, err, ErrShortBuffer)
+}
+
+func TestTaggedComplexPayload(t *testing.T) {
+	c, err := NewPayloadCodec(PayloadFormat{
+		RealComplexType:      ComplexCartesian,
+		DataItemFormat:       SignedFixedPoint,
+		ChannelTagSize:       4,
+		ItemPackingFieldSize: 16,
+		DataItemSize:         12,
+	})
+	assert.NoError(t, err)
+	samples := []complex64{complex(1, -1), complex(2, 3)}
+	tags := []PayloadTags{{Channel: 1}, {Channel: 2}}
+	buf := make([]byte, 8)
+	_, err = EncodeTaggedComplexPayload(c, buf, samples, tags)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0x11, 0xFF, 0xF1, 0, 0x22, 0, 0x32}, buf)
+
+	decoded := make([]complex64, 2)
+	decodedTags := make([]PayloadTags, 1)
+	n, err := DecodeTaggedComplexPayload(c, buf, decoded, decodedTags)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, tags[:1], decodedTags)
+	decodedTags = make([]PayloadTags, 2)
+	n, err = DecodeTaggedComplexPayload(c, buf, decoded, decodedTags)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, samples, decoded)
+	assert.Equal(t, tags, decodedTags)
+
+	_, err = EncodeTaggedComplexPayload(c, buf, samples, tags[:1])
+	assert.ErrorIs(t, err, ErrListCount)
+}
+
+func TestDemuxPayload(t *testing.T) {
+	c, err := NewPayloadCodec(PayloadFormat{DataItemFormat: SignedFixedPoint, ChannelTagSize: 2, ItemPackingFieldSize: 16, DataItemSize: 14})
+	assert.NoError(t, err)
+	samples := []int16{10, 20, 11, 21, 12, 22}
+	tags := []PayloadTags{{Channel: 1}, {Channel: 2}, {Channel: 1}, {Channel: 2}, {Channel: 1}, {Channel: 2}}
+	buf := make([]byte, c.PayloadSize(len(samples)))
+	_, err = EncodeTaggedPayload(c, buf, samples, tags)
+	assert.NoError(t, err)
+
+	channels := map[uint16][]int16{1: make([]int16, 0, 3)}
+	n, err := DemuxPayload(c, buf, len(samples), channels)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, n)
+	assert.Equal(t, map[uint16][]int16{1: {10, 11, 12}, 2: {20, 21, 22}}, channels)
+
+	// Reusing the slices appends after truncation
+	for channel := range channels {
+		channels[channel] = channels[channel][:0]
+	}
+	n, err = DemuxPayload(c, buf[:4], len(samples), channels)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, map[uint16][]int16{1: {10}, 2: {20}}, channels)
+
+	// The padding after an odd number of items is not demultiplexed as an
+	// item on channel 0
+	buf = make([]byte, c.PayloadSize(5))
+	_, err = EncodeTaggedPayload(c, buf, samples[:5], tags[:5])
+	assert.NoError(t, err)
+	assert.Equal(t, 6, c.Fields(len(buf)))
+	channels = map[uint16][]int16{}
+	n, err = DemuxPayload(c, buf, 5, channels)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, map[uint16][]int16{1: {10, 11, 12}, 2: {20, 21}}, channels)
+}
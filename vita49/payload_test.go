@@ -0,0 +1,320 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadCodecFixedPoint(t *testing.T) {
+	cases := []struct {
+		name     string
+		format   PayloadFormat
+		samples  []int32
+		expected []byte
+	}{
+		{
+			name:     "Signed 16-bit",
+			format:   PayloadFormat{DataItemFormat: SignedFixedPoint, ItemPackingFieldSize: 16, DataItemSize: 16},
+			samples:  []int32{1, -2, 3, 4},
+			expected: []byte{0, 1, 0xFF, 0xFE, 0, 3, 0, 4},
+		},
+		{
+			name:     "Unsigned 12-bit processing-efficient",
+			format:   PayloadFormat{DataItemFormat: UnsignedFixedPoint, ItemPackingFieldSize: 12, DataItemSize: 12},
+			samples:  []int32{0x123, 0x456, 0x789, 0xABC},
+			expected: []byte{0x12, 0x34, 0x56, 0, 0x78, 0x9A, 0xBC, 0},
+		},
+		{
+			name:     "Unsigned 12-bit link-efficient",
+			format:   PayloadFormat{PackingMethod: true, DataItemFormat: UnsignedFixedPoint, ItemPackingFieldSize: 12, DataItemSize: 12},
+			samples:  []int32{0x123, 0x456, 0x789},
+			expected: []byte{0x12, 0x34, 0x56, 0x78, 0x90, 0, 0, 0},
+		},
+		{
+			name:     "Item in most significant bits of field",
+			format:   PayloadFormat{DataItemFormat: SignedFixedPoint, EventTagSize: 2, ChannelTagSize: 4, ItemPackingFieldSize: 16, DataItemSize: 8},
+			samples:  []int32{-1, 0x7F},
+			expected: []byte{0xFF, 0, 0x7F, 0},
+		},
+		{
+			name:     "Signed 24-bit processing-efficient",
+			format:   PayloadFormat{DataItemFormat: SignedFixedPointNonNormalized, ItemPackingFieldSize: 24, DataItemSize: 24},
+			samples:  []int32{-1, 0x123456},
+			expected: []byte{0xFF, 0xFF, 0xFF, 0, 0x12, 0x34, 0x56, 0},
+		},
+		{
+			name:     "Signed 40-bit processing-efficient",
+			format:   PayloadFormat{DataItemFormat: SignedFixedPoint, ItemPackingFieldSize: 40, DataItemSize: 40},
+			samples:  []int32{-2},
+			expected: []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFE, 0, 0, 0},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewPayloadCodec(tc.format)
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(len(tc.expected)), c.PayloadSize(len(tc.samples)))
+			// Encode
+			buf := make([]byte, len(tc.expected))
+			for i := range buf {
+				buf[i] = 0xAA
+			}
+			n, err := EncodePayload(c, buf, tc.samples)
+			assert.NoError(t, err)
+			assert.Equal(t, len(tc.expected), n)
+			assert.Equal(t, tc.expected, buf)
+			// Decode
+			assert.GreaterOrEqual(t, c.Fields(len(buf)), len(tc.samples))
+			decoded := make([]int32, len(tc.samples))
+			n, err = DecodePayload(c, buf, decoded)
+			assert.NoError(t, err)
+			assert.Equal(t, len(tc.samples), n)
+			assert.Equal(t, tc.samples, decoded)
+		})
+	}
+}
+
+func TestPayloadCodecFields(t *testing.T) {
+	cases := []struct {
+		name      string
+		format    PayloadFormat
+		fields    int
+		size      uint32
+		available int
+	}{
+		{"Processing-efficient 12-bit", PayloadFormat{ItemPackingFieldSize: 12, DataItemSize: 12}, 5, 12, 6},
+		{"Link-efficient 12-bit", PayloadFormat{PackingMethod: true, ItemPackingFieldSize: 12, DataItemSize: 12}, 5, 8, 5},
+		{"Processing-efficient 40-bit", PayloadFormat{ItemPackingFieldSize: 40, DataItemSize: 40}, 3, 24, 3},
+		{"Link-efficient 40-bit", PayloadFormat{PackingMethod: true, ItemPackingFieldSize: 40, DataItemSize: 40}, 3, 16, 3},
+		{"Single bit", PayloadFormat{DataItemFormat: UnsignedFixedPoint}, 33, 8, 64},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewPayloadCodec(tc.format)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.size, c.PayloadSize(tc.fields))
+			assert.Equal(t, tc.available, c.Fields(int(tc.size)))
+		})
+	}
+}
+
+func TestPayloadCodecScaling(t *testing.T) {
+	c, err := NewPayloadCodec(PayloadFormat{DataItemFormat: SignedFixedPoint, DataItemFractionSize: 8, ItemPackingFieldSize: 16, DataItemSize: 16})
+	assert.NoError(t, err)
+	buf := make([]byte, 8)
+	_, err = EncodePayload(c, buf, []float32{1.5, -0.25, 200, float32(math.NaN())})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x80, 0xFF, 0xC0, 0x7F, 0xFF, 0, 0}, buf)
+	decoded := make([]float64, 4)
+	_, err = DecodePayload(c, buf, decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1.5, -0.25, 32767.0 / 256, 0}, decoded)
+	// Integer slices see the raw item
+	raw := make([]int16, 2)
+	_, err = DecodePayload(c, buf, raw)
+	assert.NoError(t, err)
+	assert.Equal(t, []int16{0x180, -0x40}, raw)
+}
+
+func TestPayloadCodecSaturation(t *testing.T) {
+	signed, err := NewPayloadCodec(PayloadFormat{DataItemFormat: SignedFixedPoint, ItemPackingFieldSize: 16, DataItemSize: 16})
+	assert.NoError(t, err)
+	buf := make([]byte, 8)
+	_, err = EncodePayload(signed, buf, []int32{40000, -40000})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x7F, 0xFF, 0x80, 0, 0, 0, 0, 0}, buf)
+	_, err = EncodePayload(signed, buf, []uint64{math.MaxUint64})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x7F, 0xFF, 0, 0}, buf[:4])
+
+	unsigned, err := NewPayloadCodec(PayloadFormat{DataItemFormat: UnsignedFixedPoint, ItemPackingFieldSize: 8, DataItemSize: 8})
+	assert.NoError(t, err)
+	_, err = EncodePayload(unsigned, buf, []int16{-5, 300, 7})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0xFF, 7, 0}, buf[:4])
+	_, err = EncodePayload(unsigned, buf, []float64{-5, 300, 7.4})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0xFF, 7, 0}, buf[:4])
+}
+
+func TestPayloadCodecIeee(t *testing.T) {
+	half, err := NewPayloadCodec(PayloadFormat{DataItemFormat: IeeeHalf, ItemPackingFieldSize: 16, DataItemSize: 16})
+	assert.NoError(t, err)
+	buf := make([]byte, 16)
+	samples := []float64{1, -2, 65504, math.Ldexp(1, -24), 0.1, 65520, math.Ldexp(1, -14) - math.Ldexp(1, -26)}
+	_, err = EncodePayload(half, buf, samples)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x3C, 0, 0xC0, 0, 0x7B, 0xFF, 0, 1, 0x2E, 0x66, 0x7C, 0, 0x04, 0, 0, 0}, buf)
+	decoded := make([]float64, 7)
+	_, err = DecodePayload(half, buf, decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, -2, 65504, math.Ldexp(1, -24), 0.0999755859375, math.Inf(1), math.Ldexp(1, -14)}, decoded)
+	_, err = EncodePayload(half, buf, []float32{float32(math.NaN())})
+	assert.NoError(t, err)
+	_, err = DecodePayload(half, buf, decoded[:1])
+	assert.NoError(t, err)
+	assert.True(t, math.IsNaN(decoded[0]))
+
+	single, err := NewPayloadCodec(PayloadFormat{PackingMethod: true, DataItemFormat: IeeeSingle, ItemPackingFieldSize: 32, DataItemSize: 32})
+	assert.NoError(t, err)
+	_, err = EncodePayload(single, buf, []float32{1.5, -0.125})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x3F, 0xC0, 0, 0, 0xBE, 0, 0, 0}, buf[:8])
+	floats := make([]float32, 2)
+	_, err = DecodePayload(single, buf[:8], floats)
+	assert.NoError(t, err)
+	assert.Equal(t, []float32{1.5, -0.125}, floats)
+
+	double, err := NewPayloadCodec(PayloadFormat{DataItemFormat: IeeeDouble, ItemPackingFieldSize: 64, DataItemSize: 64})
+	assert.NoError(t, err)
+	_, err = EncodePayload(double, buf, []float64{-1.5, math.Pi})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xBF, 0xF8, 0, 0, 0, 0, 0, 0}, buf[:8])
+	doubles := make([]float64, 2)
+	_, err = DecodePayload(double, buf, doubles)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{-1.5, math.Pi}, doubles)
+	// Floating-point items truncate into integer slices
+	ints := make([]int8, 2)
+	_, err = DecodePayload(double, buf, ints)
+	assert.NoError(t, err)
+	assert.Equal(t, []int8{-1, 3}, ints)
+}
+
+func TestPayloadCodecVrtFloat(t *testing.T) {
+	cases := []struct {
+		name     string
+		format   uint8
+		samples  []float64
+		expected []byte
+		decoded  []float64
+	}{
+		{
+			name:     "Unsigned 1-bit exponent",
+			format:   UnsignedVrtFloat1,
+			samples:  []float64{0.5, 1.5, 3, -1},
+			expected: []byte{0x80, 0xC1, 0xFF, 0},
+			decoded:  []float64{0.5, 1.5, 127.0 / 64, 0},
+		},
+		{
+			name:     "Signed 2-bit exponent",
+			format:   SignedVrtFloat2,
+			samples:  []float64{-1, 0.5, 3.5, 100},
+			expected: []byte{0x80, 0x40, 0x72, 0x7F},
+			decoded:  []float64{-1, 0.5, 3.5, 31.0 / 4},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewPayloadCodec(PayloadFormat{DataItemFormat: tc.format, ItemPackingFieldSize: 8, DataItemSize: 8})
+			assert.NoError(t, err)
+			buf := make([]byte, 4)
+			_, err = EncodePayload(c, buf, tc.samples)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, buf)
+			decoded := make([]float64, len(tc.samples))
+			_, err = DecodePayload(c, buf, decoded)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.decoded, decoded)
+		})
+	}
+}
+
+func TestPayloadCodecComplex(t *testing.T) {
+	c, err := NewPayloadCodec(PayloadFormat{
+		RealComplexType:      ComplexCartesian,
+		DataItemFormat:       SignedFixedPoint,
+		DataItemFractionSize: 14,
+		ItemPackingFieldSize: 16,
+		DataItemSize:         16,
+	})
+	assert.NoError(t, err)
+	assert.True(t, c.Complex())
+	samples := []complex64{complex(0.5, -0.5), complex(1, 0.25)}
+	assert.Equal(t, uint32(8), c.PayloadSize(2*len(samples)))
+	buf := make([]byte, 8)
+	n, err := EncodeComplexPayload(c, buf, samples)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, n)
+	assert.Equal(t, []byte{0x20, 0, 0xE0, 0, 0x40, 0, 0x10, 0}, buf)
+	decoded := make([]complex128, 3)
+	n, err = DecodeComplexPayload(c, buf, decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []complex128{complex(0.5, -0.5), complex(1, 0.25), 0}, decoded)
+	// Real slices see the items interleaved
+	interleaved := make([]int16, 4)
+	_, err = DecodePayload(c, buf, interleaved)
+	assert.NoError(t, err)
+	assert.Equal(t, []int16{0x2000, -0x2000, 0x4000, 0x1000}, interleaved)
+
+	real, err := NewPayloadCodec(PayloadFormat{ItemPackingFieldSize: 16, DataItemSize: 16})
+	assert.NoError(t, err)
+	_, err = DecodeComplexPayload(real, buf, decoded)
+	assert.ErrorIs(t, err, ErrPayloadFormat)
+	_, err = EncodeComplexPayload(real, buf, samples)
+	assert.ErrorIs(t, err, ErrPayloadFormat)
+	_, err = EncodeComplexPayload(c, buf[:4], samples)
+	assert.ErrorIs(t, err, ErrShortBuffer)
+}
+
+func TestPayloadCodecErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		format PayloadFormat
+	}{
+		{"Reserved real/complex type", PayloadFormat{RealComplexType: 3}},
+		{"Reserved data item format", PayloadFormat{DataItemFormat: 0x08}},
+		{"IEEE size", PayloadFormat{DataItemFormat: IeeeSingle, ItemPackingFieldSize: 32, DataItemSize: 16}},
+		{"VRT exponent", PayloadFormat{DataItemFormat: UnsignedVrtFloat6, ItemPackingFieldSize: 8, DataItemSize: 6}},
+		{"Field too small", PayloadFormat{EventTagSize: 1, ItemPackingFieldSize: 16, DataItemSize: 16}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewPayloadCodec(tc.format)
+			assert.ErrorIs(t, err, ErrPayloadFormat)
+		})
+	}
+
+	c, err := NewPayloadCodec(PayloadFormat{ItemPackingFieldSize: 16, DataItemSize: 16})
+	assert.NoError(t, err)
+	_, err = EncodePayload(c, make([]byte, 4), []int16{1, 2, 3})
+	assert.ErrorIs(t, err, ErrShortBuffer)
+}
+
+func TestPayloadCodecSignalData(t *testing.T) {
+	c, err := NewPayloadCodec(PayloadFormat{DataItemFormat: SignedFixedPoint, ItemPackingFieldSize: 16, DataItemSize: 16})
+	assert.NoError(t, err)
+	samples := []int16{1, 2, 3, 4, 5, 6}
+	p := SignalDataPacket{Payload: make([]byte, c.PayloadSize(len(samples)))}
+	_, err = EncodePayload(c, p.Payload, samples)
+	assert.NoError(t, err)
+	unpacked := SignalDataPacket{}
+	assert.NoError(t, unpacked.UnmarshalBinary(p.Pack()))
+	decoded := make([]int16, len(samples))
+	n, err := DecodePayload(c, unpacked.Payload, decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, len(samples), n)
+	assert.Equal(t, samples, decoded)
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"sync"
+)
+
+// Decoder
+// Unpacks a packet of the classes it is registered for. buf holds exactly
+// the PacketSize declared in the header, which has been checked.
+type Decoder interface {
+	DecodePacket(buf []byte) (Packet, error)
+}
+
+// DecoderFunc adapts a function to a Decoder
+type DecoderFunc func(buf []byte) (Packet, error)
+
+func (f DecoderFunc) DecodePacket(buf []byte) (Packet, error) {
+	return f(buf)
+}
+
+// PacketDecoder returns a Decoder that unpacks with the UnmarshalBinary of
+// the empty packet newPacket returns
+func PacketDecoder(newPacket func() Packet) Decoder {
+	return DecoderFunc(func(buf []byte) (Packet, error) {
+		packet := newPacket()
+		if err := packet.UnmarshalBinary(buf); err != nil {
+			return nil, err
+		}
+		return packet, nil
+	})
+}
+
+// GenericDecoder chooses the packet type from the header as UnmarshalPacket
+// does
+var GenericDecoder = NewGenericDecoder(nil)
+
+// NewGenericDecoder returns a Decoder that chooses the packet type from the
+// header as UnmarshalPacket does, and unpacks extension packets with the
+// fields registered with extensions. It uses DefaultExtensions when
+// extensions is nil.
+func NewGenericDecoder(extensions *ExtensionRegistry) Decoder {
+	return DecoderFunc(func(buf []byte) (Packet, error) {
+		var header Header
+		header.Unpack(buf)
+		return unmarshalPacket(header, buf, extensions)
+	})
+}
+
+// Registry
+// Chooses the Decoder for each packet by its ClassID, ignoring the
+// PadBitCount. Packets whose class is not registered, including those
+// without a ClassID, are handed to Fallback, and fail with an
+// UnknownClassError when it is nil. Fallback must be set before the Registry
+// is used; Register may be called concurrently with decoding.
+type Registry struct {
+	Fallback Decoder
+
+	mu       sync.RWMutex
+	decoders map[ClassID]Decoder
+}
+
+// NewRegistry returns an empty Registry that falls back to GenericDecoder
+func NewRegistry() *Registry {
+	return &Registry{Fallback: GenericDecoder}
+}
+
+// Register decodes packets of class classID with d. A class may only be
+// registered once.
+func (r *Registry) Register(classID ClassID, d Decoder) error {
+	classID = classKey(classID)
+	if d == nil {
+		return &RegistrationError{ClassID: classID, Reason: "no decoder"}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.decoders[classID]; ok {
+		return &RegistrationError{ClassID: classID, Reason: "decoder already registered"}
+	}
+	if r.decoders == nil {
+		r.decoders = map[ClassID]Decoder{}
+	}
+	r.decoders[classID] = d
+	return nil
+}
+
+// Decoder returns the Decoder registered for classID, or nil when there is
+// none
+func (r *Registry) Decoder(classID ClassID) Decoder {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.decoders[classKey(classID)]
+}
+
+// UnmarshalPacket unpacks the first packet in buf with the Decoder for its
+// class and returns it along with the bytes that follow it.
+func (r *Registry) UnmarshalPacket(buf []byte) (Packet, []byte, error) {
+	header, size, err := splitPacket(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	buf, rest := buf[:size], buf[size:]
+	classID, err := packetClassID(header, buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	d := r.Fallback
+	if classID != nil {
+		if registered := r.Decoder(*classID); registered != nil {
+			d = registered
+		}
+	}
+	if d == nil {
+		return nil, nil, &UnknownClassError{ClassID: classID}
+	}
+	packet, err := d.DecodePacket(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return packet, rest, nil
+}
+
+// packetClassID returns the ClassID of the packet in buf, or nil when the
+// header does not enable one
+func packetClassID(header Header, buf []byte) (*ClassID, error) {
+	if !header.ClassIdEnable {
+		return nil, nil
+	}
+	offset := headerBytes
+	if header.PacketType.HasStreamID() {
+		offset += streamIDBytes
+	}
+	classID := &ClassID{}
+	if err := classID.UnmarshalBinary(buf[offset:]); err != nil {
+		return nil, err
+	}
+	return classID, nil
+}
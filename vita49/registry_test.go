@@ -0,0 +1,116 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry()
+	classID := ClassID{Oui: 0x123456, InformationCode: 1, PacketCode: 2}
+	d := PacketDecoder(func() Packet { return &RawPacket{} })
+	require.NoError(t, r.Register(classID, d))
+	assert.ErrorIs(t, r.Register(ClassID{PadBitCount: 1, Oui: 0x123456, InformationCode: 1, PacketCode: 2}, d), ErrRegistration)
+	assert.ErrorIs(t, r.Register(ClassID{Oui: 1}, nil), ErrRegistration)
+	assert.NotNil(t, r.Decoder(ClassID{PadBitCount: 2, Oui: 0x123456, InformationCode: 1, PacketCode: 2}))
+	assert.Nil(t, r.Decoder(ClassID{Oui: 0x123456}))
+}
+
+func TestRegistryUnmarshalPacket(t *testing.T) {
+	registered := ClassID{Oui: 0x123456, PacketCode: 1}
+	unknown := ClassID{Oui: 0x123456, PacketCode: 2}
+	r := NewRegistry()
+	require.NoError(t, r.Register(registered, PacketDecoder(func() Packet { return &RawPacket{} })))
+
+	withClass := func(classID ClassID) []byte {
+		return (&ContextPacket{StreamID: 1, ClassID: &classID}).Pack()
+	}
+	data := (&SignalDataPacket{Header: DataHeader{Header: Header{PacketType: SignalData}}, ClassID: &registered, Payload: []byte{1, 2, 3, 4}}).Pack()
+	noClass := (&ContextPacket{StreamID: 1}).Pack()
+
+	cases := []struct {
+		name     string
+		buf      []byte
+		expected Packet
+	}{
+		{"Registered", withClass(registered), &RawPacket{}},
+		{"Registered without stream ID", data, &RawPacket{}},
+		{"Unknown class", withClass(unknown), &ContextPacket{}},
+		{"No class", noClass, &ContextPacket{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			packet, rest, err := r.UnmarshalPacket(append(tc.buf, 0xAA))
+			require.NoError(t, err)
+			assert.IsType(t, tc.expected, packet)
+			assert.Equal(t, []byte{0xAA}, rest)
+		})
+	}
+
+	// Without a fallback only registered classes decode
+	r.Fallback = nil
+	_, _, err := r.UnmarshalPacket(withClass(registered))
+	assert.NoError(t, err)
+	_, _, err = r.UnmarshalPacket(withClass(unknown))
+	assert.Equal(t, &UnknownClassError{ClassID: &unknown}, err)
+	_, _, err = r.UnmarshalPacket(noClass)
+	assert.Equal(t, &UnknownClassError{}, err)
+	assert.ErrorIs(t, err, ErrUnknownClass)
+}
+
+func TestRegistryUnmarshalPacketErrors(t *testing.T) {
+	classID := ClassID{Oui: 0x123456}
+	failure := errors.New("decode failed")
+	r := NewRegistry()
+	require.NoError(t, r.Register(classID, DecoderFunc(func(buf []byte) (Packet, error) { return nil, failure })))
+
+	valid := (&ContextPacket{ClassID: &classID}).Pack()
+	reserved := append([]byte{}, valid...)
+	reserved[8] = 0x01
+
+	_, _, err := r.UnmarshalPacket(valid)
+	assert.Equal(t, failure, err)
+	_, _, err = r.UnmarshalPacket(reserved)
+	assert.ErrorIs(t, err, ErrReservedBits)
+	_, _, err = r.UnmarshalPacket(valid[:len(valid)-4])
+	assert.ErrorIs(t, err, ErrShortBuffer)
+}
+
+func TestPacketReaderRegistry(t *testing.T) {
+	classID := ClassID{Oui: 0x123456, PacketCode: 3}
+	r := NewRegistry()
+	require.NoError(t, r.Register(classID, PacketDecoder(func() Packet { return &RawPacket{} })))
+	stream := append((&ContextPacket{ClassID: &classID}).Pack(), (&ContextPacket{}).Pack()...)
+
+	reader := NewPacketReader(bytes.NewReader(stream))
+	reader.Registry = r
+	packet, err := reader.ReadPacket()
+	require.NoError(t, err)
+	assert.IsType(t, &RawPacket{}, packet)
+	packet, err = reader.ReadPacket()
+	require.NoError(t, err)
+	assert.IsType(t, &ContextPacket{}, packet)
+}
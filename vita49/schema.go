@@ -0,0 +1,435 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Presence says whether a packet schema requires, permits or forbids a field
+type Presence uint8
+
+const (
+	Absent Presence = iota
+	Optional
+	Required
+)
+
+// PacketSchema
+// A packet definition loaded at run time from vrtgen-style YAML rather than
+// generated code. Data, context and control packets are described by their
+// PacketType, ClassID, timestamps, the presence of each CIF0 to CIF3 field
+// and, for data packets, of the stream ID and each trailer indicator. A
+// schema with a ClassID may be registered as the Decoder for its class.
+type PacketSchema struct {
+	Name       string
+	PacketType PacketType
+	ClassID    *ClassID
+	StreamID   Presence
+	Tsi        Tsi
+	Tsf        Tsf
+	Fields     map[FieldID]Presence
+	Trailer    map[string]Presence
+}
+
+// schemaDefinition is a packet definition as written in YAML
+type schemaDefinition struct {
+	Type      string   `yaml:"type"`
+	ClassID   *ClassID `yaml:"class_id"`
+	StreamID  string   `yaml:"stream_id"`
+	Timestamp struct {
+		Integer    string `yaml:"integer"`
+		Fractional string `yaml:"fractional"`
+	} `yaml:"timestamp"`
+	Cif0    map[string]string `yaml:"cif_0"`
+	Cif1    map[string]string `yaml:"cif_1"`
+	Cif2    map[string]string `yaml:"cif_2"`
+	Cif3    map[string]string `yaml:"cif_3"`
+	Trailer map[string]string `yaml:"trailer"`
+}
+
+var schemaKeys = map[string]bool{
+	"type": true, "class_id": true, "stream_id": true, "timestamp": true,
+	"cif_0": true, "cif_1": true, "cif_2": true, "cif_3": true, "trailer": true,
+}
+
+var schemaPacketTypes = map[string]PacketType{
+	"data":    SignalData,
+	"context": Context,
+	"control": Command,
+}
+
+var schemaTsi = map[string]Tsi{
+	"":      NoneTsi,
+	"none":  NoneTsi,
+	"utc":   Utc,
+	"gps":   Gps,
+	"other": Other,
+}
+
+var schemaTsf = map[string]Tsf{
+	"":             NoneTsf,
+	"none":         NoneTsf,
+	"sample_count": SampleCount,
+	"real_time":    Picoseconds,
+	"picoseconds":  Picoseconds,
+	"free_running": FreeRunning,
+}
+
+// trailerIndicators maps the name of each trailer state and event indicator
+// to the indicator
+var trailerIndicators = map[string]func(s *StateEventIndicators) *EnableIndicator{
+	"calibrated_time":    func(s *StateEventIndicators) *EnableIndicator { return &s.CalibratedTime },
+	"valid_data":         func(s *StateEventIndicators) *EnableIndicator { return &s.ValidData },
+	"reference_lock":     func(s *StateEventIndicators) *EnableIndicator { return &s.ReferenceLock },
+	"agc_mgc":            func(s *StateEventIndicators) *EnableIndicator { return &s.AgcMgc },
+	"detected_signal":    func(s *StateEventIndicators) *EnableIndicator { return &s.DetectedSignal },
+	"spectral_inversion": func(s *StateEventIndicators) *EnableIndicator { return &s.SpectralInversion },
+	"over_range":         func(s *StateEventIndicators) *EnableIndicator { return &s.OverRange },
+	"sample_loss":        func(s *StateEventIndicators) *EnableIndicator { return &s.SampleLoss },
+}
+
+// cifFieldNames maps the name of each CIF0 to CIF3 field to its FieldID. The
+// names are the indicator field names in snake_case, such as
+// rf_ref_frequency, with "ref" also spelled out as "reference" as vrtgen
+// does.
+var cifFieldNames, cifFieldIDs = indicatorFieldNames()
+
+func indicatorFieldNames() (map[string]FieldID, map[FieldID]string) {
+	names := map[string]FieldID{}
+	ids := map[FieldID]string{}
+	indicators := []CheckedField{&IndicatorField0{}, &IndicatorField1{}, &IndicatorField2{}, &IndicatorField3{}}
+	var buf [4]byte
+	for cif, indicator := range indicators {
+		v := reflect.ValueOf(indicator).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			if f.Kind() != reflect.Bool {
+				continue
+			}
+			f.SetBool(true)
+			indicator.PackInto(buf[:])
+			f.SetBool(false)
+			bitmap := binary.BigEndian.Uint32(buf[:])
+			if cif == 0 && bitmap&(cif0EnableBits|cif0ChangeIndicator) != 0 {
+				continue
+			}
+			id := FieldID{Cif: uint8(cif), Bit: uint8(31 - bits.LeadingZeros32(bitmap))}
+			name := snakeCase(v.Type().Field(i).Name)
+			names[name] = id
+			alias := strings.Replace("_"+name+"_", "_ref_", "_reference_", 1)
+			names[alias[1:len(alias)-1]] = id
+			ids[id] = name
+		}
+	}
+	return names, ids
+}
+
+// snakeCase converts a Go field name such as ReferencePointID to
+// reference_point_id
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteByte('_')
+				}
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func parsePresence(value string) (Presence, bool) {
+	switch value {
+	case "required":
+		return Required, true
+	case "optional":
+		return Optional, true
+	}
+	return Absent, false
+}
+
+// LoadSchemas parses a YAML document mapping packet names to vrtgen-style
+// packet definitions, and returns their schemas in document order. Each
+// definition has a type of data, context or control and may hold:
+//
+//	class_id:   oui, informationCode and packetCode
+//	stream_id:  required or optional (data packets only)
+//	timestamp:  integer (utc, gps, other) and fractional (sample_count,
+//	            real_time, free_running)
+//	cif_0 to cif_3: field names, such as bandwidth, mapped to required or
+//	            optional
+//	trailer:    indicator names, such as valid_data, mapped to required or
+//	            optional (data packets only)
+func LoadSchemas(r io.Reader) ([]*PacketSchema, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, &SchemaError{Reason: "empty document"}
+		}
+		return nil, &SchemaError{Reason: err.Error()}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, &SchemaError{Reason: "document is not a mapping of packet names to definitions"}
+	}
+	var schemas []*PacketSchema
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		schema, err := newPacketSchema(root.Content[i].Value, root.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, nil
+}
+
+func newPacketSchema(name string, node *yaml.Node) (*PacketSchema, error) {
+	fail := func(format string, args ...any) (*PacketSchema, error) {
+		return nil, &SchemaError{Schema: name, Reason: fmt.Sprintf(format, args...)}
+	}
+	if node.Kind != yaml.MappingNode {
+		return fail("definition is not a mapping")
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if key := node.Content[i].Value; !schemaKeys[key] {
+			return fail("unsupported key %q", key)
+		}
+	}
+	var def schemaDefinition
+	if err := node.Decode(&def); err != nil {
+		return fail("%v", err)
+	}
+	s := &PacketSchema{Name: name, ClassID: def.ClassID, Fields: map[FieldID]Presence{}, Trailer: map[string]Presence{}}
+
+	packetType, ok := schemaPacketTypes[def.Type]
+	if !ok {
+		return fail("unknown type %q", def.Type)
+	}
+	s.PacketType = packetType
+	if s.Tsi, ok = schemaTsi[def.Timestamp.Integer]; !ok {
+		return fail("unknown integer timestamp %q", def.Timestamp.Integer)
+	}
+	if s.Tsf, ok = schemaTsf[def.Timestamp.Fractional]; !ok {
+		return fail("unknown fractional timestamp %q", def.Timestamp.Fractional)
+	}
+
+	if packetType == SignalData {
+		if def.StreamID != "" {
+			if s.StreamID, ok = parsePresence(def.StreamID); !ok {
+				return fail("stream_id is %q, not required or optional", def.StreamID)
+			}
+		}
+		if s.StreamID == Required {
+			s.PacketType = SignalDataStreamID
+		}
+		for indicator, value := range def.Trailer {
+			if trailerIndicators[indicator] == nil {
+				return fail("unknown trailer indicator %q", indicator)
+			}
+			if s.Trailer[indicator], ok = parsePresence(value); !ok {
+				return fail("trailer indicator %s is %q, not required or optional", indicator, value)
+			}
+		}
+	} else {
+		// Context and command packets always carry a stream ID
+		if def.StreamID != "" && def.StreamID != "required" {
+			return fail("stream_id of a %s packet must be required", def.Type)
+		}
+		s.StreamID = Required
+		if len(def.Trailer) != 0 {
+			return fail("%s packets have no trailer", def.Type)
+		}
+	}
+
+	for cif, fields := range []map[string]string{def.Cif0, def.Cif1, def.Cif2, def.Cif3} {
+		if len(fields) != 0 && packetType == SignalData {
+			return fail("data packets have no CIF fields")
+		}
+		for field, value := range fields {
+			id, ok := cifFieldNames[field]
+			if !ok || id.Cif != uint8(cif) {
+				return fail("unknown cif_%d field %q", cif, field)
+			}
+			if s.Fields[id], ok = parsePresence(value); !ok {
+				return fail("field %s is %q, not required or optional", field, value)
+			}
+		}
+	}
+	return s, nil
+}
+
+// NewPacket returns an empty packet of the schema: its type, ClassID and
+// timestamps are set, along with the indicator bits of each required field
+// and, for a data packet with required trailer indicators, their enables.
+func (s *PacketSchema) NewPacket() Packet {
+	var classID *ClassID
+	if s.ClassID != nil {
+		copied := *s.ClassID
+		classID = &copied
+	}
+	header := Header{PacketType: s.PacketType, ClassIdEnable: classID != nil, Tsi: s.Tsi, Tsf: s.Tsf}
+	var cifs *Cifs
+	var packet Packet
+	switch s.PacketType {
+	case Context:
+		p := &ContextPacket{Header: ContextHeader{Header: header}, ClassID: classID}
+		cifs, packet = &p.Cifs, p
+	case Command:
+		p := &ControlPacket{CommandPrologue: CommandPrologue{Header: CommandHeader{Header: header}, ClassID: classID}}
+		cifs, packet = &p.Cifs, p
+	default:
+		p := &SignalDataPacket{Header: DataHeader{Header: header}, ClassID: classID}
+		for indicator, presence := range s.Trailer {
+			if presence == Required {
+				if p.Trailer == nil {
+					p.Trailer = &Trailer{}
+				}
+				trailerIndicators[indicator](&p.Trailer.StateEventIndicators).Enable = true
+			}
+		}
+		return p
+	}
+	for id, presence := range s.Fields {
+		if presence == Required {
+			cifs.setIndicator(id)
+		}
+	}
+	return packet
+}
+
+// Validate checks packet is of the schema: its type, ClassID and timestamps
+// match, every required field or trailer indicator is present, and no field
+// or indicator the schema does not mention is.
+func (s *PacketSchema) Validate(packet Packet) error {
+	fail := func(format string, args ...any) error {
+		return &ValidationError{Schema: s.Name, Reason: fmt.Sprintf(format, args...)}
+	}
+	var header Header
+	var classID *ClassID
+	var cifs *Cifs
+	var trailer *Trailer
+	switch p := packet.(type) {
+	case *SignalDataPacket:
+		header, classID, trailer = p.Header.Header, p.ClassID, p.Trailer
+	case *ContextPacket:
+		// The PacketType of context and command packets is set when packed
+		header, classID, cifs = p.Header.Header, p.ClassID, &p.Cifs
+		header.PacketType = Context
+	case *ControlPacket:
+		header, classID, cifs = p.Header.Header, p.ClassID, &p.Cifs
+		header.PacketType = Command
+	default:
+		return fail("unsupported packet %T", packet)
+	}
+
+	typeMatches := header.PacketType == s.PacketType
+	if s.PacketType == SignalData && s.StreamID == Optional {
+		typeMatches = header.PacketType == SignalData || header.PacketType == SignalDataStreamID
+	}
+	if !typeMatches {
+		return fail("packet type is %d", header.PacketType)
+	}
+	switch {
+	case s.ClassID == nil && classID != nil:
+		return fail("unexpected class ID")
+	case s.ClassID != nil && classID == nil:
+		return fail("missing class ID")
+	case s.ClassID != nil && classKey(*s.ClassID) != classKey(*classID):
+		return fail("class ID is %06X:%04X:%04X", classID.Oui, classID.InformationCode, classID.PacketCode)
+	}
+	if header.Tsi != s.Tsi || header.Tsf != s.Tsf {
+		return fail("timestamp has Tsi %d and Tsf %d", header.Tsi, header.Tsf)
+	}
+
+	if cifs != nil {
+		present := map[FieldID]bool{}
+		for _, id := range cifs.fieldIDs() {
+			if s.Fields[id] == Absent {
+				return fail("unexpected field %s", cifFieldIDs[id])
+			}
+			present[id] = true
+		}
+		for id, presence := range s.Fields {
+			if presence == Required && !present[id] {
+				return fail("missing required field %s", cifFieldIDs[id])
+			}
+		}
+	}
+
+	if trailer == nil {
+		for indicator, presence := range s.Trailer {
+			if presence == Required {
+				return fail("missing required trailer indicator %s", indicator)
+			}
+		}
+		return nil
+	}
+	if len(s.Trailer) == 0 {
+		return fail("unexpected trailer")
+	}
+	for indicator, get := range trailerIndicators {
+		enabled := get(&trailer.StateEventIndicators).Enable
+		switch presence := s.Trailer[indicator]; {
+		case enabled && presence == Absent:
+			return fail("unexpected trailer indicator %s", indicator)
+		case !enabled && presence == Required:
+			return fail("missing required trailer indicator %s", indicator)
+		}
+	}
+	return nil
+}
+
+// DecodePacket unpacks buf as GenericDecoder does and validates the packet,
+// so that a schema may be registered as the Decoder for its class
+func (s *PacketSchema) DecodePacket(buf []byte) (Packet, error) {
+	packet, err := GenericDecoder.DecodePacket(buf)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Validate(packet); err != nil {
+		return nil, err
+	}
+	return packet, nil
+}
+
+// AppendPack validates packet and appends it packed to dst
+func (s *PacketSchema) AppendPack(dst []byte, packet Packet) ([]byte, error) {
+	if err := s.Validate(packet); err != nil {
+		return dst, err
+	}
+	return packet.AppendPack(dst)
+}
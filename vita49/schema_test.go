@@ -0,0 +1,236 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchemas = `
+ExampleContext:
+  type: context
+  class_id:
+    oui: 0xFFFFFA
+    informationCode: 1
+    packetCode: 2
+  timestamp:
+    integer: utc
+    fractional: real_time
+  cif_0:
+    bandwidth: required
+    rf_reference_frequency: required
+    temperature: optional
+  cif_1:
+    range: optional
+ExampleData:
+  type: data
+  stream_id: required
+  class_id:
+    oui: 0xFFFFFA
+    packetCode: 3
+  timestamp:
+    integer: gps
+  trailer:
+    valid_data: required
+    over_range: optional
+ExampleControl:
+  type: control
+  cif_2:
+    controllee_uuid: required
+`
+
+func loadTestSchemas(t *testing.T) []*PacketSchema {
+	schemas, err := LoadSchemas(strings.NewReader(testSchemas))
+	require.NoError(t, err)
+	require.Len(t, schemas, 3)
+	return schemas
+}
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"Bandwidth":         "bandwidth",
+		"ReferencePointID":  "reference_point_id",
+		"DiscreteIO32":      "discrete_io32",
+		"V49SpecCompliance": "v49_spec_compliance",
+		"ControlleeUUID":    "controllee_uuid",
+		"EbnoBer":           "ebno_ber",
+	}
+	for name, expected := range cases {
+		assert.Equal(t, expected, snakeCase(name))
+	}
+	assert.Equal(t, FieldID{Cif: 0, Bit: 27}, cifFieldNames["rf_ref_frequency"])
+	assert.Equal(t, FieldID{Cif: 0, Bit: 27}, cifFieldNames["rf_reference_frequency"])
+	assert.Equal(t, FieldID{Cif: 3, Bit: 31}, cifFieldNames["timestamp_details"])
+	assert.NotContains(t, cifFieldNames, "if1_enable")
+	assert.NotContains(t, cifFieldNames, "change_indicator")
+}
+
+func TestLoadSchemas(t *testing.T) {
+	schemas := loadTestSchemas(t)
+
+	context := schemas[0]
+	assert.Equal(t, "ExampleContext", context.Name)
+	assert.Equal(t, Context, context.PacketType)
+	assert.Equal(t, &ClassID{Oui: 0xFFFFFA, InformationCode: 1, PacketCode: 2}, context.ClassID)
+	assert.Equal(t, Required, context.StreamID)
+	assert.Equal(t, Utc, context.Tsi)
+	assert.Equal(t, Picoseconds, context.Tsf)
+	assert.Equal(t, map[FieldID]Presence{
+		{Cif: 0, Bit: 29}: Required,
+		{Cif: 0, Bit: 27}: Required,
+		{Cif: 0, Bit: 18}: Optional,
+		{Cif: 1, Bit: 24}: Optional,
+	}, context.Fields)
+
+	data := schemas[1]
+	assert.Equal(t, SignalDataStreamID, data.PacketType)
+	assert.Equal(t, Gps, data.Tsi)
+	assert.Equal(t, NoneTsf, data.Tsf)
+	assert.Equal(t, map[string]Presence{"valid_data": Required, "over_range": Optional}, data.Trailer)
+
+	control := schemas[2]
+	assert.Equal(t, Command, control.PacketType)
+	assert.Nil(t, control.ClassID)
+	assert.Equal(t, map[FieldID]Presence{{Cif: 2, Bit: 24}: Required}, control.Fields)
+}
+
+func TestLoadSchemasErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		yaml string
+	}{
+		{"Empty", ""},
+		{"Syntax", "Example: [type"},
+		{"Not a mapping", "- type: data"},
+		{"Definition not a mapping", "Example: data"},
+		{"Unknown type", "Example: {type: beacon}"},
+		{"Unsupported key", "Example: {type: data, payload: {}}"},
+		{"Unknown field", "Example: {type: context, cif_0: {bandwidth_x: required}}"},
+		{"Field in the wrong CIF", "Example: {type: context, cif_1: {bandwidth: required}}"},
+		{"Unknown presence", "Example: {type: context, cif_0: {bandwidth: sometimes}}"},
+		{"Data packet with CIF fields", "Example: {type: data, cif_0: {bandwidth: required}}"},
+		{"Context packet with trailer", "Example: {type: context, trailer: {valid_data: required}}"},
+		{"Optional context stream ID", "Example: {type: context, stream_id: optional}"},
+		{"Unknown trailer indicator", "Example: {type: data, trailer: {valid: required}}"},
+		{"Unknown timestamp", "Example: {type: data, timestamp: {integer: tai}}"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadSchemas(strings.NewReader(tc.yaml))
+			assert.ErrorIs(t, err, ErrSchema)
+		})
+	}
+}
+
+func TestPacketSchemaContext(t *testing.T) {
+	schema := loadTestSchemas(t)[0]
+	packet := schema.NewPacket()
+	require.IsType(t, &ContextPacket{}, packet)
+	p := packet.(*ContextPacket)
+	assert.True(t, p.Cif0.IndicatorField0.Bandwidth)
+	assert.True(t, p.Cif0.IndicatorField0.RfRefFrequency)
+	assert.False(t, p.Cif0.IndicatorField0.Temperature)
+	p.Cif0.Bandwidth = 20e6
+	p.Cif0.RfRefFrequency = 2.4e9
+	p.StreamID = 1
+
+	packed, err := schema.AppendPack(nil, p)
+	require.NoError(t, err)
+	r := NewRegistry()
+	require.NoError(t, r.Register(*schema.ClassID, schema))
+	decoded, _, err := r.UnmarshalPacket(packed)
+	require.NoError(t, err)
+	assert.Equal(t, p, decoded)
+
+	// Optional fields may be present, fields the schema does not mention may not
+	p.Cifs.setIndicator(FieldID{Cif: 1, Bit: 24})
+	assert.NoError(t, schema.Validate(p))
+	p.Cif0.IndicatorField0.Gain = true
+	assert.ErrorIs(t, schema.Validate(p), ErrValidation)
+	p.Cif0.IndicatorField0.Gain = false
+	p.Cif0.IndicatorField0.Bandwidth = false
+	_, err = schema.AppendPack(nil, p)
+	assert.Equal(t, &ValidationError{Schema: "ExampleContext", Reason: "missing required field bandwidth"}, err)
+	p.Cif0.IndicatorField0.Bandwidth = true
+
+	p.ClassID = &ClassID{Oui: 0xFFFFFA, PacketCode: 3}
+	assert.ErrorIs(t, schema.Validate(p), ErrValidation)
+	p.ClassID = nil
+	assert.ErrorIs(t, schema.Validate(p), ErrValidation)
+	p.ClassID = &ClassID{PadBitCount: 1, Oui: 0xFFFFFA, InformationCode: 1, PacketCode: 2}
+	assert.NoError(t, schema.Validate(p))
+	p.Header.Tsf = NoneTsf
+	assert.ErrorIs(t, schema.Validate(p), ErrValidation)
+	assert.ErrorIs(t, schema.Validate(&ControlPacket{}), ErrValidation)
+
+	// Decoding validates the packet
+	p.Header.Tsf = Picoseconds
+	p.Cif0.IndicatorField0.Bandwidth = false
+	_, _, err = r.UnmarshalPacket(p.Pack())
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestPacketSchemaData(t *testing.T) {
+	schema := loadTestSchemas(t)[1]
+	packet := schema.NewPacket()
+	require.IsType(t, &SignalDataPacket{}, packet)
+	p := packet.(*SignalDataPacket)
+	assert.Equal(t, SignalDataStreamID, p.Header.PacketType)
+	require.NotNil(t, p.Trailer)
+	assert.True(t, p.Trailer.ValidData.Enable)
+	p.Payload = []byte{1, 2, 3, 4}
+	assert.NoError(t, schema.Validate(p))
+
+	decoded, err := schema.DecodePacket(p.Pack())
+	require.NoError(t, err)
+	assert.Equal(t, p, decoded)
+
+	p.Trailer.OverRange.Enable = true
+	assert.NoError(t, schema.Validate(p))
+	p.Trailer.SampleLoss.Enable = true
+	assert.ErrorIs(t, schema.Validate(p), ErrValidation)
+	p.Trailer = nil
+	assert.ErrorIs(t, schema.Validate(p), ErrValidation)
+
+	// An optional stream ID permits either data packet type
+	optional, err := LoadSchemas(strings.NewReader("Example: {type: data, stream_id: optional}"))
+	require.NoError(t, err)
+	assert.NoError(t, optional[0].Validate(&SignalDataPacket{Header: DataHeader{Header: Header{PacketType: SignalData}}}))
+	assert.NoError(t, optional[0].Validate(&SignalDataPacket{Header: DataHeader{Header: Header{PacketType: SignalDataStreamID}}}))
+	assert.ErrorIs(t, optional[0].Validate(&SignalDataPacket{Header: DataHeader{Header: Header{PacketType: ExtensionData}}}), ErrValidation)
+	assert.ErrorIs(t, optional[0].Validate(&SignalDataPacket{Trailer: &Trailer{}}), ErrValidation)
+}
+
+func TestPacketSchemaControl(t *testing.T) {
+	schema := loadTestSchemas(t)[2]
+	packet := schema.NewPacket()
+	require.IsType(t, &ControlPacket{}, packet)
+	p := packet.(*ControlPacket)
+	assert.True(t, p.Cif0.If2Enable)
+	assert.True(t, p.Cif2.IndicatorField2.ControlleeUUID)
+	assert.NoError(t, schema.Validate(p))
+	decoded, err := schema.DecodePacket(p.Pack())
+	require.NoError(t, err)
+	assert.Equal(t, p, decoded)
+}
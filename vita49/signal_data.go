@@ -0,0 +1,235 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"encoding/binary"
+)
+
+const (
+	integerTimestampBytes    = uint32(4)
+	fractionalTimestampBytes = uint32(8)
+	trailerBytes             = uint32(4)
+)
+
+// SignalDataPacket
+// A complete Signal Data packet. The Stream ID is present when the header
+// PacketType carries one, the timestamps are present according to the header
+// Tsi/Tsf, and the Class ID and Trailer are present when non-nil.
+type SignalDataPacket struct {
+	Header              DataHeader
+	StreamID            StreamID
+	ClassID             *ClassID
+	IntegerTimestamp    uint32
+	FractionalTimestamp uint64
+	Payload             []byte
+	Trailer             *Trailer
+}
+
+func (p *SignalDataPacket) prologueSize() uint32 {
+	size := headerBytes
+	if p.Header.PacketType.HasStreamID() {
+		size += streamIDBytes
+	}
+	if p.ClassID != nil {
+		size += classIdBytes
+	}
+	if p.Header.Tsi != NoneTsi {
+		size += integerTimestampBytes
+	}
+	if p.Header.Tsf != NoneTsf {
+		size += fractionalTimestampBytes
+	}
+	return size
+}
+
+func (p *SignalDataPacket) Size() uint32 {
+	// Payload is padded out to a whole number of 32-bit words
+	size := p.prologueSize() + ((uint32(len(p.Payload)) + 3) &^ 3)
+	if p.Trailer != nil {
+		size += trailerBytes
+	}
+	return size
+}
+
+// Timestamp returns the prologue timestamp described by the header
+func (p *SignalDataPacket) Timestamp() Timestamp {
+	return Timestamp{
+		Tsi:        p.Header.Tsi,
+		Tsf:        p.Header.Tsf,
+		Integer:    p.IntegerTimestamp,
+		Fractional: p.FractionalTimestamp,
+	}
+}
+
+// SetTimestamp sets the header Tsi and Tsf and the timestamp words from t
+func (p *SignalDataPacket) SetTimestamp(t Timestamp) {
+	p.Header.Tsi = t.Tsi
+	p.Header.Tsf = t.Tsf
+	p.IntegerTimestamp = t.Integer
+	p.FractionalTimestamp = t.Fractional
+}
+
+// Pack derives ClassIdEnable, TrailerIncluded and PacketSize from the packet
+// contents before packing, so the header always describes the packed bytes.
+func (p *SignalDataPacket) Pack() []byte {
+	return mustPack(p)
+}
+
+// PackInto packs the packet into buf without allocating and returns the
+// number of bytes written. The header is derived as for Pack.
+func (p *SignalDataPacket) PackInto(buf []byte) (int, error) {
+	size := p.Size()
+	if err := checkSize("SignalDataPacket", buf, size); err != nil {
+		return 0, err
+	}
+	packetSize, err := packetWords("SignalDataPacket", size)
+	if err != nil {
+		return 0, err
+	}
+	buf = buf[:size]
+	p.Header.ClassIdEnable = p.ClassID != nil
+	p.Header.TrailerIncluded = p.Trailer != nil
+	p.Header.PacketSize = packetSize
+	p.Header.PackInto(buf[0:])
+	offset := headerBytes
+	if p.Header.PacketType.HasStreamID() {
+		p.StreamID.PackInto(buf[offset:])
+		offset += streamIDBytes
+	}
+	if p.ClassID != nil {
+		p.ClassID.PackInto(buf[offset:])
+		offset += classIdBytes
+	}
+	if p.Header.Tsi != NoneTsi {
+		binary.BigEndian.PutUint32(buf[offset:], p.IntegerTimestamp)
+		offset += integerTimestampBytes
+	}
+	if p.Header.Tsf != NoneTsf {
+		binary.BigEndian.PutUint64(buf[offset:], p.FractionalTimestamp)
+		offset += fractionalTimestampBytes
+	}
+	end := size
+	if p.Trailer != nil {
+		end -= trailerBytes
+		p.Trailer.PackInto(buf[end:])
+	}
+	// Zero the padding after the payload
+	clear(buf[offset+uint32(copy(buf[offset:end], p.Payload)) : end])
+	return int(size), nil
+}
+
+// AppendPack appends the packed packet to dst, growing it only when its
+// capacity is exhausted.
+func (p *SignalDataPacket) AppendPack(dst []byte) ([]byte, error) {
+	return appendPack(dst, p)
+}
+
+// UnmarshalBinary is the checked form of Unpack. The buffer must hold the
+// PacketSize declared in the header, and the prologue and trailer must fit
+// within it.
+func (p *SignalDataPacket) UnmarshalBinary(buf []byte) error {
+	var header DataHeader
+	if err := header.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+	if header.PacketType > ExtensionDataStreamID {
+		return &PacketTypeError{Type: "SignalDataPacket", PacketType: header.PacketType}
+	}
+	buf, err := checkPacketSize("SignalDataPacket", buf, header.PacketSize)
+	if err != nil {
+		return err
+	}
+	offset := headerBytes
+	if header.PacketType.HasStreamID() {
+		offset += streamIDBytes
+	}
+	size := offset
+	if header.ClassIdEnable {
+		size += classIdBytes
+	}
+	if header.Tsi != NoneTsi {
+		size += integerTimestampBytes
+	}
+	if header.Tsf != NoneTsf {
+		size += fractionalTimestampBytes
+	}
+	if header.TrailerIncluded {
+		size += trailerBytes
+	}
+	if size > uint32(len(buf)) {
+		return &SizeMismatchError{Type: "SignalDataPacket", Declared: uint32(len(buf)), Actual: size}
+	}
+	if header.ClassIdEnable {
+		var classID ClassID
+		if err := classID.UnmarshalBinary(buf[offset:]); err != nil {
+			return err
+		}
+	}
+	if header.TrailerIncluded {
+		var trailer Trailer
+		if err := trailer.UnmarshalBinary(buf[uint32(len(buf))-trailerBytes:]); err != nil {
+			return err
+		}
+	}
+	p.Unpack(buf)
+	return nil
+}
+
+// Unpack walks the prologue according to the header bits. The Payload slice
+// refers to buf rather than a copy of it.
+func (p *SignalDataPacket) Unpack(buf []byte) {
+	p.Header.Unpack(buf)
+	end := uint32(p.Header.PacketSize) * 4
+	offset := headerBytes
+	if p.Header.PacketType.HasStreamID() {
+		p.StreamID.Unpack(buf[offset:])
+		offset += streamIDBytes
+	} else {
+		p.StreamID = 0
+	}
+	if p.Header.ClassIdEnable {
+		p.ClassID = &ClassID{}
+		p.ClassID.Unpack(buf[offset:])
+		offset += classIdBytes
+	} else {
+		p.ClassID = nil
+	}
+	if p.Header.Tsi != NoneTsi {
+		p.IntegerTimestamp = binary.BigEndian.Uint32(buf[offset:])
+		offset += integerTimestampBytes
+	} else {
+		p.IntegerTimestamp = 0
+	}
+	if p.Header.Tsf != NoneTsf {
+		p.FractionalTimestamp = binary.BigEndian.Uint64(buf[offset:])
+		offset += fractionalTimestampBytes
+	} else {
+		p.FractionalTimestamp = 0
+	}
+	if p.Header.TrailerIncluded {
+		end -= trailerBytes
+		p.Trailer = &Trailer{}
+		p.Trailer.Unpack(buf[end:])
+	} else {
+		p.Trailer = nil
+	}
+	p.Payload = buf[offset:end]
+}
@@ -0,0 +1,284 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignalDataPacketSize(t *testing.T) {
+	p := SignalDataPacket{}
+	assert.Equal(t, headerBytes, p.Size())
+}
+
+func TestSignalDataPacketDefault(t *testing.T) {
+	p := SignalDataPacket{}
+	// Pack
+	packed := p.Pack()
+	expected := []byte{0, 0, 0, 1}
+	assert.Equal(t, expected, packed)
+	// Unpack
+	p.Unpack(packed)
+	assert.Equal(t, SignalData, p.Header.PacketType)
+	assert.EqualValues(t, 1, p.Header.PacketSize)
+	assert.Nil(t, p.ClassID)
+	assert.Nil(t, p.Trailer)
+	assert.Empty(t, p.Payload)
+}
+
+func TestSignalDataPacket(t *testing.T) {
+	cases := []struct {
+		name     string
+		packet   SignalDataPacket
+		expected []byte
+	}{
+		{
+			name: "StreamID",
+			packet: SignalDataPacket{
+				Header:   DataHeader{Header: Header{PacketType: SignalDataStreamID}},
+				StreamID: 0x12345678,
+			},
+			expected: []byte{0x10, 0, 0, 2, 0x12, 0x34, 0x56, 0x78},
+		},
+		{
+			name: "StreamID ignored without PacketType",
+			packet: SignalDataPacket{
+				StreamID: 0x12345678,
+			},
+			expected: []byte{0, 0, 0, 1},
+		},
+		{
+			name: "ClassID",
+			packet: SignalDataPacket{
+				ClassID: &ClassID{Oui: 0xABCDEF, InformationCode: 0x1234, PacketCode: 0x5678},
+			},
+			expected: []byte{
+				0x08, 0, 0, 3,
+				0, 0xAB, 0xCD, 0xEF, 0x12, 0x34, 0x56, 0x78,
+			},
+		},
+		{
+			name: "Timestamps",
+			packet: SignalDataPacket{
+				Header:              DataHeader{Header: Header{Tsi: Utc, Tsf: Picoseconds}},
+				IntegerTimestamp:    0x01020304,
+				FractionalTimestamp: 0x05060708090A0B0C,
+			},
+			expected: []byte{
+				0, 0x60, 0, 4,
+				1, 2, 3, 4,
+				5, 6, 7, 8, 9, 0xA, 0xB, 0xC,
+			},
+		},
+		{
+			name: "Payload padded to word",
+			packet: SignalDataPacket{
+				Payload: []byte{1, 2, 3, 4, 5},
+			},
+			expected: []byte{0, 0, 0, 3, 1, 2, 3, 4, 5, 0, 0, 0},
+		},
+		{
+			name: "Trailer",
+			packet: SignalDataPacket{
+				Payload: []byte{1, 2, 3, 4},
+				Trailer: &Trailer{StateEventIndicators{ValidData: EnableIndicator{Enable: true, Value: true}}},
+			},
+			expected: []byte{0x04, 0, 0, 3, 1, 2, 3, 4, 0x40, 0x04, 0, 0},
+		},
+		{
+			name: "All",
+			packet: SignalDataPacket{
+				Header: DataHeader{
+					Header: Header{
+						PacketType:  SignalDataStreamID,
+						Tsi:         Gps,
+						Tsf:         SampleCount,
+						PacketCount: 5,
+					},
+					Spectrum: true,
+				},
+				StreamID:            1,
+				ClassID:             &ClassID{Oui: 1, InformationCode: 2, PacketCode: 3},
+				IntegerTimestamp:    4,
+				FractionalTimestamp: 5,
+				Payload:             []byte{6, 7, 8, 9},
+				Trailer:             &Trailer{},
+			},
+			expected: []byte{
+				0x1D, 0x95, 0, 9,
+				0, 0, 0, 1,
+				0, 0, 0, 1, 0, 2, 0, 3,
+				0, 0, 0, 4,
+				0, 0, 0, 0, 0, 0, 0, 5,
+				6, 7, 8, 9,
+				0, 0, 0, 0,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := tc.packet
+			// Pack
+			packed := p.Pack()
+			assert.Equal(t, tc.expected, packed)
+			assert.EqualValues(t, len(tc.expected)/4, p.Header.PacketSize)
+			// Unpack
+			unpacked := SignalDataPacket{}
+			unpacked.Unpack(packed)
+			assert.Equal(t, p.Header, unpacked.Header)
+			if p.Header.PacketType.HasStreamID() {
+				assert.Equal(t, p.StreamID, unpacked.StreamID)
+			}
+			assert.Equal(t, p.ClassID, unpacked.ClassID)
+			assert.Equal(t, p.IntegerTimestamp, unpacked.IntegerTimestamp)
+			assert.Equal(t, p.FractionalTimestamp, unpacked.FractionalTimestamp)
+			assert.Equal(t, p.Trailer, unpacked.Trailer)
+			assert.Equal(t, packed[p.prologueSize():p.prologueSize()+uint32(len(unpacked.Payload))], unpacked.Payload)
+		})
+	}
+}
+
+func TestSignalDataPacketUnmarshalBinary(t *testing.T) {
+	p := SignalDataPacket{
+		Header:   DataHeader{Header: Header{PacketType: SignalDataStreamID}},
+		StreamID: 0x12345678,
+		ClassID:  &ClassID{Oui: 0x123456},
+		Payload:  []byte{1, 2, 3, 4},
+		Trailer:  &Trailer{},
+	}
+	valid := p.Pack()
+	unpacked := SignalDataPacket{}
+	assert.NoError(t, unpacked.UnmarshalBinary(valid))
+	assert.Equal(t, p.StreamID, unpacked.StreamID)
+	assert.Equal(t, p.ClassID, unpacked.ClassID)
+	assert.Equal(t, p.Payload, unpacked.Payload)
+
+	corrupt := func(offset int, value byte) []byte {
+		buf := append([]byte{}, valid...)
+		buf[offset] = value
+		return buf
+	}
+	cases := []struct {
+		name     string
+		buf      []byte
+		expected error
+	}{
+		{"Empty", nil, ErrShortBuffer},
+		{"Short of PacketSize", valid[:len(valid)-4], ErrShortBuffer},
+		{"Context packet", corrupt(0, 0x4C), ErrPacketType},
+		{"Reserved class ID bits", corrupt(8, 0x01), ErrReservedBits},
+		{"PacketSize shorter than prologue", corrupt(3, 3), ErrSizeMismatch},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			unpacked := SignalDataPacket{}
+			assert.ErrorIs(t, unpacked.UnmarshalBinary(tc.buf), tc.expected)
+		})
+	}
+}
+
+func fullSignalDataPacket() SignalDataPacket {
+	return SignalDataPacket{
+		Header: DataHeader{Header: Header{
+			PacketType: SignalDataStreamID,
+			Tsi:        Utc,
+			Tsf:        Picoseconds,
+		}},
+		StreamID:            0x12345678,
+		ClassID:             &ClassID{Oui: 0x123456, InformationCode: 1, PacketCode: 2},
+		IntegerTimestamp:    0x01020304,
+		FractionalTimestamp: 0x0102030405060708,
+		Payload:             make([]byte, 1450),
+		Trailer:             &Trailer{StateEventIndicators{ValidData: EnableIndicator{Enable: true, Value: true}}},
+	}
+}
+
+func TestSignalDataPacketPackInto(t *testing.T) {
+	p := fullSignalDataPacket()
+	expected := p.Pack()
+
+	// Stale bytes in a reused buffer, including the payload padding, are
+	// overwritten
+	buf := make([]byte, len(expected)+4)
+	for i := range buf {
+		buf[i] = 0xFF
+	}
+	n, err := p.PackInto(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, len(expected), n)
+	assert.Equal(t, expected, buf[:n])
+	assert.Equal(t, byte(0xFF), buf[n])
+
+	n, err = p.PackInto(buf[:len(expected)-1])
+	assert.ErrorIs(t, err, ErrShortBuffer)
+	assert.Equal(t, 0, n)
+
+	prefix := []byte{0xAA, 0xBB}
+	appended, err := p.AppendPack(prefix)
+	assert.NoError(t, err)
+	assert.Equal(t, prefix, appended[:2])
+	assert.Equal(t, expected, appended[2:])
+}
+
+func TestSignalDataPacketPackIntoAllocs(t *testing.T) {
+	p := fullSignalDataPacket()
+	buf := make([]byte, p.Size())
+	allocs := testing.AllocsPerRun(100, func() {
+		p.PackInto(buf)
+	})
+	assert.Zero(t, allocs)
+
+	dst := make([]byte, 0, p.Size())
+	allocs = testing.AllocsPerRun(100, func() {
+		dst, _ = p.AppendPack(dst[:0])
+	})
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkSignalDataPacketPack(b *testing.B) {
+	p := fullSignalDataPacket()
+	b.ReportAllocs()
+	b.SetBytes(int64(p.Size()))
+	for i := 0; i < b.N; i++ {
+		p.Pack()
+	}
+}
+
+func BenchmarkSignalDataPacketPackInto(b *testing.B) {
+	p := fullSignalDataPacket()
+	buf := make([]byte, p.Size())
+	b.ReportAllocs()
+	b.SetBytes(int64(p.Size()))
+	for i := 0; i < b.N; i++ {
+		p.PackInto(buf)
+	}
+}
+
+func BenchmarkSignalDataPacketAppendPack(b *testing.B) {
+	p := fullSignalDataPacket()
+	var dst []byte
+	b.ReportAllocs()
+	b.SetBytes(int64(p.Size()))
+	for i := 0; i < b.N; i++ {
+		dst, _ = p.AppendPack(dst[:0])
+	}
+}
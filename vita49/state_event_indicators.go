@@ -30,30 +30,48 @@ type StateEventIndicators struct {
 	SampleLoss        EnableIndicator
 }
 
-func (t *StateEventIndicators) Size() uint32 {
+func (s *StateEventIndicators) Size() uint32 {
 	return 4
 }
 
 func (s *StateEventIndicators) Pack() []byte {
 	buf := make([]byte, s.Size())
-	s.CalibratedTime.Pack(buf, 31, 19)
-	s.ValidData.Pack(buf, 30, 18)
-	s.ReferenceLock.Pack(buf, 29, 17)
-	s.AgcMgc.Pack(buf, 28, 16)
-	s.DetectedSignal.Pack(buf, 27, 15)
-	s.SpectralInversion.Pack(buf, 26, 14)
-	s.OverRange.Pack(buf, 25, 13)
-	s.SampleLoss.Pack(buf, 24, 12)
+	s.PackInto(buf)
 	return buf
 }
 
+func (s *StateEventIndicators) PackInto(buf []byte) (int, error) {
+	if err := checkSize("StateEventIndicators", buf, s.Size()); err != nil {
+		return 0, err
+	}
+	buf = buf[:s.Size()]
+	clear(buf)
+	s.CalibratedTime.PackBits(buf, 31, 19)
+	s.ValidData.PackBits(buf, 30, 18)
+	s.ReferenceLock.PackBits(buf, 29, 17)
+	s.AgcMgc.PackBits(buf, 28, 16)
+	s.DetectedSignal.PackBits(buf, 27, 15)
+	s.SpectralInversion.PackBits(buf, 26, 14)
+	s.OverRange.PackBits(buf, 25, 13)
+	s.SampleLoss.PackBits(buf, 24, 12)
+	return len(buf), nil
+}
+
 func (s *StateEventIndicators) Unpack(buf []byte) {
-	s.CalibratedTime.Unpack(buf, 31, 19)
-	s.ValidData.Unpack(buf, 30, 18)
-	s.ReferenceLock.Unpack(buf, 29, 17)
-	s.AgcMgc.Unpack(buf, 28, 16)
-	s.DetectedSignal.Unpack(buf, 27, 15)
-	s.SpectralInversion.Unpack(buf, 26, 14)
-	s.OverRange.Unpack(buf, 25, 13)
-	s.SampleLoss.Unpack(buf, 24, 12)
+	s.CalibratedTime.UnpackBits(buf, 31, 19)
+	s.ValidData.UnpackBits(buf, 30, 18)
+	s.ReferenceLock.UnpackBits(buf, 29, 17)
+	s.AgcMgc.UnpackBits(buf, 28, 16)
+	s.DetectedSignal.UnpackBits(buf, 27, 15)
+	s.SpectralInversion.UnpackBits(buf, 26, 14)
+	s.OverRange.UnpackBits(buf, 25, 13)
+	s.SampleLoss.UnpackBits(buf, 24, 12)
+}
+
+func (s *StateEventIndicators) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("StateEventIndicators", buf, s.Size()); err != nil {
+		return err
+	}
+	s.Unpack(buf)
+	return nil
 }
@@ -0,0 +1,70 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import "encoding/binary"
+
+const (
+	streamIDBytes = uint32(4)
+)
+
+// StreamID
+// Identifies the stream a packet belongs to. Every context and command packet
+// carries one; a data packet carries one when its PacketType says so.
+type StreamID uint32
+
+func (s *StreamID) Size() uint32 {
+	return streamIDBytes
+}
+
+func (s *StreamID) Pack() []byte {
+	buf := make([]byte, s.Size())
+	s.PackInto(buf)
+	return buf
+}
+
+func (s *StreamID) PackInto(buf []byte) (int, error) {
+	if err := checkSize("StreamID", buf, s.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint32(buf, uint32(*s))
+	return int(s.Size()), nil
+}
+
+func (s *StreamID) Unpack(buf []byte) {
+	*s = StreamID(binary.BigEndian.Uint32(buf))
+}
+
+func (s *StreamID) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("StreamID", buf, s.Size()); err != nil {
+		return err
+	}
+	s.Unpack(buf)
+	return nil
+}
+
+// HasStreamID reports whether packets of this type carry a Stream ID
+func (t PacketType) HasStreamID() bool {
+	switch t {
+	case SignalDataStreamID, ExtensionDataStreamID, Context, ExtensionContext, Command, ExtensionCommand:
+		return true
+	}
+	return false
+}
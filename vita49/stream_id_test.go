@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamIDSize(t *testing.T) {
+	s := StreamID(0)
+	assert.Equal(t, streamIDBytes, s.Size())
+}
+
+func TestStreamID(t *testing.T) {
+	s := StreamID(0x12345678)
+	packed := s.Pack()
+	assert.Equal(t, []byte{0x12, 0x34, 0x56, 0x78}, packed)
+
+	var unpacked StreamID
+	assert.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, s, unpacked)
+	assert.ErrorIs(t, unpacked.UnmarshalBinary(packed[:3]), ErrShortBuffer)
+}
+
+func TestPacketTypeHasStreamID(t *testing.T) {
+	cases := []struct {
+		packetType PacketType
+		expected   bool
+	}{
+		{SignalData, false},
+		{SignalDataStreamID, true},
+		{ExtensionData, false},
+		{ExtensionDataStreamID, true},
+		{Context, true},
+		{ExtensionContext, true},
+		{Command, true},
+		{ExtensionCommand, true},
+		{PacketType(8), false},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, tc.packetType.HasStreamID(), "PacketType %d", tc.packetType)
+	}
+}
+
+func TestStreamIDRoundTrip(t *testing.T) {
+	data := SignalDataPacket{
+		Header:   DataHeader{Header: Header{PacketType: ExtensionDataStreamID}},
+		StreamID: 0xCAFEF00D,
+		Payload:  []byte{1, 2, 3, 4},
+	}
+	packed := data.Pack()
+	assert.Equal(t, []byte{0x30, 0, 0, 3, 0xCA, 0xFE, 0xF0, 0x0D, 1, 2, 3, 4}, packed)
+	unpackedData := SignalDataPacket{}
+	assert.NoError(t, unpackedData.UnmarshalBinary(packed))
+	assert.Equal(t, data.StreamID, unpackedData.StreamID)
+
+	// Without a Stream ID PacketType the first payload word is not mistaken
+	// for a Stream ID
+	data.Header.PacketType = ExtensionData
+	packed = data.Pack()
+	assert.Equal(t, []byte{0x20, 0, 0, 2, 1, 2, 3, 4}, packed)
+	assert.NoError(t, unpackedData.UnmarshalBinary(packed))
+	assert.Equal(t, StreamID(0), unpackedData.StreamID)
+	assert.Equal(t, data.Payload, unpackedData.Payload)
+
+	context := ContextPacket{StreamID: 0xCAFEF00D}
+	unpackedContext := ContextPacket{}
+	assert.NoError(t, unpackedContext.UnmarshalBinary(context.Pack()))
+	assert.Equal(t, context.StreamID, unpackedContext.StreamID)
+
+	control := ControlPacket{CommandPrologue: CommandPrologue{StreamID: 0xCAFEF00D}}
+	unpackedControl := ControlPacket{}
+	assert.NoError(t, unpackedControl.UnmarshalBinary(control.Pack()))
+	assert.Equal(t, control.StreamID, unpackedControl.StreamID)
+}
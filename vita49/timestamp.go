@@ -0,0 +1,261 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	picosecondsPerSecond       = 1_000_000_000_000
+	picosecondsPerNanosecond   = 1_000
+	maxIntegerTimestampSeconds = math.MaxUint32
+)
+
+// GpsEpoch is the start of GPS time, 6 January 1980 00:00:00 UTC
+var GpsEpoch = time.Date(1980, time.January, 6, 0, 0, 0, 0, time.UTC)
+
+// LeapSeconds
+// The number of seconds GPS time is ahead of UTC. It is applied when
+// converting GPS timestamps to and from time.Time, which follows UTC.
+type LeapSeconds int
+
+// CurrentLeapSeconds has been the GPS-UTC offset since 1 January 2017
+const CurrentLeapSeconds LeapSeconds = 18
+
+// PicosecondDuration
+// A signed span of time in picoseconds. It holds about 106 days either side
+// of zero.
+type PicosecondDuration int64
+
+// Duration returns p truncated to nanoseconds
+func (p PicosecondDuration) Duration() time.Duration {
+	return time.Duration(p / picosecondsPerNanosecond)
+}
+
+// Timestamp
+// The integer and fractional timestamp words of a packet prologue or of an
+// Ephemeris or Geolocation field. Tsi and Tsf say which words are present
+// and how they are interpreted; they are carried in the header, not packed
+// with the timestamp words.
+type Timestamp struct {
+	Tsi        Tsi
+	Tsf        Tsf
+	Integer    uint32
+	Fractional uint64
+}
+
+func (t *Timestamp) Size() uint32 {
+	var size uint32
+	if t.Tsi != NoneTsi {
+		size += integerTimestampBytes
+	}
+	if t.Tsf != NoneTsf {
+		size += fractionalTimestampBytes
+	}
+	return size
+}
+
+func (t *Timestamp) Pack() []byte {
+	buf := make([]byte, t.Size())
+	t.PackInto(buf)
+	return buf
+}
+
+func (t *Timestamp) PackInto(buf []byte) (int, error) {
+	if err := checkSize("Timestamp", buf, t.Size()); err != nil {
+		return 0, err
+	}
+	var offset uint32
+	if t.Tsi != NoneTsi {
+		binary.BigEndian.PutUint32(buf[offset:], t.Integer)
+		offset += integerTimestampBytes
+	}
+	if t.Tsf != NoneTsf {
+		binary.BigEndian.PutUint64(buf[offset:], t.Fractional)
+		offset += fractionalTimestampBytes
+	}
+	return int(offset), nil
+}
+
+// Unpack reads the words enabled by Tsi and Tsf, which must be set first
+func (t *Timestamp) Unpack(buf []byte) {
+	var offset uint32
+	t.Integer = 0
+	if t.Tsi != NoneTsi {
+		t.Integer = binary.BigEndian.Uint32(buf[offset:])
+		offset += integerTimestampBytes
+	}
+	t.Fractional = 0
+	if t.Tsf != NoneTsf {
+		t.Fractional = binary.BigEndian.Uint64(buf[offset:])
+	}
+}
+
+func (t *Timestamp) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("Timestamp", buf, t.Size()); err != nil {
+		return err
+	}
+	if t.Tsf == Picoseconds && t.Tsi != NoneTsi {
+		if frac := binary.BigEndian.Uint64(buf[t.Size()-fractionalTimestampBytes:]); frac >= picosecondsPerSecond {
+			return &TimestampError{Tsi: t.Tsi, Tsf: t.Tsf, Reason: fmt.Sprintf("fractional picoseconds %d not below one second", frac)}
+		}
+	}
+	t.Unpack(buf)
+	return nil
+}
+
+// TimestampFromTime returns a Utc or Gps timestamp with picosecond
+// fractional time holding tm. GPS timestamps are offset by leap seconds from
+// the UTC time held by tm.
+func TimestampFromTime(tm time.Time, tsi Tsi, leap LeapSeconds) (Timestamp, error) {
+	var seconds int64
+	switch tsi {
+	case Utc:
+		seconds = tm.Unix()
+	case Gps:
+		seconds = tm.Unix() - GpsEpoch.Unix() + int64(leap)
+	default:
+		return Timestamp{}, &TimestampError{Tsi: tsi, Tsf: Picoseconds, Reason: "only Utc and Gps convert to time.Time"}
+	}
+	if seconds < 0 || seconds > maxIntegerTimestampSeconds {
+		return Timestamp{}, &TimestampError{Tsi: tsi, Tsf: Picoseconds, Reason: fmt.Sprintf("%v is outside the integer timestamp range", tm)}
+	}
+	return Timestamp{
+		Tsi:        tsi,
+		Tsf:        Picoseconds,
+		Integer:    uint32(seconds),
+		Fractional: uint64(tm.Nanosecond()) * picosecondsPerNanosecond,
+	}, nil
+}
+
+// Time returns the UTC time held by a Utc or Gps timestamp, truncated to
+// nanoseconds. The fractional time must be in picoseconds or absent.
+func (t Timestamp) Time(leap LeapSeconds) (time.Time, error) {
+	if t.Tsf != NoneTsf && t.Tsf != Picoseconds {
+		return time.Time{}, &TimestampError{Tsi: t.Tsi, Tsf: t.Tsf, Reason: "fractional time must be in picoseconds"}
+	}
+	var nanoseconds int64
+	if t.Tsf == Picoseconds {
+		nanoseconds = int64(t.Fractional / picosecondsPerNanosecond)
+	}
+	switch t.Tsi {
+	case Utc:
+		return time.Unix(int64(t.Integer), nanoseconds).UTC(), nil
+	case Gps:
+		return time.Unix(GpsEpoch.Unix()+int64(t.Integer)-int64(leap), nanoseconds).UTC(), nil
+	}
+	return time.Time{}, &TimestampError{Tsi: t.Tsi, Tsf: t.Tsf, Reason: "only Utc and Gps convert to time.Time"}
+}
+
+// ToPicoseconds converts a SampleCount fractional time to Picoseconds given
+// the sample rate in Hz. Whole seconds of samples carry into the integer
+// time. A Picoseconds timestamp is returned as it is.
+func (t Timestamp) ToPicoseconds(sampleRate float64) (Timestamp, error) {
+	switch t.Tsf {
+	case Picoseconds:
+		return t, nil
+	case SampleCount:
+	default:
+		return Timestamp{}, &TimestampError{Tsi: t.Tsi, Tsf: t.Tsf, Reason: "fractional time must be a sample count"}
+	}
+	if !(sampleRate > 0) {
+		return Timestamp{}, &TimestampError{Tsi: t.Tsi, Tsf: t.Tsf, Reason: fmt.Sprintf("invalid sample rate %v", sampleRate)}
+	}
+	seconds := math.Floor(float64(t.Fractional) / sampleRate)
+	samples := float64(t.Fractional) - seconds*sampleRate
+	t.Integer += uint32(seconds)
+	t.Fractional = uint64(math.Round(samples * picosecondsPerSecond / sampleRate))
+	if t.Fractional >= picosecondsPerSecond {
+		t.Integer++
+		t.Fractional -= picosecondsPerSecond
+	}
+	t.Tsf = Picoseconds
+	return t, nil
+}
+
+// ToSampleCount converts a Picoseconds fractional time to the number of
+// samples since the integer second given the sample rate in Hz, rounding to
+// the nearest sample. A SampleCount timestamp is returned as it is.
+func (t Timestamp) ToSampleCount(sampleRate float64) (Timestamp, error) {
+	switch t.Tsf {
+	case SampleCount:
+		return t, nil
+	case Picoseconds:
+	default:
+		return Timestamp{}, &TimestampError{Tsi: t.Tsi, Tsf: t.Tsf, Reason: "fractional time must be in picoseconds"}
+	}
+	if !(sampleRate > 0) {
+		return Timestamp{}, &TimestampError{Tsi: t.Tsi, Tsf: t.Tsf, Reason: fmt.Sprintf("invalid sample rate %v", sampleRate)}
+	}
+	t.Fractional = uint64(math.Round(float64(t.Fractional) * sampleRate / picosecondsPerSecond))
+	t.Tsf = SampleCount
+	return t, nil
+}
+
+// Add returns t moved by p. The fractional time is taken to be in
+// picoseconds and the integer time wraps as the packed word would.
+func (t Timestamp) Add(p PicosecondDuration) Timestamp {
+	seconds := int64(p) / picosecondsPerSecond
+	fractional := int64(t.Fractional) + int64(p)%picosecondsPerSecond
+	if fractional < 0 {
+		fractional += picosecondsPerSecond
+		seconds--
+	} else if fractional >= picosecondsPerSecond {
+		fractional -= picosecondsPerSecond
+		seconds++
+	}
+	t.Integer = uint32(int64(t.Integer) + seconds)
+	t.Fractional = uint64(fractional)
+	return t
+}
+
+// Sub returns t-u. Both fractional times are taken to be in picoseconds;
+// the result saturates beyond the range of PicosecondDuration.
+func (t Timestamp) Sub(u Timestamp) PicosecondDuration {
+	seconds := int64(t.Integer) - int64(u.Integer)
+	fractional := int64(t.Fractional) - int64(u.Fractional)
+	const maxSeconds = math.MaxInt64 / picosecondsPerSecond
+	if seconds >= maxSeconds {
+		return math.MaxInt64
+	} else if seconds <= -maxSeconds {
+		return math.MinInt64
+	}
+	return PicosecondDuration(seconds*picosecondsPerSecond + fractional)
+}
+
+// Compare returns -1, 0 or +1 as t is before, equal to or after u, comparing
+// the integer time and then the fractional time.
+func (t Timestamp) Compare(u Timestamp) int {
+	switch {
+	case t.Integer < u.Integer:
+		return -1
+	case t.Integer > u.Integer:
+		return 1
+	case t.Fractional < u.Fractional:
+		return -1
+	case t.Fractional > u.Fractional:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,213 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimestampSize(t *testing.T) {
+	cases := []struct {
+		tsi      Tsi
+		tsf      Tsf
+		expected uint32
+	}{
+		{NoneTsi, NoneTsf, 0},
+		{Utc, NoneTsf, 4},
+		{NoneTsi, FreeRunning, 8},
+		{Gps, Picoseconds, 12},
+	}
+	for _, tc := range cases {
+		ts := Timestamp{Tsi: tc.tsi, Tsf: tc.tsf}
+		assert.Equal(t, tc.expected, ts.Size())
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	cases := []struct {
+		name      string
+		timestamp Timestamp
+		expected  []byte
+	}{
+		{
+			name:      "Integer only",
+			timestamp: Timestamp{Tsi: Utc, Integer: 0x01020304},
+			expected:  []byte{1, 2, 3, 4},
+		},
+		{
+			name:      "Fractional only",
+			timestamp: Timestamp{Tsf: SampleCount, Fractional: 0x0102030405060708},
+			expected:  []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		},
+		{
+			name:      "Integer and fractional",
+			timestamp: Timestamp{Tsi: Gps, Tsf: Picoseconds, Integer: 1, Fractional: 2},
+			expected:  []byte{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			packed := tc.timestamp.Pack()
+			assert.Equal(t, tc.expected, packed)
+			unpacked := Timestamp{Tsi: tc.timestamp.Tsi, Tsf: tc.timestamp.Tsf}
+			assert.NoError(t, unpacked.UnmarshalBinary(packed))
+			assert.Equal(t, tc.timestamp, unpacked)
+		})
+	}
+
+	ts := Timestamp{Tsi: Utc, Tsf: Picoseconds}
+	assert.ErrorIs(t, ts.UnmarshalBinary([]byte{0, 0, 0, 1}), ErrShortBuffer)
+	tooLarge := (&Timestamp{Tsi: Utc, Tsf: Picoseconds, Fractional: picosecondsPerSecond}).Pack()
+	assert.ErrorIs(t, ts.UnmarshalBinary(tooLarge), ErrTimestamp)
+}
+
+func TestTimestampTime(t *testing.T) {
+	tm := time.Date(2017, time.January, 1, 0, 0, 0, 123456789, time.UTC)
+
+	utc, err := TimestampFromTime(tm, Utc, CurrentLeapSeconds)
+	assert.NoError(t, err)
+	assert.Equal(t, Timestamp{Tsi: Utc, Tsf: Picoseconds, Integer: 1483228800, Fractional: 123456789000}, utc)
+	back, err := utc.Time(CurrentLeapSeconds)
+	assert.NoError(t, err)
+	assert.Equal(t, tm, back)
+
+	gps, err := TimestampFromTime(tm, Gps, CurrentLeapSeconds)
+	assert.NoError(t, err)
+	assert.Equal(t, Timestamp{Tsi: Gps, Tsf: Picoseconds, Integer: 1167264018, Fractional: 123456789000}, gps)
+	back, err = gps.Time(CurrentLeapSeconds)
+	assert.NoError(t, err)
+	assert.Equal(t, tm, back)
+
+	// Leap seconds are configurable
+	gps, err = TimestampFromTime(GpsEpoch, Gps, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), gps.Integer)
+	back, err = (Timestamp{Tsi: Gps, Integer: 5}).Time(5)
+	assert.NoError(t, err)
+	assert.Equal(t, GpsEpoch, back)
+
+	_, err = TimestampFromTime(tm, Other, CurrentLeapSeconds)
+	assert.ErrorIs(t, err, ErrTimestamp)
+	_, err = TimestampFromTime(GpsEpoch.Add(-time.Hour), Gps, 0)
+	assert.ErrorIs(t, err, ErrTimestamp)
+	_, err = TimestampFromTime(time.Unix(math.MaxUint32+1, 0), Utc, 0)
+	assert.ErrorIs(t, err, ErrTimestamp)
+	_, err = (Timestamp{Tsi: Utc, Tsf: SampleCount}).Time(0)
+	assert.ErrorIs(t, err, ErrTimestamp)
+	_, err = (Timestamp{Tsi: Other, Tsf: Picoseconds}).Time(0)
+	assert.ErrorIs(t, err, ErrTimestamp)
+}
+
+func TestTimestampSampleCount(t *testing.T) {
+	samples := Timestamp{Tsi: Utc, Tsf: SampleCount, Integer: 10, Fractional: 250000}
+	picos, err := samples.ToPicoseconds(1e6)
+	assert.NoError(t, err)
+	assert.Equal(t, Timestamp{Tsi: Utc, Tsf: Picoseconds, Integer: 10, Fractional: 250000000000}, picos)
+
+	back, err := picos.ToSampleCount(1e6)
+	assert.NoError(t, err)
+	assert.Equal(t, samples, back)
+
+	// Whole seconds of samples carry into the integer time
+	samples.Fractional = 1500000
+	picos, err = samples.ToPicoseconds(1e6)
+	assert.NoError(t, err)
+	assert.Equal(t, Timestamp{Tsi: Utc, Tsf: Picoseconds, Integer: 11, Fractional: 500000000000}, picos)
+
+	// Non-integral rates round to the nearest picosecond and sample
+	samples = Timestamp{Tsi: Utc, Tsf: SampleCount, Fractional: 1}
+	picos, err = samples.ToPicoseconds(3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(333333333333), picos.Fractional)
+	back, err = picos.ToSampleCount(3)
+	assert.NoError(t, err)
+	assert.Equal(t, samples, back)
+
+	_, err = samples.ToPicoseconds(0)
+	assert.ErrorIs(t, err, ErrTimestamp)
+	_, err = (Timestamp{Tsf: FreeRunning}).ToPicoseconds(1)
+	assert.ErrorIs(t, err, ErrTimestamp)
+	_, err = (Timestamp{Tsf: FreeRunning}).ToSampleCount(1)
+	assert.ErrorIs(t, err, ErrTimestamp)
+}
+
+func TestTimestampArithmetic(t *testing.T) {
+	ts := Timestamp{Tsi: Utc, Tsf: Picoseconds, Integer: 10, Fractional: 900000000000}
+
+	later := ts.Add(200000000001)
+	assert.Equal(t, uint32(11), later.Integer)
+	assert.Equal(t, uint64(100000000001), later.Fractional)
+	assert.Equal(t, PicosecondDuration(200000000001), later.Sub(ts))
+	assert.Equal(t, PicosecondDuration(-200000000001), ts.Sub(later))
+
+	earlier := ts.Add(-2*picosecondsPerSecond - 950000000000)
+	assert.Equal(t, uint32(7), earlier.Integer)
+	assert.Equal(t, uint64(950000000000), earlier.Fractional)
+	assert.Equal(t, ts, earlier.Add(ts.Sub(earlier)))
+
+	assert.Equal(t, 0, ts.Compare(ts))
+	assert.Equal(t, -1, ts.Compare(later))
+	assert.Equal(t, 1, ts.Compare(earlier))
+	assert.Equal(t, -1, ts.Compare(ts.Add(1)))
+
+	far := Timestamp{Integer: math.MaxUint32}
+	assert.Equal(t, PicosecondDuration(math.MaxInt64), far.Sub(Timestamp{}))
+	assert.Equal(t, PicosecondDuration(math.MinInt64), Timestamp{}.Sub(far))
+
+	assert.Equal(t, 1500*time.Nanosecond, PicosecondDuration(1500999).Duration())
+}
+
+func TestPacketTimestamp(t *testing.T) {
+	ts := Timestamp{Tsi: Gps, Tsf: Picoseconds, Integer: 100, Fractional: 5}
+
+	data := SignalDataPacket{}
+	data.SetTimestamp(ts)
+	unpackedData := SignalDataPacket{}
+	assert.NoError(t, unpackedData.UnmarshalBinary(data.Pack()))
+	assert.Equal(t, ts, unpackedData.Timestamp())
+
+	context := ContextPacket{}
+	context.SetTimestamp(ts)
+	unpackedContext := ContextPacket{}
+	assert.NoError(t, unpackedContext.UnmarshalBinary(context.Pack()))
+	assert.Equal(t, ts, unpackedContext.Timestamp())
+
+	control := ControlPacket{}
+	control.SetTimestamp(ts)
+	unpackedControl := ControlPacket{}
+	assert.NoError(t, unpackedControl.UnmarshalBinary(control.Pack()))
+	assert.Equal(t, ts, unpackedControl.Timestamp())
+
+	ephemeris := Ephemeris{}
+	ephemeris.SetTimestamp(ts)
+	unpackedEphemeris := Ephemeris{}
+	unpackedEphemeris.Unpack(ephemeris.Pack())
+	assert.Equal(t, ts, unpackedEphemeris.Timestamp())
+
+	geolocation := Geolocation{}
+	geolocation.SetTimestamp(ts)
+	unpackedGeolocation := Geolocation{}
+	unpackedGeolocation.Unpack(geolocation.Pack())
+	assert.Equal(t, ts, unpackedGeolocation.Timestamp())
+}
@@ -0,0 +1,328 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	// VrlFrameAlignmentWord starts every VRL frame ("VRLP")
+	VrlFrameAlignmentWord = uint32(0x56524C50)
+	// VrlNoCrc is the trailer of a VRL frame that carries no CRC ("VEND")
+	VrlNoCrc = uint32(0x56454E44)
+	// MaxVrlFrameBytes is the largest frame the 20-bit Frame Size describes
+	MaxVrlFrameBytes = uint32(1<<20-1) * 4
+
+	vrlHeaderBytes  = uint32(8)
+	vrlTrailerBytes = uint32(4)
+	vrlMinFrameSize = vrlHeaderBytes + vrlTrailerBytes
+)
+
+// VrlHeader
+// The frame alignment word, Frame Count and Frame Size that start a VITA 49.1
+// VRL frame. FrameSize counts 32-bit words, including the header and trailer.
+type VrlHeader struct {
+	FrameCount uint16
+	FrameSize  uint32
+}
+
+func (h *VrlHeader) Size() uint32 {
+	return vrlHeaderBytes
+}
+
+func (h *VrlHeader) Pack() []byte {
+	buf := make([]byte, h.Size())
+	h.PackInto(buf)
+	return buf
+}
+
+func (h *VrlHeader) PackInto(buf []byte) (int, error) {
+	if err := checkSize("VrlHeader", buf, h.Size()); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint32(buf[0:], VrlFrameAlignmentWord)
+	binary.BigEndian.PutUint32(buf[4:], uint32(h.FrameCount&0xFFF)<<20|(h.FrameSize&0xFFFFF))
+	return int(h.Size()), nil
+}
+
+func (h *VrlHeader) Unpack(buf []byte) {
+	word := binary.BigEndian.Uint32(buf[4:])
+	h.FrameCount = uint16(word >> 20)
+	h.FrameSize = word & 0xFFFFF
+}
+
+func (h *VrlHeader) UnmarshalBinary(buf []byte) error {
+	if err := checkSize("VrlHeader", buf, h.Size()); err != nil {
+		return err
+	}
+	if word := binary.BigEndian.Uint32(buf); word != VrlFrameAlignmentWord {
+		return &FrameAlignmentError{Word: word}
+	}
+	h.Unpack(buf)
+	return nil
+}
+
+// VrlFrame
+// A VRL frame carrying whole VITA 49 packets. When Crc is set the trailer
+// holds a CRC-32 (IEEE polynomial) computed over the frame up to the
+// trailer, otherwise it holds VrlNoCrc.
+type VrlFrame struct {
+	Header  VrlHeader
+	Packets [][]byte
+	Crc     bool
+}
+
+func (f *VrlFrame) Size() uint32 {
+	size := vrlMinFrameSize
+	for _, packet := range f.Packets {
+		size += uint32(len(packet))
+	}
+	return size
+}
+
+// Pack derives FrameSize from the packets before packing. It panics when the
+// frame cannot be packed, as PackInto describes.
+func (f *VrlFrame) Pack() []byte {
+	return mustPack(f)
+}
+
+// PackInto packs the frame into buf and returns the number of bytes written.
+// The header is derived as for Pack. Each packet must be whole words and
+// exactly the PacketSize declared in its header, and the frame must fit in
+// MaxVrlFrameBytes.
+func (f *VrlFrame) PackInto(buf []byte) (int, error) {
+	size := f.Size()
+	if err := checkSize("VrlFrame", buf, size); err != nil {
+		return 0, err
+	}
+	if size > MaxVrlFrameBytes {
+		return 0, &SizeMismatchError{Type: "VrlFrame", Declared: MaxVrlFrameBytes, Actual: size}
+	}
+	if err := f.checkPackets(); err != nil {
+		return 0, err
+	}
+	buf = buf[:size]
+	f.Header.FrameSize = size / 4
+	f.Header.PackInto(buf)
+	offset := vrlHeaderBytes
+	for _, packet := range f.Packets {
+		offset += uint32(copy(buf[offset:], packet))
+	}
+	trailer := VrlNoCrc
+	if f.Crc {
+		trailer = crc32.ChecksumIEEE(buf[:offset])
+	}
+	binary.BigEndian.PutUint32(buf[offset:], trailer)
+	return int(size), nil
+}
+
+// checkPackets checks each packet is whole words and exactly the PacketSize
+// declared in its header
+func (f *VrlFrame) checkPackets() error {
+	for _, packet := range f.Packets {
+		size := uint32(len(packet))
+		if size%4 != 0 || size < headerBytes {
+			return &SizeMismatchError{Type: "VrlFrame packet", Declared: size &^ 3, Actual: size}
+		}
+		if declared := uint32(binary.BigEndian.Uint16(packet[2:])) * 4; declared != size {
+			return &SizeMismatchError{Type: "VrlFrame packet", Declared: declared, Actual: size}
+		}
+	}
+	return nil
+}
+
+// AppendPack appends the packed frame to dst, growing it only when its
+// capacity is exhausted. dst is returned unchanged when PackInto fails.
+func (f *VrlFrame) AppendPack(dst []byte) ([]byte, error) {
+	return appendPack(dst, f)
+}
+
+// Unpack splits the frame into packets by the PacketSize in each packet
+// header. The Packets slices refer to buf rather than copies of it. A CRC
+// that happens to equal VrlNoCrc is read as no CRC.
+func (f *VrlFrame) Unpack(buf []byte) {
+	f.Header.Unpack(buf)
+	end := f.Header.FrameSize*4 - vrlTrailerBytes
+	f.Crc = binary.BigEndian.Uint32(buf[end:]) != VrlNoCrc
+	f.Packets = f.Packets[:0]
+	for offset := vrlHeaderBytes; offset < end; {
+		size := uint32(binary.BigEndian.Uint16(buf[offset+2:])) * 4
+		if size == 0 {
+			break
+		}
+		f.Packets = append(f.Packets, buf[offset:offset+size])
+		offset += size
+	}
+}
+
+// UnmarshalBinary is the checked form of Unpack. It checks the frame
+// alignment word and CRC, and that the packets exactly fill the frame.
+func (f *VrlFrame) UnmarshalBinary(buf []byte) error {
+	var header VrlHeader
+	if err := header.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+	size := header.FrameSize * 4
+	if size < vrlMinFrameSize {
+		return &SizeMismatchError{Type: "VrlFrame", Declared: size, Actual: vrlMinFrameSize}
+	}
+	if err := checkSize("VrlFrame", buf, size); err != nil {
+		return err
+	}
+	end := size - vrlTrailerBytes
+	if trailer := binary.BigEndian.Uint32(buf[end:]); trailer != VrlNoCrc {
+		if crc := crc32.ChecksumIEEE(buf[:end]); crc != trailer {
+			return &CrcError{FrameCount: header.FrameCount, Received: trailer, Computed: crc}
+		}
+	}
+	for offset := vrlHeaderBytes; offset < end; {
+		packetSize := uint32(binary.BigEndian.Uint16(buf[offset+2:])) * 4
+		if packetSize == 0 || packetSize > end-offset {
+			return &SizeMismatchError{Type: "VrlFrame", Declared: size, Actual: offset + max(packetSize, headerBytes) + vrlTrailerBytes}
+		}
+		offset += packetSize
+	}
+	f.Unpack(buf)
+	return nil
+}
+
+// VrlReader
+// Reads VRL frames from a byte stream. After a frame alignment error the
+// reader scans forward for the next frame alignment word, and after a CRC
+// error it moves on to the next frame.
+type VrlReader struct {
+	r       *bufio.Reader
+	buf     []byte
+	frame   VrlFrame
+	packets [][]byte
+	aligned bool
+}
+
+func NewVrlReader(r io.Reader) *VrlReader {
+	return &VrlReader{r: bufio.NewReader(r), aligned: true}
+}
+
+// ReadFrame reads the next frame. The frame and its packets refer to a
+// buffer that the next call overwrites. It returns io.EOF when the stream
+// ends between frames and io.ErrUnexpectedEOF when it ends within one.
+func (r *VrlReader) ReadFrame() (*VrlFrame, error) {
+	r.packets = nil
+	if !r.aligned {
+		if err := r.align(); err != nil {
+			return nil, err
+		}
+	}
+	if len(r.buf) < int(vrlHeaderBytes) {
+		r.buf = make([]byte, 4096)
+	}
+	if _, err := io.ReadFull(r.r, r.buf[:vrlHeaderBytes]); err != nil {
+		return nil, err
+	}
+	var header VrlHeader
+	if err := header.UnmarshalBinary(r.buf); err != nil {
+		r.aligned = false
+		return nil, err
+	}
+	size := header.FrameSize * 4
+	if size < vrlMinFrameSize {
+		r.aligned = false
+		return nil, &SizeMismatchError{Type: "VrlFrame", Declared: size, Actual: vrlMinFrameSize}
+	}
+	if uint32(len(r.buf)) < size {
+		r.buf = append(r.buf[:vrlHeaderBytes], make([]byte, size-vrlHeaderBytes)...)
+	}
+	if _, err := io.ReadFull(r.r, r.buf[vrlHeaderBytes:size]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if err := r.frame.UnmarshalBinary(r.buf[:size]); err != nil {
+		return nil, err
+	}
+	return &r.frame, nil
+}
+
+// ReadPacket returns the next packet, reading a frame when the packets of
+// the last one are exhausted. The packet refers to a buffer that the next
+// frame read overwrites.
+func (r *VrlReader) ReadPacket() ([]byte, error) {
+	for len(r.packets) == 0 {
+		frame, err := r.ReadFrame()
+		if err != nil {
+			return nil, err
+		}
+		r.packets = frame.Packets
+	}
+	packet := r.packets[0]
+	r.packets = r.packets[1:]
+	return packet, nil
+}
+
+// align discards bytes up to the next frame alignment word
+func (r *VrlReader) align() error {
+	for {
+		word, err := r.r.Peek(4)
+		if err != nil {
+			if err == io.EOF && len(word) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if binary.BigEndian.Uint32(word) == VrlFrameAlignmentWord {
+			r.aligned = true
+			return nil
+		}
+		r.r.Discard(1)
+	}
+}
+
+// VrlWriter
+// Writes VITA 49 packets to a byte stream in VRL frames, numbering the
+// frames with the 12-bit Frame Count.
+type VrlWriter struct {
+	w     io.Writer
+	crc   bool
+	count uint16
+	buf   []byte
+}
+
+// NewVrlWriter returns a writer whose frames carry a CRC when crc is set
+func NewVrlWriter(w io.Writer, crc bool) *VrlWriter {
+	return &VrlWriter{w: w, crc: crc}
+}
+
+// WriteFrame writes packets as a single frame with one Write call. Each
+// packet must be exactly the PacketSize declared in its header.
+func (w *VrlWriter) WriteFrame(packets ...[]byte) error {
+	frame := VrlFrame{Header: VrlHeader{FrameCount: w.count}, Packets: packets, Crc: w.crc}
+	buf, err := frame.AppendPack(w.buf[:0])
+	if err != nil {
+		return err
+	}
+	w.buf = buf
+	w.count = (w.count + 1) & 0xFFF
+	_, err = w.w.Write(w.buf)
+	return err
+}
@@ -0,0 +1,280 @@
+/*
+ * Copyright (C) 2024 Geon Technologies, LLC
+ *
+ * This file is part of vrtgen-go.
+ *
+ * vrtgen-go is free software: you can redistribute it and/or modify it under the
+ * terms of the GNU Lesser General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * vrtgen-go is distributed in the hope that it will be useful, but WITHOUT ANY
+ * WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS
+ * FOR A PARTICULAR PURPOSE.  See the GNU Lesser General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with this program.  If not, see http://www.gnu.org/licenses/.
+ */
+
+package vita49
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVrlHeader(t *testing.T) {
+	h := VrlHeader{FrameCount: 0x123, FrameSize: 5}
+	packed := h.Pack()
+	assert.Equal(t, []byte{0x56, 0x52, 0x4C, 0x50, 0x12, 0x30, 0, 5}, packed)
+	unpacked := VrlHeader{}
+	assert.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, h, unpacked)
+
+	packed[0] = 0
+	assert.ErrorIs(t, unpacked.UnmarshalBinary(packed), ErrFrameAlignment)
+	assert.ErrorIs(t, unpacked.UnmarshalBinary(packed[:4]), ErrShortBuffer)
+}
+
+func vrlTestPackets() [][]byte {
+	return [][]byte{
+		(&SignalDataPacket{Payload: []byte{1, 2, 3, 4}}).Pack(),
+		(&ContextPacket{StreamID: 7}).Pack(),
+	}
+}
+
+func TestVrlFrame(t *testing.T) {
+	packets := vrlTestPackets()
+	f := VrlFrame{Header: VrlHeader{FrameCount: 9}, Packets: packets}
+	assert.Equal(t, uint32(32), f.Size())
+	// Pack
+	packed := f.Pack()
+	expected := []byte{
+		0x56, 0x52, 0x4C, 0x50,
+		0, 0x90, 0, 8,
+		0, 0, 0, 2,
+		1, 2, 3, 4,
+		0x40, 0, 0, 3,
+		0, 0, 0, 7,
+		0, 0, 0, 0,
+		0x56, 0x45, 0x4E, 0x44,
+	}
+	assert.Equal(t, expected, packed)
+	// Unpack
+	unpacked := VrlFrame{}
+	assert.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, f, unpacked)
+
+	// With CRC
+	f.Crc = true
+	packed = f.Pack()
+	assert.Equal(t, expected[:28], packed[:28])
+	assert.Equal(t, crc32.ChecksumIEEE(packed[:28]), binary.BigEndian.Uint32(packed[28:]))
+	unpacked = VrlFrame{}
+	assert.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Equal(t, f, unpacked)
+
+	// Empty frame
+	empty := VrlFrame{}
+	packed = empty.Pack()
+	assert.Equal(t, []byte{0x56, 0x52, 0x4C, 0x50, 0, 0, 0, 3, 0x56, 0x45, 0x4E, 0x44}, packed)
+	assert.NoError(t, unpacked.UnmarshalBinary(packed))
+	assert.Empty(t, unpacked.Packets)
+
+	// AppendPack
+	appended, err := f.AppendPack([]byte{0xAA})
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte{0xAA}, f.Pack()...), appended)
+}
+
+func TestVrlFramePackErrors(t *testing.T) {
+	packet := vrlTestPackets()[0]
+	// The largest frame the 20-bit FrameSize holds, and one word more
+	large := (&SignalDataPacket{Payload: make([]byte, MaxPacketBytes-headerBytes)}).Pack()
+	var fits [][]byte
+	for n := MaxVrlFrameBytes - vrlMinFrameSize; n >= uint32(len(large)); n -= uint32(len(large)) {
+		fits = append(fits, large)
+	}
+	cases := []struct {
+		name    string
+		packets [][]byte
+	}{
+		{"Not whole words", [][]byte{{1, 2, 3}}},
+		{"Shorter than a header", [][]byte{{0x10, 0}}},
+		{"PacketSize disagrees", [][]byte{packet[:len(packet)-4]}},
+		{"Packet after a good one", [][]byte{packet, append(append([]byte{}, packet...), 0, 0, 0, 0)}},
+		{"Frame too large", append(fits, large)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := VrlFrame{Packets: tc.packets}
+			n, err := f.PackInto(make([]byte, f.Size()))
+			assert.ErrorIs(t, err, ErrSizeMismatch)
+			assert.Zero(t, n)
+
+			dst, err := f.AppendPack([]byte{0xAA})
+			assert.ErrorIs(t, err, ErrSizeMismatch)
+			assert.Equal(t, []byte{0xAA}, dst)
+
+			assert.Panics(t, func() { f.Pack() })
+		})
+	}
+}
+
+func TestVrlCrc(t *testing.T) {
+	// The trailer holds the IEEE 802.3 CRC-32 of the frame up to the trailer,
+	// whose catalogued check value over "123456789" is 0xCBF43926
+	assert.Equal(t, uint32(0xCBF43926), crc32.ChecksumIEEE([]byte("123456789")))
+
+	// An empty frame: the CRC covers the alignment word and the frame count
+	// and size word
+	f := VrlFrame{Crc: true}
+	packed := f.Pack()
+	assert.Equal(t, []byte{0x56, 0x52, 0x4C, 0x50, 0, 0, 0, 3, 0x9B, 0xA1, 0x89, 0xD0}, packed)
+	assert.NoError(t, (&VrlFrame{}).UnmarshalBinary(packed))
+
+	// A corrupted trailer reports the CRC received and the one computed
+	packed[11] = 0xD1
+	err := (&VrlFrame{}).UnmarshalBinary(packed)
+	assert.ErrorIs(t, err, ErrCrc)
+	assert.Equal(t, &CrcError{FrameCount: 0, Received: 0x9BA189D1, Computed: 0x9BA189D0}, err)
+}
+
+func TestVrlFrameUnmarshalBinary(t *testing.T) {
+	valid := (&VrlFrame{Packets: vrlTestPackets(), Crc: true}).Pack()
+	noCrc := (&VrlFrame{Packets: vrlTestPackets()}).Pack()
+	corrupt := func(valid []byte, offset int, value byte) []byte {
+		buf := append([]byte{}, valid...)
+		buf[offset] = value
+		return buf
+	}
+
+	cases := []struct {
+		name     string
+		buf      []byte
+		expected error
+	}{
+		{"Valid", valid, nil},
+		{"Trailing bytes", append(append([]byte{}, valid...), 0, 0, 0, 0), nil},
+		{"Frame alignment", corrupt(valid, 3, 0), ErrFrameAlignment},
+		{"CRC", corrupt(valid, 13, 0xFF), ErrCrc},
+		{"Short", valid[:len(valid)-4], ErrShortBuffer},
+		{"Frame size too small", corrupt(noCrc, 7, 2), ErrSizeMismatch},
+		{"Packet overruns frame", corrupt(noCrc, 11, 3), ErrSizeMismatch},
+		{"Zero packet size", corrupt(noCrc, 11, 0), ErrSizeMismatch},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := VrlFrame{}
+			err := f.UnmarshalBinary(tc.buf)
+			if tc.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestVrlWriterReader(t *testing.T) {
+	packets := vrlTestPackets()
+	var stream bytes.Buffer
+	w := NewVrlWriter(&stream, true)
+	assert.NoError(t, w.WriteFrame(packets...))
+	assert.NoError(t, w.WriteFrame())
+	assert.NoError(t, w.WriteFrame(packets[1]))
+
+	r := NewVrlReader(bytes.NewReader(stream.Bytes()))
+	frame, err := r.ReadFrame()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0), frame.Header.FrameCount)
+	assert.True(t, frame.Crc)
+	assert.Equal(t, packets, frame.Packets)
+
+	// Packets are reassembled across frames, skipping empty ones
+	r = NewVrlReader(bytes.NewReader(stream.Bytes()))
+	for _, expected := range [][]byte{packets[0], packets[1], packets[1]} {
+		packet, err := r.ReadPacket()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, packet)
+	}
+	_, err = r.ReadPacket()
+	assert.Equal(t, io.EOF, err)
+
+	// Frame Count wraps at 12 bits
+	w.count = 0xFFF
+	stream.Reset()
+	assert.NoError(t, w.WriteFrame())
+	assert.NoError(t, w.WriteFrame())
+	r = NewVrlReader(&stream)
+	for _, expected := range []uint16{0xFFF, 0} {
+		frame, err := r.ReadFrame()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, frame.Header.FrameCount)
+	}
+
+	// Packets must match their declared size
+	assert.ErrorIs(t, w.WriteFrame(packets[0][:4]), ErrSizeMismatch)
+	assert.ErrorIs(t, w.WriteFrame([]byte{1}), ErrSizeMismatch)
+}
+
+func TestVrlReaderRecovery(t *testing.T) {
+	packets := vrlTestPackets()
+	good := (&VrlFrame{Packets: packets, Crc: true}).Pack()
+	badCrc := append([]byte{}, good...)
+	badCrc[12] ^= 0xFF
+
+	var stream []byte
+	stream = append(stream, 0xDE, 0xAD, 0xBE, 0xEF, 0x56, 0x52, 0x4C, 0x00)
+	stream = append(stream, good...)
+	stream = append(stream, badCrc...)
+	stream = append(stream, good...)
+	stream = append(stream, good[:20]...)
+
+	r := NewVrlReader(bytes.NewReader(stream))
+	_, err := r.ReadFrame()
+	assert.ErrorIs(t, err, ErrFrameAlignment)
+	frame, err := r.ReadFrame()
+	assert.NoError(t, err)
+	assert.Equal(t, packets, frame.Packets)
+	_, err = r.ReadFrame()
+	assert.ErrorIs(t, err, ErrCrc)
+	frame, err = r.ReadFrame()
+	assert.NoError(t, err)
+	assert.Equal(t, packets, frame.Packets)
+	_, err = r.ReadFrame()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	// Garbage with no frame alignment word
+	r = NewVrlReader(bytes.NewReader([]byte{0x56, 0x52, 0x4C, 0x51, 0, 0, 0, 0, 1, 2}))
+	_, err = r.ReadFrame()
+	assert.ErrorIs(t, err, ErrFrameAlignment)
+	_, err = r.ReadFrame()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	r = NewVrlReader(bytes.NewReader(nil))
+	_, err = r.ReadFrame()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestVrlReaderLargeFrame(t *testing.T) {
+	payload := make([]byte, 8192)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	packet := (&SignalDataPacket{Payload: payload}).Pack()
+	var stream bytes.Buffer
+	w := NewVrlWriter(&stream, false)
+	assert.NoError(t, w.WriteFrame(packet, packet))
+	r := NewVrlReader(&stream)
+	frame, err := r.ReadFrame()
+	assert.NoError(t, err)
+	assert.False(t, frame.Crc)
+	assert.Equal(t, [][]byte{packet, packet}, frame.Packets)
+}